@@ -6,13 +6,24 @@ import (
 	"giftcard/config"
 	"giftcard/internal/adaptor/giftcard"
 	"giftcard/internal/adaptor/logstash"
+	"giftcard/internal/adaptor/metrics"
 	"giftcard/internal/adaptor/postgres"
 	"giftcard/internal/adaptor/redis"
 	"giftcard/internal/adaptor/trace"
 	customerModule "giftcard/internal/modules/customer"
+	exchangeRateModule "giftcard/internal/modules/exchangerate"
+	exportModule "giftcard/internal/modules/export"
 	orderModule "giftcard/internal/modules/order"
+	orderImportModule "giftcard/internal/modules/orderimport"
+	outboxModule "giftcard/internal/modules/outbox"
+	reconcileModule "giftcard/internal/modules/reconcile"
+	refundModule "giftcard/internal/modules/refund"
+	scheduleModule "giftcard/internal/modules/schedule"
 	shopModule "giftcard/internal/modules/shop"
+	webhookModule "giftcard/internal/modules/webhook"
 	"giftcard/internal/server"
+	"giftcard/pkg/eventbus"
+	"giftcard/pkg/health"
 	"giftcard/pkg/logger"
 	"go.uber.org/fx"
 	"log"
@@ -27,18 +38,35 @@ func Start() {
 
 	fxNew := fx.New(
 		fx.Provide(config.C),
+		fx.Invoke(config.Watch),
+		fx.Provide(postgres.NewPostgres),
 		fx.Provide(postgres.DB),
 		fx.Provide(redis.NewRedis),
+		fx.Provide(metrics.NewRegistry),
+		fx.Provide(health.NewRegistry),
+		fx.Provide(metrics.NewWorkers),
+		fx.Provide(eventbus.New),
 		customerModule.Module,
 		orderModule.Module,
 		shopModule.Module,
+		webhookModule.Module,
+		outboxModule.Module,
+		reconcileModule.Module,
+		orderImportModule.Module,
+		exportModule.Module,
+		exchangeRateModule.Module,
+		scheduleModule.Module,
+		refundModule.Module,
 		fx.Provide(giftcard.NewGiftCard),
 		//fx.Provide(config.NewLogger),
+		fx.Provide(logstash.NewFile),
 		fx.Provide(logstash.NewLogStash),
 		fx.Invoke(trace.InitGlobalTracer),
 		fx.Invoke(logger.InitGlobalLogger),
 		fx.Provide(server.NewServer),
+		fx.Provide(server.NewGrpcServer),
 		fx.Invoke(serve),
+		fx.Invoke(serveGrpc),
 	)
 
 	if err := fxNew.Start(context.Background()); err != nil {
@@ -69,3 +97,14 @@ func serve(lc fx.Lifecycle, server server.IServer) {
 		},
 	})
 }
+
+func serveGrpc(lc fx.Lifecycle, server server.IGrpcServer) {
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			return server.Run()
+		},
+		OnStop: func(ctx context.Context) error {
+			return server.Shutdown()
+		},
+	})
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"context"
+	"giftcard/config"
+	"giftcard/internal/adaptor/giftcard"
+	"giftcard/internal/adaptor/logstash"
+	"giftcard/internal/adaptor/metrics"
+	"giftcard/internal/adaptor/postgres"
+	"giftcard/internal/adaptor/redis"
+	"giftcard/internal/adaptor/trace"
+	"giftcard/pkg/health"
+	"giftcard/pkg/logger"
+	"go.uber.org/fx"
+	"time"
+)
+
+// RunCommand starts the shared adaptors with the given options, runs fn and stops
+// everything again. Use fx.Populate in options to get the dependencies fn needs.
+func RunCommand(fn func(ctx context.Context) error, options ...fx.Option) error {
+	fxNew := fx.New(
+		fx.NopLogger,
+		fx.Provide(config.C),
+		fx.Provide(postgres.NewPostgres),
+		fx.Provide(postgres.DB),
+		fx.Provide(redis.NewRedis),
+		fx.Provide(metrics.NewRegistry),
+		fx.Provide(health.NewRegistry),
+		fx.Provide(metrics.NewWorkers),
+		fx.Provide(giftcard.NewGiftCard),
+		fx.Provide(logstash.NewFile),
+		fx.Provide(logstash.NewLogStash),
+		fx.Invoke(trace.InitGlobalTracer),
+		fx.Invoke(logger.InitGlobalLogger),
+		fx.Options(options...),
+	)
+
+	startCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := fxNew.Start(startCtx); err != nil {
+		return err
+	}
+
+	runErr := fn(context.Background())
+
+	stopCtx, stopCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer stopCancel()
+	if err := fxNew.Stop(stopCtx); err != nil && runErr == nil {
+		return err
+	}
+	return runErr
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"giftcard/app"
+	"giftcard/internal/modules/export/repository"
+	"giftcard/internal/modules/export/usecase"
+	"giftcard/model"
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+	"io"
+	"os"
+	"time"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export orders and spend for accounting",
+	Long: `Streams the orders created in [from, to) with their invoice line items, totals,
+exchange rates, discount and fee as csv, xlsx or jsonl. The orders are read page by
+page, so large months are never held in memory.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fromFlag, _ := cmd.Flags().GetString("from")
+		toFlag, _ := cmd.Flags().GetString("to")
+		format, _ := cmd.Flags().GetString("format")
+		currency, _ := cmd.Flags().GetString("currency")
+		team, _ := cmd.Flags().GetString("team")
+		output, _ := cmd.Flags().GetString("output")
+
+		from, err := time.ParseInLocation(dateLayout, fromFlag, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid --from, expected %s: %w", dateLayout, err)
+		}
+		to := time.Now()
+		if toFlag != "" {
+			to, err = time.ParseInLocation(dateLayout, toFlag, time.Local)
+			if err != nil {
+				return fmt.Errorf("invalid --to, expected %s: %w", dateLayout, err)
+			}
+			// --to is inclusive for the user, the use case expects an exclusive bound
+			to = to.AddDate(0, 0, 1)
+		}
+		filter := repository.OrderFilter{
+			From:     from,
+			To:       to,
+			Currency: currency,
+			Team:     team,
+		}
+
+		var us usecase.IExportUseCase
+		return app.RunCommand(func(ctx context.Context) error {
+			var w io.Writer = os.Stdout
+			if output != "" {
+				file, err := os.Create(output)
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				w = file
+			}
+			buffered := bufio.NewWriter(w)
+			lines, err := us.WriteExport(ctx, filter, format, buffered)
+			if err != nil {
+				return err
+			}
+			if err := buffered.Flush(); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "%d lines exported\n", lines)
+			return nil
+		},
+			fx.Provide(usecase.NewExportUseCase),
+			fx.Provide(repository.NewExportRepository),
+			fx.Populate(&us),
+		)
+	},
+}
+
+func init() {
+	exportCmd.Flags().String("from", "", "first day to export (YYYY-MM-DD)")
+	exportCmd.Flags().String("to", "", "last day to export (YYYY-MM-DD), defaults to now")
+	exportCmd.Flags().String("format", model.ExportCSV, "file format, csv, xlsx or jsonl")
+	exportCmd.Flags().String("currency", "", "only orders paid from this wallet currency")
+	exportCmd.Flags().String("team", "", "only orders of this team")
+	exportCmd.Flags().String("output", "", "export file, defaults to stdout")
+	_ = exportCmd.MarkFlagRequired("from")
+	rootCmd.AddCommand(exportCmd)
+}
@@ -1,24 +1,127 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"giftcard/app"
 	"giftcard/internal/adaptor/postgres"
 	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
 )
 
 var migrateCmd = &cobra.Command{
 	Use:   "migrate",
 	Short: "Manage database migrations",
-	Long:  `Commands to manage database migrations.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("Applying models migration done.")
-		if err := postgres.MigrateModels(); err != nil {
-			fmt.Println("error: ", err)
-			panic(err)
+	Long: `Commands to manage database migrations. Migrations are the versioned sql scripts in
+internal/adaptor/postgres/migrations, embedded in the binary. Each one runs in a
+transaction and concurrent runs wait for each other on an advisory lock.`,
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply every pending migration",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runMigrator(func(ctx context.Context, migrator *postgres.Migrator) error {
+			applied, err := migrator.Up(ctx)
+			for _, migration := range applied {
+				fmt.Printf("applied %04d_%s\n", migration.Version, migration.Name)
+			}
+			if err != nil {
+				return err
+			}
+			if len(applied) == 0 {
+				fmt.Println("no pending migrations")
+			}
+			return nil
+		})
+	},
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down N",
+	Short: "Revert the last N applied migrations",
+	Long: `Reverts the last N applied migrations, newest first. Reverting the baseline drops
+every table.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		steps, err := strconv.Atoi(args[0])
+		if err != nil || steps < 1 {
+			return fmt.Errorf("invalid N %q, expected a positive number", args[0])
+		}
+		return runMigrator(func(ctx context.Context, migrator *postgres.Migrator) error {
+			reverted, err := migrator.Down(ctx, steps)
+			for _, migration := range reverted {
+				fmt.Printf("reverted %04d_%s\n", migration.Version, migration.Name)
+			}
+			return err
+		})
+	},
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "List the migrations and whether they are applied",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runMigrator(func(ctx context.Context, migrator *postgres.Migrator) error {
+			statuses, err := migrator.Status(ctx)
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+			for _, status := range statuses {
+				appliedAt := "pending"
+				if status.AppliedAt != nil {
+					appliedAt = status.AppliedAt.Format(time.RFC3339)
+				}
+				fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+			}
+			return w.Flush()
+		})
+	},
+}
+
+var migrateCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Write empty up and down scripts for the next version",
+	Args:  cobra.ExactArgs(1),
+	// writing the scripts needs neither the configuration nor the database
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, _ := cmd.Flags().GetString("dir")
+		up, down, err := postgres.CreateMigration(dir, args[0])
+		if err != nil {
+			return err
 		}
+		fmt.Println("created", up)
+		fmt.Println("created", down)
+		return nil
 	},
 }
 
+// runMigrator starts the database and runs fn with a migrator on the primary
+func runMigrator(fn func(ctx context.Context, migrator *postgres.Migrator) error) error {
+	var db *gorm.DB
+	return app.RunCommand(func(ctx context.Context) error {
+		migrator, err := postgres.NewMigrator(db)
+		if err != nil {
+			return err
+		}
+		return fn(ctx, migrator)
+	}, fx.Populate(&db))
+}
+
 func init() {
+	migrateCreateCmd.Flags().String("dir", postgres.MigrationsDir, "directory of the migration scripts")
+	migrateCmd.AddCommand(migrateUpCmd, migrateDownCmd, migrateStatusCmd, migrateCreateCmd)
 	rootCmd.AddCommand(migrateCmd)
 }
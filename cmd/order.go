@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"giftcard/app"
+	exchangeRateRepository "giftcard/internal/modules/exchangerate/repository"
+	exchangeRateUseCase "giftcard/internal/modules/exchangerate/usecase"
+	orderEvents "giftcard/internal/modules/order/events"
+	orderRepository "giftcard/internal/modules/order/repository"
+	orderUseCase "giftcard/internal/modules/order/usecase"
+	"giftcard/internal/modules/orderimport/repository"
+	"giftcard/internal/modules/orderimport/usecase"
+	shopUseCase "giftcard/internal/modules/shop/usecase"
+	"giftcard/model"
+	"giftcard/pkg/utils"
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+var orderCmd = &cobra.Command{
+	Use:   "order",
+	Short: "Manage orders",
+	Long:  `Commands to manage gift card orders.`,
+}
+
+var orderImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Create orders in bulk from a csv file",
+	Long: `Validates every row of the csv file against the catalog, then creates the provider
+orders, chunk-size rows per order, and writes the result of each row as csv.
+The file needs the product_id, sku, product_type, quote and quantity columns.
+With --async the import is only queued and the server workers process it.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filePath, _ := cmd.Flags().GetString("file")
+		chunkSize, _ := cmd.Flags().GetInt("chunk-size")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		principal, _ := cmd.Flags().GetString("principal")
+		team, _ := cmd.Flags().GetString("team")
+		output, _ := cmd.Flags().GetString("output")
+		async, _ := cmd.Flags().GetBool("async")
+
+		file, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		var us usecase.IOrderImportUseCase
+		return app.RunCommand(func(ctx context.Context) error {
+			ctx = utils.WithTeam(utils.WithPrincipal(ctx, principal), team)
+			orderImport, err := us.CreateImport(ctx, filepath.Base(filePath), file, chunkSize)
+			if err != nil {
+				var invalidRows *usecase.InvalidRowsError
+				if errors.As(err, &invalidRows) {
+					for _, row := range invalidRows.Rows {
+						fmt.Fprintf(os.Stderr, "row %d %s: %s\n", row.Row, row.Column, row.Message)
+					}
+				}
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "import %d queued with %d rows\n", orderImport.ID, orderImport.TotalRows)
+			if async {
+				return nil
+			}
+
+			orderImport, err = us.RunImport(ctx, orderImport.ID, concurrency)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "import %d %s: %d succeeded, %d failed, %d unknown\n",
+				orderImport.ID, orderImport.Status, orderImport.SucceededRows, orderImport.FailedRows, orderImport.UnknownRows)
+
+			rows, err := us.ListRows(ctx, orderImport.ID, "")
+			if err != nil {
+				return err
+			}
+			var w io.Writer = os.Stdout
+			if output != "" {
+				file, err := os.Create(output)
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				w = file
+			}
+			return writeImportRows(w, rows)
+		},
+			fx.Provide(usecase.NewOrderImportUseCase),
+			fx.Provide(repository.NewOrderImportRepository),
+			fx.Provide(orderUseCase.NewOrderUseCase),
+			fx.Provide(orderRepository.NewOrderRepository),
+			fx.Provide(orderEvents.NewRedisBroker),
+			fx.Provide(exchangeRateUseCase.NewExchangeRateUseCase),
+			fx.Provide(exchangeRateRepository.NewExchangeRateRepository),
+			fx.Provide(shopUseCase.NewShopUseCase),
+			fx.Populate(&us),
+		)
+	},
+}
+
+func writeImportRows(w io.Writer, rows []model.OrderImportRow) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"row", "product_id", "sku", "status", "order_id", "error"}); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := writer.Write([]string{
+			strconv.Itoa(row.RowNumber),
+			row.ProductID,
+			row.SKU,
+			row.Status,
+			row.OrderID,
+			row.Error,
+		}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func init() {
+	orderImportCmd.Flags().String("file", "", "csv file to import")
+	orderImportCmd.Flags().Int("chunk-size", 0, "rows per provider order, defaults to order_import.chunk_size")
+	orderImportCmd.Flags().Int("concurrency", 0, "provider orders in flight, defaults to order_import.concurrency")
+	orderImportCmd.Flags().String("principal", os.Getenv("USER"), "principal recorded as the orders creator")
+	orderImportCmd.Flags().String("team", "", "team recorded on the orders")
+	orderImportCmd.Flags().String("output", "", "row results file, defaults to stdout")
+	orderImportCmd.Flags().Bool("async", false, "only queue the import for the server workers")
+	_ = orderImportCmd.MarkFlagRequired("file")
+	orderCmd.AddCommand(orderImportCmd)
+	rootCmd.AddCommand(orderCmd)
+}
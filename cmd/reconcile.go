@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"giftcard/app"
+	"giftcard/internal/modules/reconcile/repository"
+	"giftcard/internal/modules/reconcile/usecase"
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+	"io"
+	"os"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "Reconcile local orders with the provider",
+	Long: `Walks the local orders created in [from, to), fetches each one from the provider
+and reports status, total and line item mismatches, missing records and stuck orders.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fromFlag, _ := cmd.Flags().GetString("from")
+		toFlag, _ := cmd.Flags().GetString("to")
+		format, _ := cmd.Flags().GetString("format")
+		output, _ := cmd.Flags().GetString("output")
+		fix, _ := cmd.Flags().GetBool("fix")
+
+		from, err := time.ParseInLocation(dateLayout, fromFlag, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid --from, expected %s: %w", dateLayout, err)
+		}
+		to := time.Now()
+		if toFlag != "" {
+			to, err = time.ParseInLocation(dateLayout, toFlag, time.Local)
+			if err != nil {
+				return fmt.Errorf("invalid --to, expected %s: %w", dateLayout, err)
+			}
+			// --to is inclusive for the user, the use case expects an exclusive bound
+			to = to.AddDate(0, 0, 1)
+		}
+
+		var write func(io.Writer, usecase.Report) error
+		switch format {
+		case usecase.ReportFormatJSON:
+			write = usecase.WriteJSON
+		case usecase.ReportFormatCSV:
+			write = usecase.WriteCSV
+		default:
+			return fmt.Errorf("invalid --format %q, expected json or csv", format)
+		}
+
+		var us usecase.IReconcileUseCase
+		return app.RunCommand(func(ctx context.Context) error {
+			report, err := us.Reconcile(ctx, from, to, fix)
+			if err != nil {
+				return err
+			}
+
+			var w io.Writer = os.Stdout
+			if output != "" {
+				file, err := os.Create(output)
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				w = file
+			}
+			return write(w, report)
+		},
+			fx.Provide(usecase.NewReconcileUseCase),
+			fx.Provide(repository.NewReconcileRepository),
+			fx.Populate(&us),
+		)
+	},
+}
+
+func init() {
+	reconcileCmd.Flags().String("from", "", "first day to reconcile (YYYY-MM-DD)")
+	reconcileCmd.Flags().String("to", "", "last day to reconcile (YYYY-MM-DD), defaults to now")
+	reconcileCmd.Flags().String("format", usecase.ReportFormatJSON, "report format, json or csv")
+	reconcileCmd.Flags().String("output", "", "report file, defaults to stdout")
+	reconcileCmd.Flags().Bool("fix", false, "move mismatching local statuses to the provider status")
+	_ = reconcileCmd.MarkFlagRequired("from")
+	rootCmd.AddCommand(reconcileCmd)
+}
@@ -3,19 +3,57 @@ package cmd
 import (
 	"fmt"
 	"giftcard/app"
+	"giftcard/config"
+	"giftcard/pkg/utils"
 	"github.com/spf13/cobra"
 	"os"
 )
 
+const (
+	configEnv  = "GIFTCARD_CONFIG"
+	profileEnv = "GIFTCARD_PROFILE"
+)
+
+var (
+	configPath string
+	profile    string
+)
+
 var rootCmd = &cobra.Command{
 	Use:   "giftcard",
 	Short: "Base command for gift card",
 	Long:  "Base command for gift card",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return config.Load(resolveConfigPath())
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		app.Start()
 	},
 }
 
+func init() {
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "config file, defaults to $"+configEnv+" or ./config.yaml")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "config profile read from config/config-<profile>.yaml, defaults to $"+profileEnv)
+}
+
+// resolveConfigPath picks the config file, the flag wins over the environment and an explicit
+// file wins over a profile. Empty means config.yaml in the working directory.
+func resolveConfigPath() string {
+	if configPath != "" {
+		return configPath
+	}
+	if path := os.Getenv(configEnv); path != "" {
+		return path
+	}
+	if profile == "" {
+		profile = os.Getenv(profileEnv)
+	}
+	if profile != "" {
+		return utils.GetConfigPath(profile)
+	}
+	return ""
+}
+
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
@@ -0,0 +1,18 @@
+package config
+
+type Approval struct {
+	Enabled  bool             `mapstructure:"enabled"`
+	Policies []ApprovalPolicy `mapstructure:"policies" validate:"dive"`
+}
+
+// ApprovalPolicy matches an order when every criteria it sets matches,
+// empty criteria match any order. MinAmount is in Currency, orders paid from another wallet
+// are not compared against it.
+type ApprovalPolicy struct {
+	Name         string   `mapstructure:"name"`
+	MinAmount    float64  `mapstructure:"min_amount"`
+	Currency     string   `mapstructure:"currency"`
+	ProductTypes []string `mapstructure:"product_types"`
+	Countries    []string `mapstructure:"countries"`
+	Approvers    int      `mapstructure:"approvers"`
+}
@@ -1,43 +1,148 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"github.com/spf13/viper"
 	"os"
+	"reflect"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	// EnvPrefix prefixes the environment overrides, service.client_secret is read from
+	// GIFTCARD_SERVICE_CLIENT_SECRET
+	EnvPrefix = "GIFTCARD"
+	// FileEnvSuffix marks an override read from a file, GIFTCARD_POSTGRES_PASSWORD_FILE
+	// holds the path of a file containing the database password
+	FileEnvSuffix = "_FILE"
 )
 
 var (
-	// Global config
-	confs = Config{}
+	// Global config, replaced as a whole on reload so readers never see a half applied change
+	confs atomic.Pointer[Config]
+	// configFile is the yaml file the running config was read from, empty when there was none
+	configFile string
 )
 
+func init() {
+	confs.Store(&Config{})
+}
+
 type Config struct {
-	Service  GiftCard `mapstructure:"service"`
-	DataBase Postgres `mapstructure:"postgres"`
-	Redis    Redis    `mapstructure:"redis"`
-	Jaeger   tracer   `yaml:"jaeger"`
-	Logstash Logstash `yaml:"logstash"`
+	Service   GiftCard    `mapstructure:"service"`
+	DataBase  Postgres    `mapstructure:"postgres"`
+	Redis     Redis       `mapstructure:"redis"`
+	Tracer    Tracer      `mapstructure:"tracer"`
+	Logstash  Logstash    `mapstructure:"logstash"`
+	Log       Log         `mapstructure:"log"`
+	Grpc      Grpc        `mapstructure:"grpc"`
+	SSE       SSE         `mapstructure:"sse"`
+	Webhook   Webhook     `mapstructure:"webhook"`
+	Outbox    Outbox      `mapstructure:"outbox"`
+	Reconcile Reconcile   `mapstructure:"reconcile"`
+	Approval  Approval    `mapstructure:"approval"`
+	Import    OrderImport `mapstructure:"order_import"`
+	Export    Export      `mapstructure:"export"`
+	Invoice   Invoice     `mapstructure:"invoice"`
+	Schedule  Schedule    `mapstructure:"schedule"`
+	Refund    Refund      `mapstructure:"refund"`
+	OrderLock OrderLock   `mapstructure:"order_lock"`
+	Health    Health      `mapstructure:"health"`
 	//Debug    bool   `mapstructure:"debug"`
 }
 
-func init() {
-	dir, _ := os.Getwd()
+// Load reads the configuration in layers, each one overriding the previous: the defaults,
+// the yaml file, GIFTCARD_* environment variables and GIFTCARD_*_FILE secret files.
+// An empty path falls back to config.yaml in the working directory, which may be missing
+// when the environment carries everything.
+func Load(path string) error {
+	loaded, file, err := read(path)
+	if err != nil {
+		return err
+	}
+	configFile = file
+	confs.Store(loaded)
+	return nil
+}
+
+// read builds and validates a config without applying it, it returns the file it read
+func read(path string) (*Config, string, error) {
+	v := viper.New()
+	setDefaults(v)
 
-	viper.AddConfigPath(dir)
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
+	if path != "" {
+		v.SetConfigFile(path)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, "", fmt.Errorf("failed to read config file %s, %w", path, err)
+		}
+	} else {
+		dir, _ := os.Getwd()
+		v.AddConfigPath(dir)
+		v.SetConfigName("config")
+		v.SetConfigType("yaml")
+		var notFound viper.ConfigFileNotFoundError
+		if err := v.ReadInConfig(); err != nil && !errors.As(err, &notFound) {
+			return nil, "", fmt.Errorf("failed to read config file, %w", err)
+		}
+	}
+
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	for _, key := range keys(reflect.TypeOf(Config{}), "") {
+		if err := v.BindEnv(key); err != nil {
+			return nil, "", err
+		}
+		fileEnv := EnvName(key) + FileEnvSuffix
+		if file := os.Getenv(fileEnv); file != "" {
+			content, err := os.ReadFile(file)
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to read %s from %s, %w", key, fileEnv, err)
+			}
+			v.Set(key, strings.TrimRight(string(content), "\r\n"))
+		}
+	}
+
+	var loaded Config
+	if err := v.Unmarshal(&loaded); err != nil {
+		return nil, "", fmt.Errorf("failed to decode config, %w", err)
+	}
+	if err := loaded.validate(); err != nil {
+		return nil, "", err
+	}
+	return &loaded, v.ConfigFileUsed(), nil
+}
+
+// EnvName is the environment variable overriding a config key
+func EnvName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
 
-	if err := viper.ReadInConfig(); err != nil {
-		panic(err)
+// keyName is the viper key of a struct field, the mapstructure tag or the lowercased field name
+func keyName(field reflect.StructField) string {
+	name := field.Tag.Get("mapstructure")
+	if name == "" {
+		name = field.Name
 	}
-	loadConfigs()
+	return strings.ToLower(name)
 }
 
-func loadConfigs() {
-	if err := viper.Unmarshal(&confs); err != nil {
-		panic(err)
+// keys lists the leaf keys of a config struct the way viper names them
+func keys(t reflect.Type, prefix string) []string {
+	var result []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := prefix + keyName(field)
+		if field.Type.Kind() == reflect.Struct {
+			result = append(result, keys(field.Type, key+".")...)
+			continue
+		}
+		result = append(result, key)
 	}
+	return result
 }
 
 func C() *Config {
-	return &confs
+	return confs.Load()
 }
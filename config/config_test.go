@@ -0,0 +1,154 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const baseConfig = `
+service:
+  client_id: "id"
+postgres:
+  username: "root"
+  schema: "gift_card_db"
+tracer:
+  endpoint: "localhost:4317"
+logstash:
+  endpoint: "localhost:5000"
+`
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		env   map[string]string
+		check func(t *testing.T, c *Config)
+		err   []string
+	}{
+		{
+			name: "secrets from the environment",
+			file: baseConfig,
+			env: map[string]string{
+				"GIFTCARD_SERVICE_CLIENT_SECRET": "secret",
+				"GIFTCARD_GRPC_AUTH_TOKENS":      "first,second",
+			},
+			check: func(t *testing.T, c *Config) {
+				if c.Service.ClientSecret != "secret" {
+					t.Errorf("client_secret = %q", c.Service.ClientSecret)
+				}
+				if !reflect.DeepEqual(c.Grpc.AuthTokens, []string{"first", "second"}) {
+					t.Errorf("auth_tokens = %q", c.Grpc.AuthTokens)
+				}
+				if c.DataBase.Host != "localhost" || c.Grpc.Port != 9090 {
+					t.Errorf("defaults were not applied, postgres.host %q grpc.port %d", c.DataBase.Host, c.Grpc.Port)
+				}
+			},
+		},
+		{
+			name: "environment over the file",
+			file: baseConfig + "grpc:\n  auth_disabled: true\n",
+			env: map[string]string{
+				"GIFTCARD_SERVICE_CLIENT_SECRET": "secret",
+				"GIFTCARD_SERVICE_CLIENT_ID":     "other",
+			},
+			check: func(t *testing.T, c *Config) {
+				if c.Service.ClientID != "other" {
+					t.Errorf("client_id = %q", c.Service.ClientID)
+				}
+			},
+		},
+		{
+			name: "secret file",
+			file: baseConfig + "grpc:\n  auth_disabled: true\n",
+			env: map[string]string{
+				"GIFTCARD_SERVICE_CLIENT_SECRET_FILE": "secret",
+			},
+			check: func(t *testing.T, c *Config) {
+				if c.Service.ClientSecret != "from a file" {
+					t.Errorf("client_secret = %q", c.Service.ClientSecret)
+				}
+			},
+		},
+		{
+			name: "every missing setting is listed",
+			file: baseConfig,
+			err: []string{
+				"service.client_secret is required",
+				"grpc.auth_tokens is required unless grpc.auth_disabled is set",
+			},
+		},
+		{
+			name: "blank token",
+			file: baseConfig + "grpc:\n  auth_tokens: [\"token\", \" \"]\n",
+			env: map[string]string{
+				"GIFTCARD_SERVICE_CLIENT_SECRET": "secret",
+			},
+			err: []string{"grpc.auth_tokens[1] must not be blank"},
+		},
+		{
+			name: "amount policy without a currency",
+			file: baseConfig + "grpc:\n  auth_disabled: true\napproval:\n  policies:\n    - name: large\n      min_amount: 1000\n",
+			env: map[string]string{
+				"GIFTCARD_SERVICE_CLIENT_SECRET": "secret",
+			},
+			err: []string{"approval.policies[0].currency is required with min_amount"},
+		},
+		{
+			name: "invalid value",
+			file: baseConfig + "grpc:\n  auth_disabled: true\n",
+			env: map[string]string{
+				"GIFTCARD_SERVICE_CLIENT_SECRET": "secret",
+				"GIFTCARD_POSTGRES_SSL_MODE":     "sometimes",
+			},
+			err: []string{`postgres.ssl_mode must be one of`, `got "sometimes"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "config.yaml")
+			if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, "secret"), []byte("from a file\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			for key, value := range tt.env {
+				if strings.HasSuffix(key, FileEnvSuffix) {
+					value = filepath.Join(dir, value)
+				}
+				t.Setenv(key, value)
+			}
+
+			loaded, file, err := read(path)
+			if len(tt.err) > 0 {
+				if err == nil {
+					t.Fatal("expected the config to be refused")
+				}
+				for _, problem := range tt.err {
+					if !strings.Contains(err.Error(), problem) {
+						t.Errorf("expected %q in %v", problem, err)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if file != path {
+				t.Errorf("read %s, expected %s", file, path)
+			}
+			tt.check(t, loaded)
+		})
+	}
+}
+
+func TestReadMissingFile(t *testing.T) {
+	_, _, err := read(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err == nil || !strings.Contains(err.Error(), "failed to read config file") {
+		t.Fatalf("expected the missing file to be reported, got %v", err)
+	}
+}
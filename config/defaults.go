@@ -0,0 +1,57 @@
+package config
+
+import "github.com/spf13/viper"
+
+// defaults hold everything that is the same on every deployment, secrets never have one
+var defaults = map[string]any{
+	"service.name":                    "gift card",
+	"service.base_url":                "https://sandbox-api.core.hub.gift",
+	"service.timeout":                 30,
+	"service.retry.max_attempts":      3,
+	"service.retry.backoff":           1000,
+	"log.level":                       "info",
+	"log.file.path":                   "logs/giftcard.log",
+	"log.file.max_size":               100,
+	"log.file.rotate_every":           24,
+	"log.file.max_age":                7,
+	"log.file.max_backups":            10,
+	"log.file.compress":               true,
+	"redis.mode":                      "standalone",
+	"redis.host":                      "localhost",
+	"redis.port":                      "6379",
+	"redis.db":                        0,
+	"postgres.host":                   "localhost",
+	"postgres.port":                   5432,
+	"postgres.ssl_mode":               "disable",
+	"postgres.timezone":               "UTC",
+	"postgres.max_open_conns":         20,
+	"postgres.max_idle_conns":         5,
+	"postgres.conn_max_lifetime":      1800,
+	"postgres.connect_timeout":        5,
+	"postgres.connect_retries":        5,
+	"postgres.connect_retry_interval": 2,
+	"postgres.health_interval":        15,
+	"tracer.enabled":                  true,
+	"tracer.exporter":                 "otlp_grpc",
+	"tracer.insecure":                 true,
+	"tracer.timeout":                  10,
+	"tracer.sample_ratio":             1.0,
+	"tracer.environment":              "dev",
+	"grpc.port":                       9090,
+	"health.cache_ttl":                5,
+	"health.timeout":                  2,
+	"health.critical":                 []string{"postgres", "redis"},
+	"logstash.protocol":               "tcp",
+	"logstash.timeout":                5,
+	"logstash.buffer_size":            4096,
+	"logstash.reconnect_backoff":      500,
+	"logstash.reconnect_max_backoff":  30000,
+	"reconcile.report_dir":            "reports",
+	"invoice.number_prefix":           "INV-",
+}
+
+func setDefaults(v *viper.Viper) {
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+	}
+}
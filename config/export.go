@@ -0,0 +1,7 @@
+package config
+
+type Export struct {
+	PollInterval int `mapstructure:"poll_interval"`
+	Lease        int `mapstructure:"lease"`
+	Retention    int `mapstructure:"retention"`
+}
@@ -1,8 +1,26 @@
 package config
 
 type GiftCard struct {
-	Name         string `mapstructure:"name"`
-	BaseUrl      string `mapstructure:"base_url"`
-	ClientID     string `mapstructure:"client_id"`
-	ClientSecret string `mapstructure:"client_secret"`
+	Name         string `mapstructure:"name" validate:"required"`
+	BaseUrl      string `mapstructure:"base_url" validate:"required,url"`
+	ClientID     string `mapstructure:"client_id" validate:"required"`
+	ClientSecret string `mapstructure:"client_secret" validate:"required"`
+	// Timeout in seconds of one attempt, a call with its retries takes up to
+	// retry.max_attempts times as long plus the backoffs between them
+	Timeout   int               `mapstructure:"timeout" validate:"gte=0" reload:"true"`
+	Retry     ProviderRetry     `mapstructure:"retry" reload:"true"`
+	RateLimit ProviderRateLimit `mapstructure:"rate_limit" reload:"true"`
+}
+
+// ProviderRetry retries the provider calls that failed before an answer arrived
+type ProviderRetry struct {
+	MaxAttempts int `mapstructure:"max_attempts" validate:"gte=1"`
+	// Backoff in milliseconds between two attempts
+	Backoff int `mapstructure:"backoff" validate:"gte=0"`
+}
+
+// ProviderRateLimit caps the calls we make to the provider, zero requests per second means no limit
+type ProviderRateLimit struct {
+	RequestsPerSecond float64 `mapstructure:"requests_per_second" validate:"gte=0"`
+	Burst             int     `mapstructure:"burst" validate:"gte=0"`
 }
@@ -0,0 +1,9 @@
+package config
+
+type Grpc struct {
+	Port               int      `mapstructure:"port" validate:"gte=0,lte=65535"`
+	AuthTokens         []string `mapstructure:"auth_tokens"`
+	StatusPollInterval int      `mapstructure:"status_poll_interval"`
+	// AuthDisabled lets calls in without a token, auth_tokens is required otherwise
+	AuthDisabled bool `mapstructure:"auth_disabled"`
+}
@@ -0,0 +1,10 @@
+package config
+
+type Health struct {
+	// seconds a readiness report is served from cache, zero runs the checks on every probe
+	CacheTTL int `mapstructure:"cache_ttl" validate:"gte=0" reload:"true"`
+	// seconds a check may take when its adaptor did not set a timeout
+	Timeout int `mapstructure:"timeout" validate:"gte=0" reload:"true"`
+	// checks that make the service not ready when they fail, the others only degrade it
+	Critical []string `mapstructure:"critical" reload:"true"`
+}
@@ -0,0 +1,16 @@
+package config
+
+type Invoice struct {
+	NumberPrefix string         `mapstructure:"number_prefix"`
+	Company      InvoiceCompany `mapstructure:"company"`
+}
+
+// InvoiceCompany is the header printed on the generated invoices, regenerate an
+// invoice to pick up a changed header
+type InvoiceCompany struct {
+	Name    string   `mapstructure:"name"`
+	Address []string `mapstructure:"address"`
+	TaxID   string   `mapstructure:"tax_id"`
+	Email   string   `mapstructure:"email"`
+	Phone   string   `mapstructure:"phone"`
+}
@@ -0,0 +1,28 @@
+package config
+
+type Log struct {
+	Level string `mapstructure:"level" validate:"oneof=debug info warn error" reload:"true"`
+	// levels of single modules, the modules left out follow level
+	Modules map[string]string `mapstructure:"modules" validate:"dive,keys,oneof=giftcard order shop customer http,endkeys,oneof=debug info warn error" reload:"true"`
+	// principals allowed to change levels at runtime, empty disables the runtime changes
+	Admins []string `mapstructure:"admins" reload:"true"`
+	File   LogFile  `mapstructure:"file"`
+}
+
+// LogFile is the local log file, it takes the lines logstash cannot and every line when
+// Always is set
+type LogFile struct {
+	// empty disables the file
+	Path string `mapstructure:"path"`
+	// megabytes the file grows to before it is rotated
+	MaxSize int `mapstructure:"max_size" validate:"gte=0"`
+	// hours after which the file is rotated whatever its size, zero rotates on size only
+	RotateEvery int `mapstructure:"rotate_every" validate:"gte=0"`
+	// days rotated files are kept, zero keeps them
+	MaxAge int `mapstructure:"max_age" validate:"gte=0"`
+	// rotated files that are kept, zero keeps them all
+	MaxBackups int  `mapstructure:"max_backups" validate:"gte=0"`
+	Compress   bool `mapstructure:"compress"`
+	// write every line to the file, not only the ones logstash could not take
+	Always bool `mapstructure:"always"`
+}
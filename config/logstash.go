@@ -1,6 +1,18 @@
 package config
 
+const (
+	LogstashTCP = "tcp"
+	LogstashUDP = "udp"
+)
+
 type Logstash struct {
 	Endpoint string `mapstructure:"endpoint" validate:"required"`
-	Timeout  int    `mapstructure:"timeout"`
+	Protocol string `mapstructure:"protocol" validate:"oneof=tcp udp"`
+	// seconds to dial and to write a line
+	Timeout int `mapstructure:"timeout"`
+	// lines waiting to be shipped, a full buffer sends new lines to the log file
+	BufferSize int `mapstructure:"buffer_size" validate:"min=1"`
+	// milliseconds before the first reconnect, doubled after every failed one
+	ReconnectBackoff    int `mapstructure:"reconnect_backoff" validate:"gte=0"`
+	ReconnectMaxBackoff int `mapstructure:"reconnect_max_backoff" validate:"gte=0"`
 }
@@ -0,0 +1,9 @@
+package config
+
+type OrderImport struct {
+	ChunkSize    int `mapstructure:"chunk_size"`
+	Concurrency  int `mapstructure:"concurrency"`
+	MaxRows      int `mapstructure:"max_rows"`
+	PollInterval int `mapstructure:"poll_interval"`
+	Lease        int `mapstructure:"lease"`
+}
@@ -0,0 +1,11 @@
+package config
+
+// OrderLock guards the read, provider call and save of an order against concurrent confirms
+type OrderLock struct {
+	// TTL in seconds, the lock expires on its own if the holder dies
+	TTL int `mapstructure:"ttl"`
+	// Wait makes a second confirm wait for the first one and return its result instead of a conflict
+	Wait bool `mapstructure:"wait"`
+	// WaitTimeout in seconds, the conflict is returned when the first confirm takes longer
+	WaitTimeout int `mapstructure:"wait_timeout"`
+}
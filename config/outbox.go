@@ -0,0 +1,7 @@
+package config
+
+type Outbox struct {
+	PollInterval int `mapstructure:"poll_interval"`
+	BatchSize    int `mapstructure:"batch_size"`
+	OrphanAfter  int `mapstructure:"orphan_after"`
+}
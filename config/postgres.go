@@ -1,11 +1,37 @@
 package config
 
 type Postgres struct {
-	Host     string `mapstructure:"host"`
-	Port     int    `mapstructure:"port"`
+	Host     string `mapstructure:"host" validate:"required"`
+	Port     int    `mapstructure:"port" validate:"required,min=1,max=65535"`
 	Password string `mapstructure:"password"`
-	Username string `mapstructure:"username"`
-	Schema   string `mapstructure:"schema"`
-	SSLMode  string `mapstructure:"ssl_mode"`
+	Username string `mapstructure:"username" validate:"required"`
+	Schema   string `mapstructure:"schema" validate:"required"`
+	SSLMode  string `mapstructure:"ssl_mode" validate:"oneof=disable allow prefer require verify-ca verify-full"`
 	TimeZone string `mapstructure:"timezone"`
+
+	// pool sizes, zero keeps the database/sql default
+	MaxOpenConns int `mapstructure:"max_open_conns" validate:"gte=0"`
+	MaxIdleConns int `mapstructure:"max_idle_conns" validate:"gte=0"`
+	// in seconds, zero keeps connections forever
+	ConnMaxLifetime int `mapstructure:"conn_max_lifetime" validate:"gte=0"`
+	ConnMaxIdleTime int `mapstructure:"conn_max_idle_time" validate:"gte=0"`
+	// in seconds
+	ConnectTimeout int `mapstructure:"connect_timeout" validate:"gte=0"`
+	// in milliseconds, zero disables it
+	StatementTimeout int `mapstructure:"statement_timeout" validate:"gte=0"`
+
+	// attempts to reach the primary on start before giving up, interval in seconds
+	ConnectRetries       int `mapstructure:"connect_retries" validate:"gte=0"`
+	ConnectRetryInterval int `mapstructure:"connect_retry_interval" validate:"gte=0"`
+	// seconds between health probes of the primary and the replicas
+	HealthInterval int `mapstructure:"health_interval" validate:"gte=0"`
+
+	// read replicas serve list and report queries, they may lag behind the primary
+	Replicas []PostgresReplica `mapstructure:"replicas" validate:"dive"`
+}
+
+// PostgresReplica is a read only copy of the primary, credentials and schema are shared
+type PostgresReplica struct {
+	Host string `mapstructure:"host" validate:"required"`
+	Port int    `mapstructure:"port" validate:"required,min=1,max=65535"`
 }
@@ -0,0 +1,10 @@
+package config
+
+type Reconcile struct {
+	Enabled      bool   `mapstructure:"enabled"`
+	Interval     int    `mapstructure:"interval"`
+	LookbackDays int    `mapstructure:"lookback_days"`
+	StuckAfter   int    `mapstructure:"stuck_after"`
+	ReportDir    string `mapstructure:"report_dir"`
+	AutoFix      bool   `mapstructure:"auto_fix"`
+}
@@ -1,7 +1,40 @@
 package config
 
+// Redis modes
+const (
+	RedisStandalone = "standalone"
+	RedisSentinel   = "sentinel"
+	RedisCluster    = "cluster"
+)
+
 type Redis struct {
+	Mode string `mapstructure:"mode" validate:"oneof=standalone sentinel cluster"`
+	// Host and Port address the standalone server
 	Host string `mapstructure:"host"`
 	Port string `mapstructure:"port"`
-	DB   int    `mapstructure:"db"`
+	// Addrs are the sentinels or the cluster seed nodes, host:port each
+	Addrs            []string `mapstructure:"addrs"`
+	MasterName       string   `mapstructure:"master_name"`
+	Username         string   `mapstructure:"username"`
+	Password         string   `mapstructure:"password"`
+	SentinelPassword string   `mapstructure:"sentinel_password"`
+	DB               int      `mapstructure:"db" validate:"gte=0"`
+	TLS              RedisTLS `mapstructure:"tls"`
+	PoolSize         int      `mapstructure:"pool_size" validate:"gte=0"`
+	MinIdleConns     int      `mapstructure:"min_idle_conns" validate:"gte=0"`
+	MaxRetries       int      `mapstructure:"max_retries" validate:"gte=-1"`
+	// timeouts in milliseconds, zero keeps the client default
+	DialTimeout  int `mapstructure:"dial_timeout" validate:"gte=0"`
+	ReadTimeout  int `mapstructure:"read_timeout" validate:"gte=-1"`
+	WriteTimeout int `mapstructure:"write_timeout" validate:"gte=-1"`
+	PoolTimeout  int `mapstructure:"pool_timeout" validate:"gte=0"`
+}
+
+type RedisTLS struct {
+	Enabled            bool   `mapstructure:"enabled"`
+	CAFile             string `mapstructure:"ca_file"`
+	CertFile           string `mapstructure:"cert_file"`
+	KeyFile            string `mapstructure:"key_file"`
+	ServerName         string `mapstructure:"server_name"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
 }
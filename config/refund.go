@@ -0,0 +1,7 @@
+package config
+
+type Refund struct {
+	Enabled        bool    `mapstructure:"enabled"`
+	PollInterval   int     `mapstructure:"poll_interval"`
+	MatchTolerance float64 `mapstructure:"match_tolerance"`
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"context"
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+)
+
+// Subscriber is told about a reload that changed the configuration
+type Subscriber func(previous, current *Config)
+
+var (
+	reloadMu    sync.Mutex
+	subscribers []Subscriber
+)
+
+// OnReload registers a subsystem that caches a reloadable field, fields read through C() on
+// every use pick up a reload on their own
+func OnReload(subscriber Subscriber) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	subscribers = append(subscribers, subscriber)
+}
+
+// Reload reads the configuration again and applies the fields tagged reload:"true". Other
+// changes need a restart and are only logged, an invalid configuration keeps the running one.
+func Reload() error {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	loaded, _, err := read(configFile)
+	if err != nil {
+		return err
+	}
+	previous := C()
+	next := *previous
+	restart := apply(reflect.ValueOf(&next).Elem(), reflect.ValueOf(loaded).Elem(), "")
+	for _, key := range restart {
+		zap.L().Warn("config change ignored until restart", zap.String("key", key))
+	}
+	if reflect.DeepEqual(*previous, next) {
+		return nil
+	}
+
+	confs.Store(&next)
+	for _, subscriber := range subscribers {
+		subscriber(previous, &next)
+	}
+	zap.L().Info("configuration reloaded", zap.String("file", configFile))
+	return nil
+}
+
+// apply copies the reloadable fields of loaded into next and returns the keys of the other
+// fields that differ
+func apply(next, loaded reflect.Value, prefix string) []string {
+	var restart []string
+	for i := 0; i < next.NumField(); i++ {
+		field := next.Type().Field(i)
+		key := prefix + keyName(field)
+		switch {
+		case field.Tag.Get("reload") == "true":
+			next.Field(i).Set(loaded.Field(i))
+		case field.Type.Kind() == reflect.Struct:
+			restart = append(restart, apply(next.Field(i), loaded.Field(i), key+".")...)
+		case !reflect.DeepEqual(next.Field(i).Interface(), loaded.Field(i).Interface()):
+			restart = append(restart, key)
+		}
+	}
+	return restart
+}
+
+// Watch reloads the configuration when its file changes and on SIGHUP
+func Watch(lc fx.Lifecycle) {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	reload := func(trigger string) {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		if err := Reload(); err != nil {
+			zap.L().Error("error while reload config",
+				zap.String("trigger", trigger),
+				zap.String("error", err.Error()),
+			)
+		}
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			signal.Notify(signals, syscall.SIGHUP)
+			go func() {
+				for {
+					select {
+					case <-done:
+						return
+					case <-signals:
+						reload("SIGHUP")
+					}
+				}
+			}()
+			if configFile != "" {
+				watcher := viper.New()
+				watcher.SetConfigFile(configFile)
+				watcher.OnConfigChange(func(fsnotify.Event) {
+					reload("file")
+				})
+				watcher.WatchConfig()
+			}
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			signal.Stop(signals)
+			close(done)
+			return nil
+		},
+	})
+}
@@ -0,0 +1,6 @@
+package config
+
+type Schedule struct {
+	Enabled      bool `mapstructure:"enabled"`
+	PollInterval int  `mapstructure:"poll_interval"`
+}
@@ -0,0 +1,8 @@
+package config
+
+type SSE struct {
+	HeartbeatInterval       int   `mapstructure:"heartbeat_interval" reload:"true"`
+	MaxConnectionsPerClient int   `mapstructure:"max_connections_per_client" reload:"true"`
+	HistorySize             int64 `mapstructure:"history_size"`
+	HistoryTTL              int   `mapstructure:"history_ttl"`
+}
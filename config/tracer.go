@@ -1,6 +1,24 @@
 package config
 
-type tracer struct {
-	HostPort string `mapstructure:"hostPort" required:"true"`
-	LogSpans bool   `mapstructure:"logSpans"`
+// Tracer exporters
+const (
+	TracerOTLPGrpc = "otlp_grpc"
+	TracerOTLPHttp = "otlp_http"
+	TracerStdout   = "stdout"
+)
+
+type Tracer struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	Exporter string `mapstructure:"exporter" validate:"oneof=otlp_grpc otlp_http stdout"`
+	// Endpoint is the collector host:port, otlp_grpc listens on 4317 and otlp_http on 4318
+	Endpoint string `mapstructure:"endpoint"`
+	Insecure bool   `mapstructure:"insecure"`
+	// in seconds, how long an export batch may take
+	Timeout int `mapstructure:"timeout" validate:"gte=0"`
+	// SampleRatio is the share of new traces recorded, a trace started by a caller follows
+	// the caller's decision
+	SampleRatio float64 `mapstructure:"sample_ratio" validate:"gte=0,lte=1"`
+	Environment string  `mapstructure:"environment"`
+	// Attributes are added to the resource of every span
+	Attributes map[string]string `mapstructure:"attributes"`
 }
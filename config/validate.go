@@ -0,0 +1,112 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator"
+	"strings"
+)
+
+// validate checks the validate tags of every section and reports all problems at once,
+// named by their config key
+func (c *Config) validate() error {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(keyName)
+	validate.RegisterStructValidation(validateRedis, Redis{})
+	validate.RegisterStructValidation(validateTracer, Tracer{})
+	validate.RegisterStructValidation(validateGrpc, Grpc{})
+	validate.RegisterStructValidation(validateApprovalPolicy, ApprovalPolicy{})
+
+	err := validate.Struct(c)
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return err
+	}
+	problems := make([]string, 0, len(validationErrors))
+	for _, fieldError := range validationErrors {
+		problems = append(problems, describe(fieldError))
+	}
+	return fmt.Errorf("invalid configuration:\n  - %s", strings.Join(problems, "\n  - "))
+}
+
+func describe(fieldError validator.FieldError) string {
+	// the namespace starts with the struct name, Config.service.client_secret
+	_, key, _ := strings.Cut(fieldError.Namespace(), ".")
+	switch fieldError.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required, set it in the config file, %s or %s",
+			key, EnvName(key), EnvName(key)+FileEnvSuffix)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of %s, got %q", key, fieldError.Param(), fmt.Sprint(fieldError.Value()))
+	case "required_unless":
+		return fmt.Sprintf("%s is required unless %s is set, set it in the config file, %s or %s",
+			key, fieldError.Param(), EnvName(key), EnvName(key)+FileEnvSuffix)
+	case "required_with":
+		return fmt.Sprintf("%s is required with %s", key, fieldError.Param())
+	case "notblank":
+		return fmt.Sprintf("%s must not be blank", key)
+	case "min", "gte":
+		return fmt.Sprintf("%s must be at least %s, got %v", key, fieldError.Param(), fieldError.Value())
+	case "max", "lte":
+		return fmt.Sprintf("%s must be at most %s, got %v", key, fieldError.Param(), fieldError.Value())
+	case "url":
+		return fmt.Sprintf("%s must be a url, got %q", key, fmt.Sprint(fieldError.Value()))
+	}
+	return fmt.Sprintf("%s fails the %s check", key, fieldError.Tag())
+}
+
+// validateRedis requires the addresses the selected mode dials
+func validateRedis(sl validator.StructLevel) {
+	r := sl.Current().Interface().(Redis)
+	switch r.Mode {
+	case RedisStandalone:
+		if r.Host == "" {
+			sl.ReportError(r.Host, "host", "Host", "required", "")
+		}
+		if r.Port == "" {
+			sl.ReportError(r.Port, "port", "Port", "required", "")
+		}
+	case RedisSentinel:
+		if r.MasterName == "" {
+			sl.ReportError(r.MasterName, "master_name", "MasterName", "required", "")
+		}
+		fallthrough
+	case RedisCluster:
+		if len(r.Addrs) == 0 {
+			sl.ReportError(r.Addrs, "addrs", "Addrs", "required", "")
+		}
+	}
+	if r.TLS.CertFile != "" && r.TLS.KeyFile == "" {
+		sl.ReportError(r.TLS.KeyFile, "tls.key_file", "KeyFile", "required", "")
+	}
+}
+
+// validateTracer requires the collector address of the otlp exporters
+func validateTracer(sl validator.StructLevel) {
+	t := sl.Current().Interface().(Tracer)
+	if t.Enabled && t.Exporter != TracerStdout && t.Endpoint == "" {
+		sl.ReportError(t.Endpoint, "endpoint", "Endpoint", "required", "")
+	}
+}
+
+// validateGrpc requires a token unless authentication is explicitly disabled
+func validateGrpc(sl validator.StructLevel) {
+	g := sl.Current().Interface().(Grpc)
+	if !g.AuthDisabled && len(g.AuthTokens) == 0 {
+		sl.ReportError(g.AuthTokens, "auth_tokens", "AuthTokens", "required_unless", "grpc.auth_disabled")
+	}
+	for i, token := range g.AuthTokens {
+		if strings.TrimSpace(token) == "" {
+			sl.ReportError(token, fmt.Sprintf("auth_tokens[%d]", i), "AuthTokens", "notblank", "")
+		}
+	}
+}
+
+// validateApprovalPolicy requires the currency of an amount threshold, amounts of different
+// wallets are never compared
+func validateApprovalPolicy(sl validator.StructLevel) {
+	p := sl.Current().Interface().(ApprovalPolicy)
+	if p.MinAmount > 0 && p.Currency == "" {
+		sl.ReportError(p.Currency, "currency", "Currency", "required_with", "min_amount")
+	}
+}
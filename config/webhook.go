@@ -0,0 +1,13 @@
+package config
+
+type Webhook struct {
+	PollInterval int `mapstructure:"poll_interval"`
+	BatchSize    int `mapstructure:"batch_size"`
+	MaxAttempts  int `mapstructure:"max_attempts" reload:"true"`
+	BaseBackoff  int `mapstructure:"base_backoff" reload:"true"`
+	MaxBackoff   int `mapstructure:"max_backoff" reload:"true"`
+	Timeout      int `mapstructure:"timeout"`
+	// AllowPrivateTargets lets subscriptions reach loopback and private addresses, for local
+	// development only
+	AllowPrivateTargets bool `mapstructure:"allow_private_targets"`
+}
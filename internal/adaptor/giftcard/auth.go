@@ -2,8 +2,10 @@ package giftcard
 
 import (
 	"context"
+	"giftcard/internal/adaptor/redis"
 	"giftcard/internal/adaptor/trace"
 	"giftcard/internal/exceptions"
+	"giftcard/pkg/logger"
 	"giftcard/pkg/requester"
 	"giftcard/pkg/responser"
 	"giftcard/pkg/utils"
@@ -11,13 +13,42 @@ import (
 	"go.uber.org/zap"
 	"io"
 	"net/http"
+	"sync"
 	"time"
 )
 
+const (
+	tokenKey = "giftcard_token"
+	tokenTTL = 3600 * time.Second
+)
+
 type AuthToken struct {
 	Token string
 }
 
+// tokenCache keeps the auth token in process, it serves the requests while redis is down
+type tokenCache struct {
+	mu        sync.RWMutex
+	token     string
+	expiresAt time.Time
+}
+
+func (c *tokenCache) get() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if time.Now().After(c.expiresAt) {
+		return ""
+	}
+	return c.token
+}
+
+func (c *tokenCache) set(token string, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = token
+	c.expiresAt = time.Now().Add(ttl)
+}
+
 func (g *GiftCard) Auth(ctx context.Context) (AuthToken, error) {
 	span, spannedContext := trace.T.SpanFromContext(
 		ctx,
@@ -26,18 +57,23 @@ func (g *GiftCard) Auth(ctx context.Context) (AuthToken, error) {
 	defer span.End()
 
 	uniqueID, _ := ctx.Value("tracer").(string)
-	logger := zap.L().With(
+	logger := logger.For(ctx, logger.ModuleGiftCard).With(
 		zap.String("tracer", uniqueID),
 	)
 
+	// redis shares the token between replicas, the local copy only stands in while redis is down
 	var authToken AuthToken
-	getTokenErr := g.redis.Get(spannedContext, "giftcard_token", &authToken.Token)
-	if getTokenErr != nil {
+	cache := "redis"
+	getTokenErr := g.redis.Get(spannedContext, tokenKey, &authToken.Token)
+	if getTokenErr != nil && !redis.IsNil(getTokenErr) {
 		logger.Error("internal error", zap.String("message", getTokenErr.Error()))
 		span.SetAttributes(attribute.String("internal error", getTokenErr.Error()))
+		cache = "local"
+		authToken.Token = g.tokens.get()
 	}
 
 	if authToken.Token != "" {
+		g.metrics.tokenCacheHits.WithLabelValues(cache).Inc()
 		return authToken, nil
 	}
 
@@ -46,15 +82,21 @@ func (g *GiftCard) Auth(ctx context.Context) (AuthToken, error) {
 
 	client := &http.Client{}
 
-	req, err := http.NewRequest(method, url, nil)
+	req, err := http.NewRequestWithContext(spannedContext, method, url, nil)
 	if err != nil {
 		return AuthToken{}, err
 	}
 
 	req.Header.Add("client-id", g.ClientID)
 	req.Header.Add("client-secret", g.ClientSecret)
+	trace.Inject(spannedContext, req.Header)
 
 	res, err := client.Do(req)
+	if err != nil || res.StatusCode != http.StatusOK {
+		g.metrics.tokenRefreshes.WithLabelValues("failure").Inc()
+	} else {
+		g.metrics.tokenRefreshes.WithLabelValues("success").Inc()
+	}
 
 	request := requester.Request{
 		ID:          uniqueID,
@@ -64,7 +106,7 @@ func (g *GiftCard) Auth(ctx context.Context) (AuthToken, error) {
 		Header:      req.Header,
 		Params:      req.URL.Query(),
 	}
-	logger.Error("Request to provider", zap.Any("message", request))
+	logger.Debug("Request to provider", zap.Any("message", request))
 	span.SetAttributes(attribute.String("Request to provider", utils.Marshal(request)))
 
 	if err != nil {
@@ -90,13 +132,14 @@ func (g *GiftCard) Auth(ctx context.Context) (AuthToken, error) {
 		Header:     res.Header,
 		Body:       string(bodyBytes),
 	}
-	logger.Info("Response from provider", zap.Any("data", response))
+	logger.Debug("Response from provider", zap.Any("data", response))
 	span.SetAttributes(attribute.String("Response", utils.Marshal(response)))
 
 	switch res.StatusCode {
 	case http.StatusOK:
 		authHeader := res.Header.Get("Authorization")
-		setToRedisErr := g.redis.Set(spannedContext, "giftcard_token", authHeader, 3600*time.Second)
+		g.tokens.set(authHeader, tokenTTL)
+		setToRedisErr := g.redis.Set(spannedContext, tokenKey, authHeader, tokenTTL)
 		if setToRedisErr != nil {
 			logger.Error("internal error", zap.String("error", setToRedisErr.Error()))
 			span.SetAttributes(attribute.String("internal error", setToRedisErr.Error()))
@@ -9,14 +9,21 @@ import (
 	"giftcard/internal/adaptor/redis"
 	"giftcard/internal/adaptor/trace"
 	"giftcard/internal/exceptions"
+	"giftcard/pkg/health"
+	"giftcard/pkg/logger"
 	"giftcard/pkg/requester"
 	"giftcard/pkg/responser"
 	"giftcard/pkg/utils"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
+	"golang.org/x/time/rate"
 	"io"
+	"net"
 	"net/http"
 	_url "net/url"
+	"strconv"
+	"syscall"
 	"time"
 )
 
@@ -25,18 +32,69 @@ type GiftCard struct {
 	ClientID     string `json:"clientID"`
 	ClientSecret string `json:"clientSecret"`
 	redis        *redis.Store
+	tokens       *tokenCache
+	limiter      *rate.Limiter
+	metrics      *providerMetrics
+	client       *http.Client
 }
 
-func NewGiftCard(redisStore *redis.Store) *GiftCard {
-	return &GiftCard{
+func NewGiftCard(redisStore *redis.Store, registry *prometheus.Registry, checks *health.Registry) *GiftCard {
+	g := &GiftCard{
 		BaseUrl:      config.C().Service.BaseUrl,
 		ClientID:     config.C().Service.ClientID,
 		ClientSecret: config.C().Service.ClientSecret,
 		redis:        redisStore,
+		tokens:       &tokenCache{},
+		limiter:      rate.NewLimiter(rate.Inf, 0),
+		metrics:      newProviderMetrics(registry),
+		client:       &http.Client{},
 	}
+	g.setRateLimit(config.C().Service.RateLimit)
+	checks.Register("provider_auth", 0, func(ctx context.Context) error {
+		_, err := g.Auth(ctx)
+		return err
+	})
+	config.OnReload(func(previous, current *config.Config) {
+		if previous.Service.RateLimit != current.Service.RateLimit {
+			g.setRateLimit(current.Service.RateLimit)
+		}
+	})
+	return g
+}
+
+// setRateLimit applies the provider rate limit, requests waiting on the old limit keep waiting on the new one
+func (g *GiftCard) setRateLimit(limit config.ProviderRateLimit) {
+	if limit.RequestsPerSecond <= 0 {
+		g.limiter.SetLimit(rate.Inf)
+		return
+	}
+	burst := limit.Burst
+	if burst <= 0 {
+		burst = 1
+	}
+	g.limiter.SetBurst(burst)
+	g.limiter.SetLimit(rate.Limit(limit.RequestsPerSecond))
 }
 
-const maxRetries = 3
+const (
+	defaultMaxAttempts = 3
+	defaultTimeout     = 30 * time.Second
+)
+
+// retryPolicy reads the provider retry settings on every call so a config reload applies to the next one
+func retryPolicy() (attempts int, backoff time.Duration, timeout time.Duration) {
+	service := config.C().Service
+	attempts = service.Retry.MaxAttempts
+	if attempts <= 0 {
+		attempts = defaultMaxAttempts
+	}
+	backoff = time.Duration(service.Retry.Backoff) * time.Millisecond
+	timeout = time.Duration(service.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return attempts, backoff, timeout
+}
 
 func (g *GiftCard) ProcessRequest(ctx context.Context, method string, url string, payload *[]byte) (map[string]any, error) {
 	span, spannedContext := trace.T.SpanFromContext(
@@ -47,12 +105,12 @@ func (g *GiftCard) ProcessRequest(ctx context.Context, method string, url string
 	defer span.End()
 
 	uniqueID, _ := ctx.Value("tracer").(string)
-	logger := zap.L().With(
+	logger := logger.For(ctx, logger.ModuleGiftCard).With(
 		zap.String("tracer", uniqueID),
 	)
 
-	client := &http.Client{}
-	req, err := http.NewRequest(method, url, nil)
+	maxAttempts, backoff, timeout := retryPolicy()
+	req, err := http.NewRequestWithContext(spannedContext, method, url, nil)
 	if err != nil {
 		logger.Error(exceptions.InternalServerError, zap.String("error", err.Error()))
 		span.SetAttributes(attribute.String(exceptions.InternalServerError, err.Error()))
@@ -68,6 +126,7 @@ func (g *GiftCard) ProcessRequest(ctx context.Context, method string, url string
 
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("Authorization", token.Token)
+	trace.Inject(spannedContext, req.Header)
 
 	var requestBody string
 	if payload != nil {
@@ -84,43 +143,56 @@ func (g *GiftCard) ProcessRequest(ctx context.Context, method string, url string
 		Params:      req.URL.Query(),
 	}
 
-	logger.Info("Request to provider", zap.Any("data", request))
+	logger.Debug("Request to provider", zap.Any("data", request))
 	span.SetAttributes(attribute.String("Request to provider", utils.Marshal(request)))
 
+	if err := g.limiter.Wait(spannedContext); err != nil {
+		logger.Error(exceptions.InternalServerError, zap.String("error", err.Error()))
+		span.SetAttributes(attribute.String(exceptions.InternalServerError, err.Error()))
+		return nil, err
+	}
+
+	operation := operationFrom(ctx)
+	status := "error"
+	start := time.Now()
+	defer func() {
+		g.metrics.duration.WithLabelValues(operation, status).Observe(time.Since(start).Seconds())
+	}()
+
 	var res *http.Response
 	var bodyBytes []byte
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		res, err = client.Do(req)
-
-		if err != nil {
-			var urlErr *_url.Error
-			if errors.As(err, &urlErr) {
-				logger.Error(exceptions.InternalServerError, zap.String("error", urlErr.Error()))
-				span.SetAttributes(attribute.String(exceptions.InternalServerError, urlErr.Error()))
-				time.Sleep(1 * time.Second)
-				continue
-			}
-
-			if isEOFError(err) {
-				logger.Error(exceptions.InternalServerError, zap.String("error", err.Error()))
-				span.SetAttributes(attribute.String(exceptions.InternalServerError, err.Error()))
-				time.Sleep(1 * time.Second)
-				continue
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 {
+			g.metrics.retries.WithLabelValues(operation).Inc()
+			select {
+			case <-spannedContext.Done():
+				return nil, spannedContext.Err()
+			case <-time.After(backoff):
 			}
-			logger.Error(exceptions.InternalServerError, zap.String("error", err.Error()))
-			span.SetAttributes(attribute.String(exceptions.InternalServerError, err.Error()))
-			return nil, err
 		}
-		defer res.Body.Close()
-
-		bodyBytes, err = io.ReadAll(res.Body)
-		if err != nil {
-			logger.Error(exceptions.InternalServerError, zap.String("error", err.Error()))
-			span.SetAttributes(attribute.String(exceptions.InternalServerError, err.Error()))
-			return nil, err
+		res, bodyBytes, err = g.attempt(spannedContext, req, payload, timeout)
+		if err == nil {
+			break
+		}
+		logger.Error(exceptions.InternalServerError, zap.String("error", err.Error()))
+		span.SetAttributes(attribute.String(exceptions.InternalServerError, err.Error()))
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if !notSent(err) {
+			if method != http.MethodGet {
+				// the provider may have applied it, sending it again could apply it twice
+				return nil, &UnknownOutcomeErr{ErrMsg: "no answer from provider", Err: err}
+			}
+			if !isTransportError(err) {
+				return nil, err
+			}
 		}
-		break
 	}
+	if res == nil {
+		return nil, &InternalErr{ErrMsg: exceptions.InternalServerError}
+	}
+	status = strconv.Itoa(res.StatusCode)
 
 	if res.StatusCode == http.StatusForbidden {
 		logger.Error("Response from provider", zap.String("data", exceptions.StatusForbidden))
@@ -141,7 +213,7 @@ func (g *GiftCard) ProcessRequest(ctx context.Context, method string, url string
 		Header:     res.Header,
 		Body:       string(bodyBytes),
 	}
-	logger.Info("Response from provider", zap.Any("data", response))
+	logger.Debug("Response from provider", zap.Any("data", response))
 	span.SetAttributes(attribute.String("Response from provider", utils.Marshal(response)))
 
 	switch res.StatusCode {
@@ -152,6 +224,43 @@ func (g *GiftCard) ProcessRequest(ctx context.Context, method string, url string
 	}
 }
 
-func isEOFError(err error) bool {
-	return err != nil && err == io.EOF
+// attempt sends req once with its own timeout and reads the whole answer
+func (g *GiftCard) attempt(ctx context.Context, req *http.Request, payload *[]byte, timeout time.Duration) (*http.Response, []byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req = req.Clone(ctx)
+	if payload != nil {
+		req.Body = io.NopCloser(bytes.NewReader(*payload))
+		req.ContentLength = int64(len(*payload))
+	}
+	res, err := g.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, body, nil
+}
+
+// notSent reports whether the call failed before the request left, so retrying it is safe
+// whatever the method
+func notSent(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) || errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// isTransportError reports whether the call failed without an answer, a timeout or a dropped
+// connection, which only idempotent calls retry
+func isTransportError(err error) bool {
+	var urlErr *_url.Error
+	return errors.As(err, &urlErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
 }
@@ -0,0 +1,142 @@
+package giftcard
+
+import (
+	"context"
+	"errors"
+	"giftcard/config"
+	"giftcard/internal/adaptor/redis/redistest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"golang.org/x/time/rate"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestGiftCard calls baseUrl with a cached token, one second per attempt and three attempts
+func newTestGiftCard(t *testing.T, baseUrl string) *GiftCard {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `
+service:
+  client_id: "id"
+  client_secret: "secret"
+  timeout: 1
+  retry:
+    max_attempts: 3
+    backoff: 10
+postgres:
+  username: "root"
+  schema: "gift_card_db"
+tracer:
+  enabled: false
+logstash:
+  endpoint: "localhost:5000"
+grpc:
+  auth_disabled: true
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Load(path); err != nil {
+		t.Fatal(err)
+	}
+
+	store, fake := redistest.NewStore()
+	fake.SetDown(true)
+	g := &GiftCard{
+		BaseUrl: baseUrl,
+		redis:   store,
+		tokens:  &tokenCache{},
+		limiter: rate.NewLimiter(rate.Inf, 0),
+		metrics: newProviderMetrics(prometheus.NewRegistry()),
+		client:  &http.Client{},
+	}
+	g.tokens.set("token", time.Hour)
+	return g
+}
+
+func TestProcessRequestRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		calls    int32
+		outcome  bool
+		answered bool
+	}{
+		{name: "timed out create is not sent again", method: http.MethodPost, calls: 1, outcome: true},
+		{name: "timed out confirm is not sent again", method: http.MethodPut, calls: 1, outcome: true},
+		{name: "timed out read is retried", method: http.MethodGet, calls: 2, answered: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) == 1 {
+					time.Sleep(1500 * time.Millisecond)
+				}
+				_, _ = w.Write([]byte(`{"data":{}}`))
+			}))
+			defer server.Close()
+
+			g := newTestGiftCard(t, server.URL)
+			payload := []byte(`{}`)
+			_, err := g.ProcessRequest(context.Background(), tt.method, server.URL+"/order", &payload)
+
+			var unknownErr *UnknownOutcomeErr
+			if errors.As(err, &unknownErr) != tt.outcome {
+				t.Fatalf("unknown outcome %v, got %v", tt.outcome, err)
+			}
+			if tt.answered && err != nil {
+				t.Fatalf("expected the retry to be answered, got %v", err)
+			}
+			if got := calls.Load(); got != tt.calls {
+				t.Fatalf("provider called %d times, expected %d", got, tt.calls)
+			}
+		})
+	}
+}
+
+func TestProcessRequestNotSent(t *testing.T) {
+	// a closed listener refuses the connection, the request never leaves
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := "http://" + listener.Addr().String()
+	_ = listener.Close()
+
+	g := newTestGiftCard(t, url)
+	payload := []byte(`{}`)
+	_, err = g.ProcessRequest(context.Background(), http.MethodPost, url+"/order/create", &payload)
+	var unknownErr *UnknownOutcomeErr
+	if err == nil || errors.As(err, &unknownErr) {
+		t.Fatalf("expected a refused create to fail as not sent, got %v", err)
+	}
+	if retries := testutil.ToFloat64(g.metrics.retries.WithLabelValues("ProcessRequest")); retries != 2 {
+		t.Fatalf("expected a refused create to be retried twice, got %v", retries)
+	}
+}
+
+func TestProcessRequestCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	g := newTestGiftCard(t, server.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := g.ProcessRequest(ctx, http.MethodGet, server.URL+"/order", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the caller deadline, got %v", err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("the retries outlived the caller, took %s", time.Since(start))
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"giftcard/internal/adaptor/trace"
 	"giftcard/internal/exceptions"
+	"giftcard/pkg/logger"
 	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
@@ -18,7 +19,7 @@ func (g *GiftCard) ConfirmOrder(ctx context.Context, orderId string) (map[string
 	defer span.End()
 
 	uniqueID, _ := ctx.Value("tracer").(string)
-	logger := zap.L().With(
+	logger := logger.For(ctx, logger.ModuleGiftCard).With(
 		zap.String("tracer", uniqueID),
 	)
 
@@ -37,7 +38,7 @@ func (g *GiftCard) ConfirmOrder(ctx context.Context, orderId string) (map[string
 		return nil, err
 	}
 
-	data, err := g.ProcessRequest(spannedContext, method, url, &payloadBytes)
+	data, err := g.ProcessRequest(withOperation(spannedContext, "ConfirmOrder"), method, url, &payloadBytes)
 	if err != nil {
 		span.SetAttributes(attribute.String("error", err.Error()))
 		return nil, err
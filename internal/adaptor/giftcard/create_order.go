@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"giftcard/internal/adaptor/trace"
 	"giftcard/internal/exceptions"
+	"giftcard/pkg/logger"
 	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
+	"time"
 )
 
 type ModifiedDate struct {
@@ -14,11 +16,8 @@ type ModifiedDate struct {
 	Nanoseconds int   `json:"_nanoseconds"`
 }
 
-type exchangeRate struct {
-	BaseCurrency   string       `json:"baseCurrency"`
-	ModifiedDate   ModifiedDate `json:"modifiedDate"`
-	Rate           float64      `json:"rate"`
-	TargetCurrency string       `json:"targetCurrency"`
+func (d ModifiedDate) Time() time.Time {
+	return time.Unix(d.Seconds, int64(d.Nanoseconds))
 }
 
 type Effect struct {
@@ -61,7 +60,7 @@ type Invoice struct {
 
 type OrderResponse struct {
 	Data struct {
-		ExchangeRates []exchangeRate `json:"exchangeRates"`
+		ExchangeRates []ExchangeRate `json:"exchangeRates"`
 		ExpiresAt     int64          `json:"expiresAt"`
 		ID            string         `json:"id"`
 		Invoice       Invoice        `json:"invoice"`
@@ -78,7 +77,7 @@ func (g *GiftCard) CreateOrder(ctx context.Context, productList []map[string]any
 	defer span.End()
 
 	uniqueID, _ := ctx.Value("tracer").(string)
-	logger := zap.L().With(
+	logger := logger.For(ctx, logger.ModuleGiftCard).With(
 		zap.String("tracer", uniqueID),
 	)
 
@@ -99,7 +98,7 @@ func (g *GiftCard) CreateOrder(ctx context.Context, productList []map[string]any
 		return OrderResponse{}, err
 	}
 
-	data, err := g.ProcessRequest(spannedContext, method, url, &payloadBytes)
+	data, err := g.ProcessRequest(withOperation(spannedContext, "CreateOrder"), method, url, &payloadBytes)
 	if err != nil {
 		span.SetAttributes(attribute.String("error", err.Error()))
 		return OrderResponse{}, err
@@ -5,18 +5,16 @@ import (
 	"encoding/json"
 	"giftcard/internal/adaptor/trace"
 	"giftcard/internal/exceptions"
+	"giftcard/pkg/logger"
 	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
 type ExchangeRate struct {
-	BaseCurrency string `json:"baseCurrency"`
-	ModifiedDate struct {
-		Nanoseconds int64 `json:"_nanoseconds"`
-		Seconds     int64 `json:"_seconds"`
-	} `json:"modifiedDate"`
-	Rate           float64 `json:"rate"`
-	TargetCurrency string  `json:"targetCurrency"`
+	BaseCurrency   string       `json:"baseCurrency"`
+	ModifiedDate   ModifiedDate `json:"modifiedDate"`
+	Rate           float64      `json:"rate"`
+	TargetCurrency string       `json:"targetCurrency"`
 }
 
 type Wallet struct {
@@ -44,14 +42,14 @@ func (g *GiftCard) CustomerInfo(ctx context.Context) (CustomerInfoResponse, erro
 	defer span.End()
 
 	uniqueID, _ := ctx.Value("tracer").(string)
-	logger := zap.L().With(
+	logger := logger.For(ctx, logger.ModuleGiftCard).With(
 		zap.String("tracer", uniqueID),
 	)
 
 	url := g.BaseUrl + "/customer/info"
 	method := "GET"
 
-	data, err := g.ProcessRequest(spannedContext, method, url, nil)
+	data, err := g.ProcessRequest(withOperation(spannedContext, "CustomerInfo"), method, url, nil)
 	if err != nil {
 		span.SetAttributes(attribute.String("error", err.Error()))
 		return CustomerInfoResponse{}, err
@@ -32,3 +32,18 @@ type InternalErr struct {
 func (e *InternalErr) Error() string {
 	return e.ErrMsg
 }
+
+// UnknownOutcomeErr is returned when a call that changes the provider state was sent but no
+// answer came back, the provider may or may not have applied it and the call is not retried
+type UnknownOutcomeErr struct {
+	ErrMsg string
+	Err    error
+}
+
+func (e *UnknownOutcomeErr) Error() string {
+	return e.ErrMsg + ", " + e.Err.Error()
+}
+
+func (e *UnknownOutcomeErr) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,61 @@
+package giftcard
+
+import (
+	"context"
+	"giftcard/internal/adaptor/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type operationKey struct{}
+
+// withOperation names the IGiftCard method a provider request is made for, it labels the
+// provider metrics
+func withOperation(ctx context.Context, operation string) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+func operationFrom(ctx context.Context) string {
+	if operation, ok := ctx.Value(operationKey{}).(string); ok {
+		return operation
+	}
+	return "ProcessRequest"
+}
+
+type providerMetrics struct {
+	duration       *prometheus.HistogramVec
+	retries        *prometheus.CounterVec
+	tokenRefreshes *prometheus.CounterVec
+	tokenCacheHits *prometheus.CounterVec
+}
+
+func newProviderMetrics(registry *prometheus.Registry) *providerMetrics {
+	m := &providerMetrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "provider",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of the provider calls including retries, by method and final status.",
+			Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, []string{"method", "status"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "provider",
+			Name:      "retries_total",
+			Help:      "Provider requests sent again after a transport error, by method.",
+		}, []string{"method"}),
+		tokenRefreshes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "provider",
+			Name:      "token_refreshes_total",
+			Help:      "Auth token requests to the provider, by result.",
+		}, []string{"result"}),
+		tokenCacheHits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "provider",
+			Name:      "token_cache_hits_total",
+			Help:      "Auth tokens served from cache, by cache.",
+		}, []string{"cache"}),
+	}
+	registry.MustRegister(m.duration, m.retries, m.tokenRefreshes, m.tokenCacheHits)
+	return m
+}
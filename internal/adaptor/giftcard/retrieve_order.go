@@ -20,7 +20,7 @@ func (g *GiftCard) RetrieveOrder(ctx context.Context, orderId string) (map[strin
 	url := g.BaseUrl + fmt.Sprintf("/order/get?orderId=%s", orderId)
 	method := "GET"
 
-	data, err := g.ProcessRequest(spannedContext, method, url, nil)
+	data, err := g.ProcessRequest(withOperation(spannedContext, "RetrieveOrder"), method, url, nil)
 	if err != nil {
 		span.SetAttributes(attribute.String("error", err.Error()))
 		return nil, err
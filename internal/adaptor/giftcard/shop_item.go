@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"giftcard/internal/adaptor/trace"
 	"giftcard/internal/exceptions"
+	"giftcard/pkg/logger"
 	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
@@ -51,14 +52,14 @@ func (g *GiftCard) ShopItem(ctx context.Context, productId string) (ProductRespo
 	defer span.End()
 
 	uniqueID, _ := ctx.Value("tracer").(string)
-	logger := zap.L().With(
+	logger := logger.For(ctx, logger.ModuleGiftCard).With(
 		zap.String("tracer", uniqueID),
 	)
 
 	url := g.BaseUrl + fmt.Sprintf("/shop/products/%s", productId)
 	method := "GET"
 
-	data, err := g.ProcessRequest(spannedContext, method, url, nil)
+	data, err := g.ProcessRequest(withOperation(spannedContext, "ShopItem"), method, url, nil)
 	if err != nil {
 		span.SetAttributes(attribute.String("error", err.Error()))
 		return ProductResponse{}, err
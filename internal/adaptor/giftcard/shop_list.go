@@ -19,7 +19,7 @@ func (g *GiftCard) ShopList(ctx context.Context, pageSize int, pageToken string)
 	url := fmt.Sprintf("%s/shop/products?pageSize=%d&pageToken=%s", g.BaseUrl, pageSize, pageToken)
 	method := "GET"
 
-	data, err := g.ProcessRequest(spannedContext, method, url, nil)
+	data, err := g.ProcessRequest(withOperation(spannedContext, "ShopList"), method, url, nil)
 	if err != nil {
 		span.SetAttributes(attribute.String("error", err.Error()))
 		return nil, err
@@ -0,0 +1,35 @@
+package logstash
+
+import (
+	"context"
+	"fmt"
+	"giftcard/config"
+	"giftcard/pkg/filewriter"
+	"time"
+
+	"go.uber.org/fx"
+)
+
+// NewFile opens the local log file, it is nil when log.file.path is empty
+func NewFile(lc fx.Lifecycle) (*filewriter.Rotating, error) {
+	conf := config.C().Log.File
+	if conf.Path == "" {
+		return nil, nil
+	}
+	file, err := filewriter.NewRotating(conf.Path, filewriter.Options{
+		MaxSize:     conf.MaxSize,
+		RotateEvery: time.Duration(conf.RotateEvery) * time.Hour,
+		MaxAge:      conf.MaxAge,
+		MaxBackups:  conf.MaxBackups,
+		Compress:    conf.Compress,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the log file, %w", err)
+	}
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return file.Close()
+		},
+	})
+	return file, nil
+}
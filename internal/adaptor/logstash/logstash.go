@@ -2,37 +2,206 @@ package logstash
 
 import (
 	"context"
+	"fmt"
 	"giftcard/config"
+	"giftcard/internal/adaptor/metrics"
+	"giftcard/pkg/filewriter"
+	"giftcard/pkg/health"
+	"github.com/prometheus/client_golang/prometheus"
 	"log"
 	"net"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/fx"
 )
 
+// LogStash ships log lines in the background, Write only queues the line so logging never
+// waits on the network. Lines that cannot be queued or shipped go to the local log file.
 type LogStash struct {
-	conn net.Conn
+	conf    config.Logstash
+	lines   chan []byte
+	file    *filewriter.Rotating
+	metrics *shipMetrics
+
+	// conn, backoff and retryAt belong to the shipping goroutine
+	conn    net.Conn
+	backoff time.Duration
+	retryAt time.Time
+
+	mu      sync.Mutex
+	connErr error
+
+	closed  atomic.Bool
+	done    chan struct{}
+	stopped chan struct{}
 }
 
-func NewLogStash(lc fx.Lifecycle) *LogStash {
-	var err error
-	logstash := LogStash{}
+// NewLogStash ships to logstash.endpoint, file is the fallback, it is skipped when every line goes to the file anyway.
+func NewLogStash(lc fx.Lifecycle, checks *health.Registry, registry *prometheus.Registry, file *filewriter.Rotating) *LogStash {
+	confs := config.C()
+	logstash := &LogStash{
+		conf:    confs.Logstash,
+		lines:   make(chan []byte, confs.Logstash.BufferSize),
+		metrics: newShipMetrics(registry),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	if !confs.Log.File.Always {
+		logstash.file = file
+	}
+	registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Name:      "logs_buffered",
+		Help:      "Log lines waiting to be shipped to logstash.",
+	}, func() float64 {
+		return float64(len(logstash.lines))
+	}))
+	checks.Register("logstash", 0, logstash.check)
+
+	// shipping starts right away, adaptors log while the lifecycle is starting and those
+	// lines matter most when the start fails
+	go logstash.run()
 	lc.Append(fx.Hook{
-		OnStart: func(ctx context.Context) error {
-			logstash.conn, err = net.DialTimeout("udp", config.C().Logstash.Endpoint, time.Duration(config.C().Logstash.Timeout)*time.Second)
-			if err != nil {
-				return err
-			}
-			log.Printf("logstash connected successfully \n")
-			return nil
-		},
 		OnStop: func(ctx context.Context) error {
-			return logstash.conn.Close()
+			logstash.closed.Store(true)
+			close(logstash.done)
+			select {
+			case <-logstash.stopped:
+				return nil
+			case <-ctx.Done():
+				return fmt.Errorf("logstash did not flush in time, %w", ctx.Err())
+			}
 		},
 	})
-	return &logstash
+	return logstash
 }
 
 func (l *LogStash) Write(p []byte) (int, error) {
-	return l.conn.Write(p)
+	// zap reuses the buffer once Write returns
+	line := make([]byte, len(p))
+	copy(line, p)
+	if l.closed.Load() {
+		l.spill(line, reasonStopped)
+		return len(p), nil
+	}
+	select {
+	case l.lines <- line:
+	default:
+		l.spill(line, reasonBufferFull)
+	}
+	return len(p), nil
+}
+
+func (l *LogStash) run() {
+	defer close(l.stopped)
+	for {
+		select {
+		case line := <-l.lines:
+			l.ship(line)
+		case <-l.done:
+			l.flush()
+			return
+		}
+	}
+}
+
+// flush ships what is still queued, lines that arrive meanwhile are written to the file
+func (l *LogStash) flush() {
+	defer func() {
+		if l.conn != nil {
+			_ = l.conn.Close()
+		}
+	}()
+	for {
+		select {
+		case line := <-l.lines:
+			l.ship(line)
+		default:
+			return
+		}
+	}
+}
+
+func (l *LogStash) ship(line []byte) {
+	conn := l.connection()
+	if conn == nil {
+		l.spill(line, reasonDisconnected)
+		return
+	}
+	if timeout := l.timeout(); timeout > 0 {
+		_ = conn.SetWriteDeadline(time.Now().Add(timeout))
+	}
+	if _, err := conn.Write(line); err != nil {
+		_ = conn.Close()
+		l.conn = nil
+		l.failed(fmt.Errorf("write failed, %w", err))
+		l.spill(line, reasonWriteFailed)
+		return
+	}
+	l.metrics.shipped.Inc()
+}
+
+// connection returns the open connection or dials a new one once the backoff has passed,
+// nil means logstash is unavailable for now
+func (l *LogStash) connection() net.Conn {
+	if l.conn != nil || time.Now().Before(l.retryAt) {
+		return l.conn
+	}
+	conn, err := net.DialTimeout(l.conf.Protocol, l.conf.Endpoint, l.timeout())
+	if err != nil {
+		l.failed(fmt.Errorf("dial failed, %w", err))
+		return nil
+	}
+	l.conn = conn
+	l.backoff = 0
+	l.mu.Lock()
+	l.connErr = nil
+	l.mu.Unlock()
+	log.Printf("logstash connected to %s over %s\n", l.conf.Endpoint, l.conf.Protocol)
+	return conn
+}
+
+// failed records err and doubles the reconnect backoff. It logs to the standard logger,
+// a zap line would only end up in the queue it failed to ship.
+func (l *LogStash) failed(err error) {
+	if l.backoff == 0 {
+		l.backoff = time.Duration(l.conf.ReconnectBackoff) * time.Millisecond
+	} else {
+		l.backoff = min(2*l.backoff, time.Duration(l.conf.ReconnectMaxBackoff)*time.Millisecond)
+	}
+	l.retryAt = time.Now().Add(l.backoff)
+	l.mu.Lock()
+	l.connErr = err
+	l.mu.Unlock()
+	log.Printf("logstash unavailable, retrying in %s: %v\n", l.backoff, err)
+}
+
+// spill writes a line logstash did not take to the file, it is dropped when there is no file
+func (l *LogStash) spill(line []byte, reason string) {
+	if l.file == nil {
+		l.metrics.dropped.WithLabelValues(reason).Inc()
+		return
+	}
+	if _, err := l.file.Write(line); err != nil {
+		l.metrics.dropped.WithLabelValues(reasonFileFailed).Inc()
+		return
+	}
+	l.metrics.fallback.WithLabelValues(reason).Inc()
+}
+
+func (l *LogStash) timeout() time.Duration {
+	return time.Duration(l.conf.Timeout) * time.Second
+}
+
+// check fails while logstash is unreachable, udp only learns about a missing logstash from
+// the writes that follow
+func (l *LogStash) check(context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.connErr != nil {
+		return fmt.Errorf("logstash unavailable, %w", l.connErr)
+	}
+	return nil
 }
@@ -0,0 +1,43 @@
+package logstash
+
+import (
+	"giftcard/internal/adaptor/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// reasons a line did not reach logstash
+const (
+	reasonBufferFull   = "buffer_full"
+	reasonDisconnected = "disconnected"
+	reasonWriteFailed  = "write_failed"
+	reasonStopped      = "stopped"
+	reasonFileFailed   = "file_failed"
+)
+
+type shipMetrics struct {
+	shipped  prometheus.Counter
+	fallback *prometheus.CounterVec
+	dropped  *prometheus.CounterVec
+}
+
+func newShipMetrics(registry *prometheus.Registry) *shipMetrics {
+	m := &shipMetrics{
+		shipped: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Name:      "logs_shipped_total",
+			Help:      "Log lines written to logstash.",
+		}),
+		fallback: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Name:      "logs_fallback_total",
+			Help:      "Log lines written to the local log file because logstash did not take them.",
+		}, []string{"reason"}),
+		dropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Name:      "logs_dropped_total",
+			Help:      "Log lines that reached neither logstash nor the local log file.",
+		}, []string{"reason"}),
+	}
+	registry.MustRegister(m.shipped, m.fallback, m.dropped)
+	return m
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sync"
+	"time"
+)
+
+// Workers reports the lag of the background workers, the time since each one last caught up
+// with its queue. A healthy worker stays below its poll interval, a growing lag means the
+// worker is stuck or cannot keep up.
+type Workers struct {
+	mu       sync.Mutex
+	caughtUp map[string]time.Time
+	lag      *prometheus.Desc
+}
+
+func NewWorkers(registry *prometheus.Registry) *Workers {
+	workers := &Workers{
+		caughtUp: map[string]time.Time{},
+		lag: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "worker", "lag_seconds"),
+			"Seconds since the worker last processed everything that was due.",
+			[]string{"worker"}, nil),
+	}
+	registry.MustRegister(workers)
+	return workers
+}
+
+// CaughtUp records that worker has nothing due left, workers call it once on start too
+func (w *Workers) CaughtUp(worker string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.caughtUp[worker] = time.Now()
+}
+
+func (w *Workers) Describe(ch chan<- *prometheus.Desc) {
+	ch <- w.lag
+}
+
+func (w *Workers) Collect(ch chan<- prometheus.Metric) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for worker, at := range w.caughtUp {
+		ch <- prometheus.MustNewConstMetric(w.lag, prometheus.GaugeValue, time.Since(at).Seconds(), worker)
+	}
+}
@@ -0,0 +1,269 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"giftcard/model"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    fstest.MapFS
+		versions []uint
+		err      string
+	}{
+		{
+			name: "pairs up and down in version order",
+			files: fstest.MapFS{
+				"0002_second.up.sql":   {Data: []byte("up 2")},
+				"0002_second.down.sql": {Data: []byte("down 2")},
+				"0001_first.up.sql":    {Data: []byte("up 1")},
+				"0001_first.down.sql":  {Data: []byte("down 1")},
+			},
+			versions: []uint{1, 2},
+		},
+		{
+			name: "missing down",
+			files: fstest.MapFS{
+				"0001_first.up.sql": {Data: []byte("up 1")},
+			},
+			err: "needs both an up and a down script",
+		},
+		{
+			name: "unexpected file",
+			files: fstest.MapFS{
+				"README.md": {Data: []byte("")},
+			},
+			err: "unexpected migration file",
+		},
+		{
+			name: "one version two names",
+			files: fstest.MapFS{
+				"0001_first.up.sql":   {Data: []byte("up 1")},
+				"0001_other.down.sql": {Data: []byte("down 1")},
+			},
+			err: "has two names",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := loadMigrations(tt.files, ".")
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(migrations) != len(tt.versions) {
+				t.Fatalf("expected %d migrations, got %d", len(tt.versions), len(migrations))
+			}
+			for i, version := range tt.versions {
+				if migrations[i].Version != version {
+					t.Errorf("migration %d has version %d, expected %d", i, migrations[i].Version, version)
+				}
+				if migrations[i].Up != fmt.Sprintf("up %d", version) || migrations[i].Down != fmt.Sprintf("down %d", version) {
+					t.Errorf("migration %d has scripts %q and %q", version, migrations[i].Up, migrations[i].Down)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateMigration(t *testing.T) {
+	dir := t.TempDir()
+	up, down, err := CreateMigration(dir, "add_orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(up) != "0001_add_orders.up.sql" || filepath.Base(down) != "0001_add_orders.down.sql" {
+		t.Fatalf("unexpected first files %s and %s", up, down)
+	}
+	up, _, err = CreateMigration(dir, "add_index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(up) != "0002_add_index.up.sql" {
+		t.Fatalf("unexpected second file %s", up)
+	}
+	if _, _, err := CreateMigration(dir, "add-index"); err == nil {
+		t.Fatal("expected a name with a dash to be refused")
+	}
+}
+
+// legacyOrderColumns is the orders table of the first AutoMigrate, before the series of
+// order features
+var legacyOrderColumns = map[string]bool{
+	"id": true, "created_at": true, "updated_at": true, "deleted_at": true, "sku": true,
+	"order_id": true, "product_type": true, "quote": true, "quantity": true, "status": true,
+}
+
+// TestBaselineUpgradesLegacyOrders guards the migrations against a column that only the
+// CREATE TABLE knows about, a database created by AutoMigrate would never get it
+func TestBaselineUpgradesLegacyOrders(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := schema.Parse(&model.Order{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var up strings.Builder
+	for _, migration := range migrations {
+		up.WriteString(migration.Up)
+	}
+	for _, column := range order.DBNames {
+		if legacyOrderColumns[column] {
+			continue
+		}
+		statement := fmt.Sprintf(`ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "%s"`, column)
+		if !strings.Contains(up.String(), statement) {
+			t.Errorf("no migration adds orders.%s to a legacy database", column)
+		}
+	}
+}
+
+// legacyOrder and legacyWallet are the models the first AutoMigrate created the tables from
+type legacyOrder struct {
+	gorm.Model
+	SKU         string
+	OrderID     string
+	ProductType string
+	Quote       uint
+	Quantity    uint
+	Status      string
+}
+
+func (legacyOrder) TableName() string {
+	return "orders"
+}
+
+type legacyWallet struct {
+	gorm.Model
+	Currency      string  `gorm:"column:currency;type:varchar(3);not null"`
+	Balance       float64 `gorm:"column:balance;type:numeric;not null"`
+	CreditBalance float64 `gorm:"column:credit_balance;type:numeric;not null"`
+	FrozenBalance float64 `gorm:"column:frozen_balance;type:numeric;not null"`
+}
+
+func (legacyWallet) TableName() string {
+	return "Wallet"
+}
+
+// TestMigrations runs against GIFTCARD_TEST_POSTGRES_DSN, a keyword/value dsn, each case in
+// a schema of its own
+func TestMigrations(t *testing.T) {
+	dsn := os.Getenv("GIFTCARD_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("GIFTCARD_TEST_POSTGRES_DSN is not set")
+	}
+
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, db *gorm.DB)
+	}{
+		{
+			name:  "fresh",
+			setup: func(t *testing.T, db *gorm.DB) {},
+		},
+		{
+			name: "legacy",
+			setup: func(t *testing.T, db *gorm.DB) {
+				if err := db.AutoMigrate(&legacyOrder{}, &legacyWallet{}); err != nil {
+					t.Fatal(err)
+				}
+				if err := db.Create(&legacyOrder{SKU: "sku", OrderID: "order", Status: "completed"}).Error; err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testSchema(t, dsn, fmt.Sprintf("migrate_%s_%d", tt.name, time.Now().UnixNano()))
+			tt.setup(t, db)
+
+			migrator, err := NewMigrator(db)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.Background()
+			if _, err := migrator.Up(ctx); err != nil {
+				t.Fatalf("up failed, %v", err)
+			}
+			statuses, err := migrator.Status(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, status := range statuses {
+				if status.AppliedAt == nil {
+					t.Errorf("migration %d_%s is still pending", status.Version, status.Name)
+				}
+			}
+
+			order := model.Order{SKU: "sku", OrderID: "new", Status: "created", Currency: "USD"}
+			if err := db.Create(&order).Error; err != nil {
+				t.Fatalf("the migrated orders table does not fit the model, %v", err)
+			}
+			var nullTotals int64
+			if err := db.Model(&model.Order{}).Where("total IS NULL").Count(&nullTotals).Error; err != nil {
+				t.Fatal(err)
+			}
+			if nullTotals != 0 {
+				t.Errorf("%d orders have no total", nullTotals)
+			}
+
+			if _, err := migrator.Down(ctx, len(statuses)-1); err != nil {
+				t.Fatalf("down failed, %v", err)
+			}
+			if db.Migrator().HasTable("order_items") {
+				t.Error("order_items survived down")
+			}
+			if _, err := migrator.Down(ctx, 1); err == nil || !strings.Contains(err.Error(), "baseline cannot be reverted") {
+				t.Fatalf("expected the baseline to refuse down, got %v", err)
+			}
+			if !db.Migrator().HasTable("orders") {
+				t.Error("down of the baseline dropped orders")
+			}
+		})
+	}
+}
+
+func testSchema(t *testing.T, dsn string, name string) *gorm.DB {
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := admin.Exec(fmt.Sprintf(`CREATE SCHEMA "%s"`, name)).Error; err != nil {
+		t.Fatal(err)
+	}
+	db, err := gorm.Open(postgres.Open(dsn+" search_path="+name), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+		admin.Exec(fmt.Sprintf(`DROP SCHEMA "%s" CASCADE`, name))
+		if sqlDB, err := admin.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	return db
+}
@@ -0,0 +1,7 @@
+-- orders and Wallet predate the baseline on databases upgraded from AutoMigrate and hold their
+-- data, reverting the baseline would drop what it never created
+DO $$
+BEGIN
+    RAISE EXCEPTION 'baseline cannot be reverted';
+END
+$$;
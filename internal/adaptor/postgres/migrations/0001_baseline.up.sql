@@ -0,0 +1,290 @@
+-- baseline of the schema AutoMigrate used to maintain. A database AutoMigrate created
+-- gets the tables and columns added since, everything else is a no-op on it.
+
+CREATE TABLE IF NOT EXISTS "Wallet" (
+    "id" bigserial,
+    "currency" varchar(3) NOT NULL,
+    "balance" numeric NOT NULL,
+    "credit_balance" numeric NOT NULL,
+    "frozen_balance" numeric NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "orders" (
+    "id" bigserial,
+    "sku" text,
+    "order_id" text,
+    "product_type" text,
+    "quote" bigint,
+    "quantity" bigint,
+    "status" text,
+    "total" decimal NOT NULL DEFAULT 0,
+    "currency" text,
+    "country" text,
+    "created_by" text,
+    "team" text,
+    "invoice" text,
+    "expires_at" timestamptz,
+    "confirmed_at" timestamptz,
+    "schedule_id" bigint,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "approval_policy" text,
+    "required_approvals" bigint,
+    "approval_status" text,
+    PRIMARY KEY ("id")
+);
+-- the first orders table only had the product, status and timestamps, CREATE TABLE above
+-- leaves it as it is. total defaults to 0 so the (total, id) keyset never meets a NULL.
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "total" decimal NOT NULL DEFAULT 0;
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "currency" text;
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "country" text;
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "created_by" text;
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "team" text;
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "invoice" text;
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "expires_at" timestamptz;
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "confirmed_at" timestamptz;
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "schedule_id" bigint;
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "approval_policy" text;
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "required_approvals" bigint;
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "approval_status" text;
+CREATE INDEX IF NOT EXISTS "idx_orders_product_type_created" ON "orders" ("product_type","created_at");
+CREATE INDEX IF NOT EXISTS "idx_orders_sku_created" ON "orders" ("sku","created_at");
+CREATE INDEX IF NOT EXISTS "idx_orders_created" ON "orders" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_orders_schedule_id" ON "orders" ("schedule_id");
+CREATE INDEX IF NOT EXISTS "idx_orders_expires_at" ON "orders" ("expires_at");
+CREATE INDEX IF NOT EXISTS "idx_orders_team" ON "orders" ("team");
+CREATE INDEX IF NOT EXISTS "idx_orders_created_by_created" ON "orders" ("created_by","created_at");
+CREATE INDEX IF NOT EXISTS "idx_orders_status_created" ON "orders" ("status","created_at");
+
+CREATE TABLE IF NOT EXISTS "webhook_subscriptions" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "url" text NOT NULL,
+    "secret" text NOT NULL,
+    "event_types" text,
+    "active" boolean NOT NULL DEFAULT true,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_webhook_subscriptions_deleted_at" ON "webhook_subscriptions" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "webhook_deliveries" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "subscription_id" bigint NOT NULL,
+    "event_id" text NOT NULL,
+    "event_type" text NOT NULL,
+    "payload" text NOT NULL,
+    "status" text NOT NULL,
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "next_attempt_at" timestamptz NOT NULL,
+    "last_status_code" bigint,
+    "last_error" text,
+    "delivered_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_webhook_delivery_due" ON "webhook_deliveries" ("status","next_attempt_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_webhook_delivery_event" ON "webhook_deliveries" ("subscription_id","event_id");
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_deleted_at" ON "webhook_deliveries" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "outbox_events" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "event_id" text NOT NULL,
+    "event_type" text NOT NULL,
+    "aggregate_id" text NOT NULL,
+    "payload" text NOT NULL,
+    "status" text NOT NULL,
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "next_attempt_at" timestamptz NOT NULL,
+    "last_error" text,
+    "published_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_outbox_events_deleted_at" ON "outbox_events" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_outbox_due" ON "outbox_events" ("status","next_attempt_at");
+CREATE INDEX IF NOT EXISTS "idx_outbox_events_aggregate_id" ON "outbox_events" ("aggregate_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_outbox_events_event_id" ON "outbox_events" ("event_id");
+
+CREATE TABLE IF NOT EXISTS "order_approvals" (
+    "id" bigserial,
+    "order_ref" bigint NOT NULL,
+    "order_id" text NOT NULL,
+    "principal" text NOT NULL,
+    "decision" text NOT NULL,
+    "comment" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_order_approval_principal" ON "order_approvals" ("order_ref","principal");
+
+CREATE TABLE IF NOT EXISTS "order_imports" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "file_name" text,
+    "created_by" text,
+    "team" text,
+    "status" text NOT NULL,
+    "chunk_size" bigint NOT NULL,
+    "total_rows" bigint NOT NULL,
+    "succeeded_rows" bigint NOT NULL DEFAULT 0,
+    "failed_rows" bigint NOT NULL DEFAULT 0,
+    "locked_until" timestamptz,
+    "finished_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_order_imports_status" ON "order_imports" ("status");
+CREATE INDEX IF NOT EXISTS "idx_order_imports_deleted_at" ON "order_imports" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "order_import_rows" (
+    "id" bigserial,
+    "import_id" bigint NOT NULL,
+    "row_number" bigint NOT NULL,
+    "product_id" text,
+    "sku" text NOT NULL,
+    "product_type" text NOT NULL,
+    "quote" bigint NOT NULL,
+    "quantity" bigint NOT NULL,
+    "status" text NOT NULL,
+    "order_id" text,
+    "error" text,
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_order_import_row" ON "order_import_rows" ("import_id","row_number");
+
+CREATE TABLE IF NOT EXISTS "order_exports" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "format" text NOT NULL,
+    "from_date" timestamptz NOT NULL,
+    "to_date" timestamptz NOT NULL,
+    "currency" text,
+    "team" text,
+    "created_by" text,
+    "status" text NOT NULL,
+    "rows" bigint NOT NULL DEFAULT 0,
+    "file_path" text,
+    "error" text,
+    "locked_until" timestamptz,
+    "finished_at" timestamptz,
+    "expires_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_order_exports_expires_at" ON "order_exports" ("expires_at");
+CREATE INDEX IF NOT EXISTS "idx_order_exports_status" ON "order_exports" ("status");
+CREATE INDEX IF NOT EXISTS "idx_order_exports_deleted_at" ON "order_exports" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "invoice_documents" (
+    "id" bigserial,
+    "order_ref" bigint NOT NULL,
+    "number" text NOT NULL,
+    "content" bytea NOT NULL,
+    "generated_at" timestamptz NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_invoice_documents_number" ON "invoice_documents" ("number");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_invoice_documents_order_ref" ON "invoice_documents" ("order_ref");
+
+CREATE TABLE IF NOT EXISTS "exchange_rates" (
+    "id" bigserial,
+    "base_currency" text NOT NULL,
+    "target_currency" text NOT NULL,
+    "modified_date" timestamptz NOT NULL,
+    "rate" decimal NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_exchange_rate_pair_modified" ON "exchange_rates" ("base_currency","target_currency","modified_date");
+
+CREATE TABLE IF NOT EXISTS "order_exchange_rates" (
+    "order_ref" bigint,
+    "exchange_rate_ref" bigint,
+    PRIMARY KEY ("order_ref","exchange_rate_ref")
+);
+CREATE INDEX IF NOT EXISTS "idx_order_exchange_rates_exchange_rate_ref" ON "order_exchange_rates" ("exchange_rate_ref");
+
+CREATE TABLE IF NOT EXISTS "order_schedules" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "name" text NOT NULL,
+    "spec" text NOT NULL,
+    "timezone" text NOT NULL DEFAULT 'UTC',
+    "products" text,
+    "auto_confirm" boolean NOT NULL DEFAULT false,
+    "run_budget" decimal NOT NULL DEFAULT 0,
+    "monthly_budget" decimal NOT NULL DEFAULT 0,
+    "status" text NOT NULL,
+    "next_run_at" timestamptz,
+    "last_run_at" timestamptz,
+    "run_requested" boolean NOT NULL DEFAULT false,
+    "created_by" text,
+    "team" text,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_order_schedules_due" ON "order_schedules" ("status","next_run_at");
+CREATE INDEX IF NOT EXISTS "idx_order_schedules_deleted_at" ON "order_schedules" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "order_schedule_runs" (
+    "id" bigserial,
+    "schedule_id" bigint NOT NULL,
+    "order_id" text,
+    "status" text NOT NULL,
+    "total" decimal NOT NULL DEFAULT 0,
+    "currency" text,
+    "error" text,
+    "scheduled_for" timestamptz NOT NULL,
+    "manual" boolean NOT NULL DEFAULT false,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_order_schedule_runs_schedule_id" ON "order_schedule_runs" ("schedule_id");
+
+CREATE TABLE IF NOT EXISTS "order_refunds" (
+    "id" bigserial,
+    "order_ref" bigint,
+    "order_id" text,
+    "reason" text,
+    "note" text,
+    "currency" varchar(3),
+    "expected_amount" numeric NOT NULL,
+    "received_amount" numeric NOT NULL DEFAULT 0,
+    "status" text,
+    "received_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_order_refunds_status" ON "order_refunds" ("status");
+CREATE INDEX IF NOT EXISTS "idx_order_refunds_currency" ON "order_refunds" ("currency");
+CREATE INDEX IF NOT EXISTS "idx_order_refunds_order_id" ON "order_refunds" ("order_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_order_refunds_order_reason" ON "order_refunds" ("order_ref","reason");
+
+CREATE TABLE IF NOT EXISTS "refund_ledger_cursors" (
+    "currency" varchar(3),
+    "wallet_id" bigint,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("currency")
+);
+
+-- orders and wallets embedded gorm.Model next to their own id and timestamps, nothing
+-- ever soft deleted them so the column goes
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "deleted_at";
+ALTER TABLE "Wallet" DROP COLUMN IF EXISTS "deleted_at";
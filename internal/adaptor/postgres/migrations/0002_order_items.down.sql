@@ -0,0 +1,3 @@
+ALTER TABLE "order_imports" DROP COLUMN IF EXISTS "unknown_rows";
+ALTER TABLE "order_import_rows" DROP COLUMN IF EXISTS "order_ref";
+DROP TABLE IF EXISTS "order_items";
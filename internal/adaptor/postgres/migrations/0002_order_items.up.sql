@@ -0,0 +1,22 @@
+-- every product line of an order, the orders row only kept the first one
+CREATE TABLE IF NOT EXISTS "order_items" (
+    "id" bigserial,
+    "order_ref" bigint NOT NULL,
+    "product_id" text,
+    "sku" text NOT NULL,
+    "product_type" text NOT NULL,
+    "quote" bigint NOT NULL,
+    "quantity" bigint NOT NULL,
+    "country" text,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_order_items_order_ref" ON "order_items" ("order_ref");
+
+-- the orders created before know their first line only
+INSERT INTO "order_items" ("order_ref", "sku", "product_type", "quote", "quantity", "country")
+SELECT "id", COALESCE("sku", ''), COALESCE("product_type", ''), COALESCE("quote", 0), COALESCE("quantity", 0), "country"
+FROM "orders";
+
+-- import rows whose provider call failed without an answer
+ALTER TABLE "order_import_rows" ADD COLUMN IF NOT EXISTS "order_ref" bigint;
+ALTER TABLE "order_imports" ADD COLUMN IF NOT EXISTS "unknown_rows" bigint NOT NULL DEFAULT 0;
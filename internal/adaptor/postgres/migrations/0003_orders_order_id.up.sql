@@ -0,0 +1,3 @@
+-- every confirm, status poll, cancel, approval and reconcile looks the order up by order_id,
+-- orders the provider has not answered yet share the empty id
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_orders_order_id" ON "orders" ("order_id") WHERE "order_id" <> '';
@@ -0,0 +1,2 @@
+-- the order lock fencing token of the last save, see model.Order.LockFence
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "lock_fence" bigint NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+ALTER TABLE "order_exports" ADD COLUMN IF NOT EXISTS "file_path" text;
+ALTER TABLE "order_exports" DROP COLUMN IF EXISTS "has_file";
+DROP TABLE IF EXISTS "order_export_files";
@@ -0,0 +1,11 @@
+-- export files move from the local dir of the replica that wrote them to the database, so
+-- every replica can serve and purge them. Files written before are not carried over, their
+-- exports answer as expired.
+CREATE TABLE IF NOT EXISTS "order_export_files" (
+    "export_id" bigint,
+    "content" bytea NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("export_id")
+);
+ALTER TABLE "order_exports" ADD COLUMN IF NOT EXISTS "has_file" boolean NOT NULL DEFAULT false;
+ALTER TABLE "order_exports" DROP COLUMN IF EXISTS "file_path";
@@ -1,44 +1,239 @@
 package postgres
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"giftcard/config"
-	model2 "giftcard/model"
+	"giftcard/pkg/health"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"log"
+	"sync/atomic"
+	"time"
 )
 
-var database *gorm.DB
-var e error
+// Cluster is the primary database and its optional read replicas
+type Cluster struct {
+	primary  *gorm.DB
+	replicas []*replica
+	next     atomic.Uint64
+	done     chan struct{}
+	stopped  chan struct{}
+}
+
+type replica struct {
+	name    string
+	db      *gorm.DB
+	healthy atomic.Bool
+}
+
+// NewPostgres opens the handles without dialing, the primary is reached on start with
+// retries and the replicas join the read rotation once a probe succeeds
+func NewPostgres(lc fx.Lifecycle, checks *health.Registry) (*Cluster, error) {
+	conf := config.C().DataBase
+	primary, err := open(conf, conf.Host, conf.Port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres, %w", err)
+	}
+
+	cluster := &Cluster{
+		primary: primary,
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	for _, conn := range conf.Replicas {
+		db, err := open(conf, conn.Host, conn.Port)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open postgres replica, %w", err)
+		}
+		cluster.replicas = append(cluster.replicas, &replica{
+			name: fmt.Sprintf("%s:%d", conn.Host, conn.Port),
+			db:   db,
+		})
+	}
+
+	checks.Register("postgres", 0, cluster.Ping)
+	for _, r := range cluster.replicas {
+		checks.Register("postgres_replica_"+r.name, 0, func(ctx context.Context) error {
+			return ping(ctx, r.db)
+		})
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			if err := cluster.connect(ctx, conf); err != nil {
+				return err
+			}
+			cluster.probeReplicas(ctx)
+			go cluster.watch(seconds(conf.HealthInterval))
+			log.Println("postgres connected")
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			close(cluster.done)
+			<-cluster.stopped
+			log.Println("postgres closed")
+			return cluster.close()
+		},
+	})
+	return cluster, nil
+}
+
+// DB is the primary, everything that writes or must read its own writes uses it
+func DB(cluster *Cluster) *gorm.DB {
+	return cluster.Primary()
+}
+
+func (c *Cluster) Primary() *gorm.DB {
+	return c.primary
+}
+
+// Reader picks a healthy replica in turn and falls back to the primary, use it only for
+// queries that tolerate replication lag
+func (c *Cluster) Reader() *gorm.DB {
+	count := uint64(len(c.replicas))
+	for i := uint64(0); i < count; i++ {
+		candidate := c.replicas[(c.next.Add(1)-1)%count]
+		if candidate.healthy.Load() {
+			return candidate.db
+		}
+	}
+	return c.primary
+}
+
+// Ping checks the primary is reachable
+func (c *Cluster) Ping(ctx context.Context) error {
+	return ping(ctx, c.primary)
+}
 
-func init() {
-	config.C()
+// connect waits for the primary, a database that is still starting next to the service
+// gets conf.ConnectRetries more attempts
+func (c *Cluster) connect(ctx context.Context, conf config.Postgres) error {
+	var err error
+	for attempt := 0; attempt <= conf.ConnectRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("failed to connect postgres, %w", errors.Join(err, ctx.Err()))
+			case <-time.After(seconds(conf.ConnectRetryInterval)):
+			}
+		}
+		if err = c.Ping(ctx); err == nil {
+			return nil
+		}
+		zap.L().Warn("postgres unreachable",
+			zap.Int("attempt", attempt+1),
+			zap.String("error", err.Error()))
+	}
+	return fmt.Errorf("failed to connect postgres, %w", err)
+}
+
+func (c *Cluster) watch(interval time.Duration) {
+	defer close(c.stopped)
+	if interval <= 0 {
+		<-c.done
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			if err := c.Ping(ctx); err != nil {
+				zap.L().Error("postgres primary unhealthy", zap.String("error", err.Error()))
+			}
+			c.probeReplicas(ctx)
+			cancel()
+		}
+	}
+}
+
+// probeReplicas takes failing replicas out of the read rotation and puts recovered ones back
+func (c *Cluster) probeReplicas(ctx context.Context) {
+	for _, r := range c.replicas {
+		err := ping(ctx, r.db)
+		healthy := err == nil
+		if r.healthy.Swap(healthy) == healthy {
+			continue
+		}
+		if healthy {
+			zap.L().Info("postgres replica healthy", zap.String("replica", r.name))
+		} else {
+			zap.L().Warn("postgres replica unhealthy, reading from the primary",
+				zap.String("replica", r.name),
+				zap.String("error", err.Error()))
+		}
+	}
+}
+
+func (c *Cluster) close() error {
+	var errs []error
+	for _, db := range append([]*gorm.DB{c.primary}, c.replicaDBs()...) {
+		sqlDB, err := db.DB()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		errs = append(errs, sqlDB.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Cluster) replicaDBs() []*gorm.DB {
+	dbs := make([]*gorm.DB, 0, len(c.replicas))
+	for _, r := range c.replicas {
+		dbs = append(dbs, r.db)
+	}
+	return dbs
+}
+
+func open(conf config.Postgres, host string, port int) (*gorm.DB, error) {
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s TimeZone=%s",
-		config.C().DataBase.Host,
-		config.C().DataBase.Username,
-		config.C().DataBase.Password,
-		config.C().DataBase.Schema,
-		config.C().DataBase.Port,
-		config.C().DataBase.SSLMode,
-		config.C().DataBase.TimeZone,
+		host,
+		conf.Username,
+		conf.Password,
+		conf.Schema,
+		port,
+		conf.SSLMode,
+		conf.TimeZone,
 	)
-	database, e = gorm.Open(postgres.Open(dsn), &gorm.Config{})
-	if e != nil {
-		log.Fatal("failed to connect postgres")
+	if conf.ConnectTimeout > 0 {
+		dsn += fmt.Sprintf(" connect_timeout=%d", conf.ConnectTimeout)
 	}
+	if conf.StatementTimeout > 0 {
+		dsn += fmt.Sprintf(" statement_timeout=%d", conf.StatementTimeout)
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(conf.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(conf.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(seconds(conf.ConnMaxLifetime))
+	sqlDB.SetConnMaxIdleTime(seconds(conf.ConnMaxIdleTime))
+	return db, nil
 }
 
-func MigrateModels() error {
-	err := database.AutoMigrate(
-		&model2.Wallet{},
-		&model2.Order{},
-	)
+func ping(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
 	if err != nil {
-		return fmt.Errorf("failed to migrate model, %w", err)
+		return err
 	}
-	return nil
+	return sqlDB.PingContext(ctx)
 }
-func DB() *gorm.DB {
-	return database
+
+func seconds(value int) time.Duration {
+	return time.Duration(value) * time.Second
 }
@@ -0,0 +1,84 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+)
+
+const lockRetryInterval = 50 * time.Millisecond
+
+// ErrLockNotAcquired is returned while another owner holds the lock
+var ErrLockNotAcquired = errors.New("lock is held by another owner")
+
+// unlockScript deletes the key only while it still holds the owner token, so an owner whose
+// lock expired never releases the lock of the next owner
+var unlockScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0`)
+
+// Lock is a lock taken with SET NX, it expires on its own when the owner dies
+type Lock struct {
+	store *Store
+	key   string
+	token int64
+}
+
+// TryLock takes the lock once. Every attempt draws a fencing token from a counter next to the
+// lock key, tokens only grow so a later owner always holds a larger one.
+func (r *Store) TryLock(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	token, err := r.db.Incr(ctx, key+":fence").Result()
+	if err != nil {
+		return nil, err
+	}
+	ok, err := r.db.SetNX(ctx, key, strconv.FormatInt(token, 10), ttl).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrLockNotAcquired
+	}
+	return &Lock{store: r, key: key, token: token}, nil
+}
+
+// Lock waits up to wait for the lock, it returns ErrLockNotAcquired when the wait is over
+func (r *Store) Lock(ctx context.Context, key string, ttl time.Duration, wait time.Duration) (*Lock, error) {
+	deadline := time.Now().Add(wait)
+	for {
+		lock, err := r.TryLock(ctx, key, ttl)
+		if !errors.Is(err, ErrLockNotAcquired) || time.Now().After(deadline) {
+			return lock, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+// Token is the fencing token of this owner
+func (l *Lock) Token() int64 {
+	return l.token
+}
+
+// Held reports whether the lock still belongs to this owner, false once it expired
+func (l *Lock) Held(ctx context.Context) (bool, error) {
+	value, err := l.store.db.Get(ctx, l.key).Result()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return value == strconv.FormatInt(l.token, 10), nil
+}
+
+// Unlock releases the lock if this owner still holds it
+func (l *Lock) Unlock(ctx context.Context) error {
+	return unlockScript.Run(ctx, l.store.db, []string{l.key}, strconv.FormatInt(l.token, 10)).Err()
+}
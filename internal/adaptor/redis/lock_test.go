@@ -0,0 +1,88 @@
+package redis_test
+
+import (
+	"context"
+	"errors"
+	"giftcard/internal/adaptor/redis"
+	"giftcard/internal/adaptor/redis/redistest"
+	"testing"
+	"time"
+)
+
+func TestLock(t *testing.T) {
+	ctx := context.Background()
+	store, fake := redistest.NewStore()
+
+	first, err := store.TryLock(ctx, "order_lock:1", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.TryLock(ctx, "order_lock:1", time.Minute); !errors.Is(err, redis.ErrLockNotAcquired) {
+		t.Fatalf("expected a held lock to refuse a second owner, got %v", err)
+	}
+	if held, err := first.Held(ctx); err != nil || !held {
+		t.Fatalf("expected the first owner to hold the lock, got %v %v", held, err)
+	}
+
+	// the first owner stalls past its ttl and a second owner takes over
+	fake.Expire("order_lock:1")
+	second, err := store.TryLock(ctx, "order_lock:1", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Token() <= first.Token() {
+		t.Fatalf("fencing token went from %d to %d, it must grow", first.Token(), second.Token())
+	}
+	if held, _ := first.Held(ctx); held {
+		t.Fatal("the expired owner still believes it holds the lock")
+	}
+
+	if err := first.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if held, _ := second.Held(ctx); !held {
+		t.Fatal("the expired owner released the lock of the next owner")
+	}
+
+	if err := second.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fake.Value("order_lock:1"); ok {
+		t.Fatal("unlock left the lock behind")
+	}
+}
+
+func TestLockWait(t *testing.T) {
+	ctx := context.Background()
+	store, _ := redistest.NewStore()
+
+	held, err := store.TryLock(ctx, "order_lock:1", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Lock(ctx, "order_lock:1", time.Minute, 120*time.Millisecond); !errors.Is(err, redis.ErrLockNotAcquired) {
+		t.Fatalf("expected the wait to run out, got %v", err)
+	}
+
+	go func() {
+		time.Sleep(60 * time.Millisecond)
+		_ = held.Unlock(context.Background())
+	}()
+	lock, err := store.Lock(ctx, "order_lock:1", time.Minute, time.Second)
+	if err != nil {
+		t.Fatalf("expected the lock once released, got %v", err)
+	}
+	if lock.Token() <= held.Token() {
+		t.Fatalf("fencing token went from %d to %d, it must grow", held.Token(), lock.Token())
+	}
+}
+
+func TestLockDown(t *testing.T) {
+	store, fake := redistest.NewStore()
+	fake.SetDown(true)
+
+	_, err := store.TryLock(context.Background(), "order_lock:1", time.Minute)
+	if err == nil || errors.Is(err, redis.ErrLockNotAcquired) {
+		t.Fatalf("expected the backend error, got %v", err)
+	}
+}
@@ -2,24 +2,31 @@ package redis
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"giftcard/config"
+	"giftcard/pkg/health"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 	"log"
+	"net"
+	"os"
 	"time"
 )
 
 type Store struct {
-	db *redis.Client
+	db redis.UniversalClient
 }
 
-func NewRedis(lc fx.Lifecycle) *Store {
+func NewRedis(lc fx.Lifecycle, checks *health.Registry) *Store {
 	rds := Store{}
+	checks.Register("redis", 0, rds.Ping)
 	lc.Append(fx.Hook{
 		OnStart: func(c context.Context) error {
-			log.Println("redis connected")
 			return rds.connect(*config.C())
 		},
 		OnStop: func(c context.Context) error {
@@ -30,19 +37,112 @@ func NewRedis(lc fx.Lifecycle) *Store {
 	return &rds
 }
 
+// NewStore wraps a client that is already connected, the tests use it with a fake client
+func NewStore(client redis.UniversalClient) *Store {
+	return &Store{db: client}
+}
+
+// connect builds the client of the configured mode. An unreachable redis does not stop the
+// start, the client reconnects on its own and callers fall back where they can.
 func (r *Store) connect(confs config.Config) error {
-	var err error
+	options, err := universalOptions(confs.Redis)
+	if err != nil {
+		return err
+	}
 
-	r.db = redis.NewClient(&redis.Options{
-		DB:   confs.Redis.DB,
-		Addr: "localhost:6379",
-	})
+	switch confs.Redis.Mode {
+	case config.RedisSentinel:
+		r.db = redis.NewFailoverClient(options.Failover())
+	case config.RedisCluster:
+		r.db = redis.NewClusterClient(options.Cluster())
+	default:
+		r.db = redis.NewClient(options.Simple())
+	}
 
-	if err = r.db.Ping(context.Background()).Err(); err != nil {
-		zap.L().Error(err.Error())
+	if err := r.db.Ping(context.Background()).Err(); err != nil {
+		zap.L().Error("redis unreachable, continuing without it", zap.String("error", err.Error()))
+		log.Println("redis unreachable:", err)
+		return nil
 	}
+	log.Println("redis connected")
+	return nil
+}
 
-	return err
+func universalOptions(conf config.Redis) (*redis.UniversalOptions, error) {
+	options := &redis.UniversalOptions{
+		Addrs:            conf.Addrs,
+		MasterName:       conf.MasterName,
+		Username:         conf.Username,
+		Password:         conf.Password,
+		SentinelPassword: conf.SentinelPassword,
+		DB:               conf.DB,
+		PoolSize:         conf.PoolSize,
+		MinIdleConns:     conf.MinIdleConns,
+		MaxRetries:       conf.MaxRetries,
+		DialTimeout:      milliseconds(conf.DialTimeout),
+		ReadTimeout:      milliseconds(conf.ReadTimeout),
+		WriteTimeout:     milliseconds(conf.WriteTimeout),
+		PoolTimeout:      milliseconds(conf.PoolTimeout),
+	}
+	if conf.Mode == config.RedisStandalone || conf.Mode == "" {
+		options.Addrs = []string{net.JoinHostPort(conf.Host, conf.Port)}
+	}
+	if conf.TLS.Enabled {
+		tlsConfig, err := tlsConfig(conf.TLS)
+		if err != nil {
+			return nil, err
+		}
+		options.TLSConfig = tlsConfig
+	}
+	return options, nil
+}
+
+func tlsConfig(conf config.RedisTLS) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         conf.ServerName,
+		InsecureSkipVerify: conf.InsecureSkipVerify,
+	}
+	if conf.CAFile != "" {
+		ca, err := os.ReadFile(conf.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read redis ca file, %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificate found in redis ca file %s", conf.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if conf.CertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load redis client certificate, %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	return tlsConfig, nil
+}
+
+// milliseconds converts a config timeout, negative values keep their meaning for the client
+func milliseconds(value int) time.Duration {
+	if value < 0 {
+		return -1
+	}
+	return time.Duration(value) * time.Millisecond
+}
+
+// Ping checks redis answers
+func (r *Store) Ping(ctx context.Context) error {
+	if r.db == nil {
+		return errors.New("redis is not connected")
+	}
+	return r.db.Ping(ctx).Err()
+}
+
+// IsNil reports a missing key, every other error means redis itself failed
+func IsNil(err error) bool {
+	return errors.Is(err, redis.Nil)
 }
 
 func (r *Store) Set(ctx context.Context, key string, value interface{}, duration time.Duration) error {
@@ -54,6 +154,26 @@ func (r *Store) Set(ctx context.Context, key string, value interface{}, duration
 	return r.db.Set(ctx, key, p, duration).Err()
 }
 
+// SetNX meth, set value as json only when the key does not exist, it reports whether it was set
+func (r *Store) SetNX(ctx context.Context, key string, value interface{}, duration time.Duration) (bool, error) {
+	p, err := json.Marshal(value)
+	if err != nil {
+		zap.L().Error(err.Error())
+		return false, err
+	}
+	return r.db.SetNX(ctx, key, p, duration).Result()
+}
+
+// Delete meth, delete keys and return how many existed
+func (r *Store) Delete(ctx context.Context, keys ...string) (int64, error) {
+	return r.db.Del(ctx, keys...).Result()
+}
+
+// Incr meth, increment the integer at key and return the new value, a missing key counts from zero
+func (r *Store) Incr(ctx context.Context, key string) (int64, error) {
+	return r.db.Incr(ctx, key).Result()
+}
+
 // Get meth, get value with key
 func (r *Store) Get(ctx context.Context, key string, dest interface{}) error {
 	p, err := r.db.Get(ctx, key).Result()
@@ -63,3 +183,70 @@ func (r *Store) Get(ctx context.Context, key string, dest interface{}) error {
 
 	return json.Unmarshal([]byte(p), &dest)
 }
+
+// Publish meth, publish value as json on channel
+func (r *Store) Publish(ctx context.Context, channel string, value interface{}) error {
+	p, err := json.Marshal(value)
+	if err != nil {
+		zap.L().Error(err.Error())
+		return err
+	}
+	return r.db.Publish(ctx, channel, p).Err()
+}
+
+// Subscribe meth, subscribe to channels, caller must close the returned PubSub
+func (r *Store) Subscribe(ctx context.Context, channels ...string) *redis.PubSub {
+	return r.db.Subscribe(ctx, channels...)
+}
+
+// AddToStream meth, append value as json to a capped stream and return the entry id
+func (r *Store) AddToStream(ctx context.Context, key string, maxLen int64, duration time.Duration, value interface{}) (string, error) {
+	p, err := json.Marshal(value)
+	if err != nil {
+		zap.L().Error(err.Error())
+		return "", err
+	}
+
+	id, err := r.db.XAdd(ctx, &redis.XAddArgs{
+		Stream: key,
+		MaxLen: maxLen,
+		Approx: true,
+		Values: map[string]interface{}{"value": p},
+	}).Result()
+	if err != nil {
+		return "", err
+	}
+	return id, r.db.Expire(ctx, key, duration).Err()
+}
+
+// RangeStream meth, get raw json values of stream entries between start and stop
+func (r *Store) RangeStream(ctx context.Context, key string, start string, stop string) ([]StreamEntry, error) {
+	messages, err := r.db.XRange(ctx, key, start, stop).Result()
+	if err != nil {
+		return nil, err
+	}
+	return toStreamEntries(messages), nil
+}
+
+// LastFromStream meth, get the latest n stream entries, newest first
+func (r *Store) LastFromStream(ctx context.Context, key string, count int64) ([]StreamEntry, error) {
+	messages, err := r.db.XRevRangeN(ctx, key, "+", "-", count).Result()
+	if err != nil {
+		return nil, err
+	}
+	return toStreamEntries(messages), nil
+}
+
+type StreamEntry struct {
+	ID    string
+	Value []byte
+}
+
+func toStreamEntries(messages []redis.XMessage) []StreamEntry {
+	entries := make([]StreamEntry, 0, len(messages))
+	for _, message := range messages {
+		value, _ := message.Values["value"].(string)
+		entries = append(entries, StreamEntry{ID: message.ID, Value: []byte(value)})
+	}
+	return entries
+}
@@ -0,0 +1,152 @@
+// Package redistest serves the redis commands the locks and caches use from memory, so the
+// tests run without a redis server
+package redistest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	adaptor "giftcard/internal/adaptor/redis"
+	"github.com/redis/go-redis/v9"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrDown is returned by every command while the fake is down
+var ErrDown = errors.New("dial tcp: connection refused")
+
+// Fake is an in memory redis, keys never expire on their own, call Expire instead
+type Fake struct {
+	mu   sync.Mutex
+	data map[string]string
+	down bool
+}
+
+// NewStore returns a store backed by a new fake
+func NewStore() (*adaptor.Store, *Fake) {
+	fake := &Fake{data: map[string]string{}}
+	client := redis.NewClient(&redis.Options{Addr: "redistest:6379", MaxRetries: -1})
+	client.AddHook(fake)
+	return adaptor.NewStore(client), fake
+}
+
+// SetDown makes every command fail like an unreachable server
+func (f *Fake) SetDown(down bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.down = down
+}
+
+// Expire drops key as if its ttl ran out
+func (f *Fake) Expire(key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.data, key)
+}
+
+// Value returns the value of key and whether it is set
+func (f *Fake) Value(key string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	value, ok := f.data[key]
+	return value, ok
+}
+
+func (f *Fake) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return nil, ErrDown
+	}
+}
+
+func (f *Fake) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		for _, cmd := range cmds {
+			if err := f.process(cmd); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func (f *Fake) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		return f.process(cmd)
+	}
+}
+
+func (f *Fake) process(cmd redis.Cmder) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.down {
+		cmd.SetErr(ErrDown)
+		return ErrDown
+	}
+
+	args := make([]string, len(cmd.Args()))
+	for i, arg := range cmd.Args() {
+		args[i] = fmt.Sprint(arg)
+	}
+	switch cmd.Name() {
+	case "ping":
+		cmd.(*redis.StatusCmd).SetVal("PONG")
+	case "incr":
+		value, _ := strconv.ParseInt(f.data[args[1]], 10, 64)
+		value++
+		f.data[args[1]] = strconv.FormatInt(value, 10)
+		cmd.(*redis.IntCmd).SetVal(value)
+	case "get":
+		value, ok := f.data[args[1]]
+		if !ok {
+			cmd.SetErr(redis.Nil)
+			return redis.Nil
+		}
+		cmd.(*redis.StringCmd).SetVal(value)
+	case "set":
+		_, exists := f.data[args[1]]
+		nx := false
+		for _, arg := range args[3:] {
+			nx = nx || strings.EqualFold(arg, "nx")
+		}
+		if nx && exists {
+			if boolCmd, ok := cmd.(*redis.BoolCmd); ok {
+				boolCmd.SetVal(false)
+				return nil
+			}
+			cmd.SetErr(redis.Nil)
+			return redis.Nil
+		}
+		f.data[args[1]] = args[2]
+		switch c := cmd.(type) {
+		case *redis.BoolCmd:
+			c.SetVal(true)
+		case *redis.StatusCmd:
+			c.SetVal("OK")
+		}
+	case "del":
+		deleted := int64(0)
+		for _, key := range args[1:] {
+			if _, ok := f.data[key]; ok {
+				delete(f.data, key)
+				deleted++
+			}
+		}
+		cmd.(*redis.IntCmd).SetVal(deleted)
+	case "evalsha", "eval":
+		// the only script is the compare and delete of the lock release
+		key, token := args[3], args[4]
+		deleted := int64(0)
+		if f.data[key] == token {
+			delete(f.data, key)
+			deleted = 1
+		}
+		cmd.(*redis.Cmd).SetVal(deleted)
+	default:
+		err := fmt.Errorf("redistest: unsupported command %s", cmd.Name())
+		cmd.SetErr(err)
+		return err
+	}
+	return nil
+}
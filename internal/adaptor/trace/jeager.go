@@ -2,20 +2,27 @@ package trace
 
 import (
 	"context"
+	"errors"
 	fmt "fmt"
 	"giftcard/config"
+	"giftcard/pkg/health"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdk "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/fx"
-	"io"
 	"log"
+	"net"
 	"os"
+	"sort"
+	"time"
 )
 
 type ITracer interface {
@@ -30,48 +37,105 @@ type tracer struct {
 	oteTracer trace.Tracer
 }
 
-func InitGlobalTracer(lc fx.Lifecycle) {
-	config := config.C()
-	jaegerCloser, err := connect(config)
+// InitGlobalTracer installs the tracer provider of the configured exporter. The propagator
+// is installed even when tracing is disabled so trace context still flows through.
+func InitGlobalTracer(lc fx.Lifecycle, checks *health.Registry) error {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	confs := config.C()
+	if !confs.Tracer.Enabled {
+		log.Println("tracing disabled")
+		return nil
+	}
+
+	tp, err := newTracerProvider(confs)
 	if err != nil {
-		log.Fatalf("Error initializing Jaeger: %v", err)
+		return fmt.Errorf("failed to initialize tracing, %w", err)
+	}
+	otel.SetTracerProvider(tp)
+
+	if confs.Tracer.Exporter != config.TracerStdout {
+		endpoint := confs.Tracer.Endpoint
+		checks.Register("tracer", 0, func(ctx context.Context) error {
+			return reachable(ctx, endpoint)
+		})
 	}
+
 	lc.Append(fx.Hook{
 		OnStop: func(c context.Context) error {
-			log.Printf("Jaeger and OpenTelemetry shutdown\n")
-			jaegerCloser.Close()
-			return nil
+			log.Printf("OpenTelemetry shutdown\n")
+			// Shutdown flushes the spans still queued in the batcher
+			return tp.Shutdown(c)
 		},
 	})
+	return nil
 }
 
-func connect(confs *config.Config) (io.Closer, error) {
-	os.Setenv("OTEL_INSTRUMENTATION_CAPTURE_REQUEST_BODY", "true")
-	log.Println(confs.Jaeger)
-	exp, err := otlptrace.New(
-		context.Background(),
-		otlptracegrpc.NewClient(
-			otlptracegrpc.WithInsecure(),
-			otlptracegrpc.WithEndpoint(confs.Jaeger.HostPort)))
+func newTracerProvider(confs *config.Config) (*sdk.TracerProvider, error) {
+	exporter, err := newExporter(confs.Tracer)
 	if err != nil {
-		log.Fatalf(err.Error())
+		return nil, err
+	}
+
+	attributes := []attribute.KeyValue{
+		semconv.ServiceNameKey.String(confs.Service.Name),
+		semconv.DeploymentEnvironmentKey.String(confs.Tracer.Environment),
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		attributes = append(attributes, semconv.HostNameKey.String(hostname))
+	}
+	keys := make([]string, 0, len(confs.Tracer.Attributes))
+	for key := range confs.Tracer.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		attributes = append(attributes, attribute.String(key, confs.Tracer.Attributes[key]))
 	}
 
-	resources := resource.NewWithAttributes(
-		semconv.SchemaURL,
-		semconv.ServiceNameKey.String(config.C().Service.Name),
-		semconv.DeploymentEnvironmentKey.String("dev"))
+	return sdk.NewTracerProvider(
+		sdk.WithSampler(sdk.ParentBased(sdk.TraceIDRatioBased(confs.Tracer.SampleRatio))),
+		sdk.WithResource(resource.NewWithAttributes(semconv.SchemaURL, attributes...)),
+		sdk.WithBatcher(exporter),
+	), nil
+}
 
-	tp := sdk.NewTracerProvider(
-		sdk.WithSampler(sdk.AlwaysSample()),
-		sdk.WithResource(resources),
-		sdk.WithBatcher(exp),
-		//sdk.WithBatcher(sexp),
-	)
+func newExporter(confs config.Tracer) (sdk.SpanExporter, error) {
+	timeout := time.Duration(confs.Timeout) * time.Second
+	switch confs.Exporter {
+	case config.TracerStdout:
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case config.TracerOTLPHttp:
+		options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(confs.Endpoint)}
+		if confs.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		if timeout > 0 {
+			options = append(options, otlptracehttp.WithTimeout(timeout))
+		}
+		return otlptrace.New(context.Background(), otlptracehttp.NewClient(options...))
+	case config.TracerOTLPGrpc:
+		options := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(confs.Endpoint)}
+		if confs.Insecure {
+			options = append(options, otlptracegrpc.WithInsecure())
+		}
+		if timeout > 0 {
+			options = append(options, otlptracegrpc.WithTimeout(timeout))
+		}
+		return otlptrace.New(context.Background(), otlptracegrpc.NewClient(options...))
+	}
+	return nil, errors.New("unknown tracer exporter " + confs.Exporter)
+}
 
-	otel.SetTracerProvider(tp)
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
-	return nil, nil
+// reachable checks the collector accepts connections, the exporter itself only reports
+// failures in its own logs
+func reachable(ctx context.Context, hostPort string) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", hostPort)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
 }
 
 func (e *tracer) SpanFromContext(ctx context.Context, name string, spanType string) (trace.Span, context.Context) {
@@ -0,0 +1,56 @@
+package trace
+
+import (
+	"context"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+)
+
+// EchoMiddleware continues the trace of an incoming W3C traceparent header, or starts one,
+// with a server span around the request. Handlers pick it up through the request context.
+func EchoMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			request := c.Request()
+			ctx := otel.GetTextMapPropagator().Extract(request.Context(), propagation.HeaderCarrier(request.Header))
+
+			route := c.Path()
+			if route == "" {
+				route = request.URL.Path
+			}
+			ctx, span := otel.GetTracerProvider().Tracer("giftcard").Start(ctx,
+				request.Method+" "+route,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPMethodKey.String(request.Method),
+					semconv.HTTPRouteKey.String(route),
+					semconv.HTTPClientIPKey.String(c.RealIP()),
+				))
+			defer span.End()
+
+			c.SetRequest(request.WithContext(ctx))
+			err := next(c)
+			if err != nil {
+				// let the error handler write the response so the status below is the real one
+				c.Error(err)
+			}
+
+			status := c.Response().Status
+			span.SetAttributes(semconv.HTTPStatusCodeKey.Int(status))
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+			return nil
+		}
+	}
+}
+
+// Inject writes the trace context of ctx into outgoing request headers
+func Inject(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
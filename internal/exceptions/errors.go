@@ -14,4 +14,38 @@ const (
 	PageSizeError            = "تعداد لیست درخواست ها باید بین ۵ تا ۵۰ باشد"
 	ProductIDError           = "آی دی محصول مورد نیاز است"
 	DBError                  = "خطای دیتابیس"
+	InvalidWebhookInput      = "داده ورودی برای ثبت وب هوک نامعتبر می باشد"
+	WebhookNotFound          = "وب هوک یافت نشد"
+	UnsafeWebhookTarget      = "آدرس وب هوک باید یک آدرس عمومی http یا https باشد"
+	OrderNotReconcilable     = "سفارش در وضعیت قابل تطبیق با ارائه دهنده نیست"
+	OrderExpired             = "مهلت تایید سفارش به پایان رسیده است"
+	OrderApprovalPending     = "سفارش منتظر تایید مدیر است"
+	OrderRejected            = "سفارش توسط مدیر رد شده است"
+	OrderApprovalNotRequired = "سفارش نیازی به تایید مدیر ندارد"
+	OrderAlreadyDecided      = "نظر شما برای این سفارش قبلا ثبت شده است"
+	OrderSelfApproval        = "ثبت کننده سفارش نمی تواند آن را تایید کند"
+	OrderUnknownCreator      = "ثبت کننده سفارش مشخص نیست و سفارش قابل تایید نیست"
+	RequiredPrincipal        = "هویت درخواست کننده مشخص نیست"
+	InvalidCursor            = "نشانگر صفحه نامعتبر است"
+	InvalidOrderImport       = "فایل ورودی سفارش گروهی نامعتبر است"
+	OrderImportNotFound      = "سفارش گروهی یافت نشد"
+	OrderImportInProgress    = "سفارش گروهی در حال پردازش است"
+	InvalidExportFormat      = "قالب خروجی نامعتبر است"
+	ExportNotFound           = "خروجی یافت نشد"
+	ExportNotReady           = "فایل خروجی هنوز آماده نیست"
+	ExportExpired            = "مهلت دریافت فایل خروجی به پایان رسیده است"
+	InvoiceUnavailable       = "فاکتور فقط برای سفارش های تکمیل شده صادر می شود"
+	ExchangeRateNotFound     = "نرخ ارز یافت نشد"
+	InvalidSchedule          = "زمان بندی سفارش نامعتبر است"
+	ScheduleNotFound         = "زمان بندی سفارش یافت نشد"
+	OrderNotCancelable       = "فقط سفارش های تایید نشده قابل لغو هستند"
+	OrderCanceled            = "سفارش لغو شده است"
+	InvalidRefund            = "بازپرداخت نامعتبر است"
+	RefundNotFound           = "بازپرداخت یافت نشد"
+	RefundExists             = "بازپرداخت این سفارش قبلا ثبت شده است"
+	OrderLocked              = "سفارش در حال پردازش درخواست دیگری است"
+	OrderLockLost            = "قفل سفارش پیش از ذخیره منقضی شد"
+	OrderLocalStatus         = "وضعیت سفارش محلی است و با وضعیت ارائه دهنده جایگزین نمی شود"
+	TooManyConnections       = "تعداد اتصال های همزمان بیش از حد مجاز است"
+	InvalidLogLevel          = "سطح لاگ یا ماژول نامعتبر است"
 )
@@ -0,0 +1,77 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"giftcard/api/pb"
+	gftErr "giftcard/internal/adaptor/giftcard"
+	"giftcard/internal/adaptor/trace"
+	"giftcard/internal/exceptions"
+	"giftcard/internal/modules/customer/usecase"
+	"giftcard/pkg/logger"
+	"giftcard/pkg/utils"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type CustomerGrpcHandler struct {
+	pb.UnimplementedCustomerServiceServer
+	customerUseCase usecase.ICustomerUseCase
+}
+
+type CustomerGrpcHandlerParams struct {
+	fx.In
+	CustomerUseCase *usecase.CustomerUseCase
+}
+
+func NewCustomerGrpcHandler(params CustomerGrpcHandlerParams) *CustomerGrpcHandler {
+	return &CustomerGrpcHandler{
+		customerUseCase: params.CustomerUseCase,
+	}
+}
+
+func (h *CustomerGrpcHandler) GetCustomerInfo(ctx context.Context, _ *pb.GetCustomerInfoRequest) (*pb.DataResponse, error) {
+	span, spannedContext := trace.T.SpanFromContext(
+		ctx,
+		"CustomerInfo[CustomerGrpcDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID, _ := ctx.Value("tracer").(string)
+	logger := logger.For(ctx, logger.ModuleCustomer).With(
+		zap.String("tracer", uniqueID),
+	)
+
+	data, err := h.customerUseCase.GetCustomerInfoUseCase(spannedContext)
+	if err != nil {
+		return nil, toStatusError(logger, span, err)
+	}
+
+	value, err := utils.ToProtoValue(data.Data)
+	if err != nil {
+		return nil, toStatusError(logger, span, err)
+	}
+	return &pb.DataResponse{Data: value}, nil
+}
+
+func toStatusError(logger *zap.Logger, span oteltrace.Span, err error) error {
+	var forbiddenErr *gftErr.ForbiddenErr
+	if errors.As(err, &forbiddenErr) {
+		logger.Info("Response to client", zap.Any("error", forbiddenErr.ErrMsg))
+		span.SetAttributes(attribute.String(exceptions.StatusForbidden, forbiddenErr.ErrMsg))
+		return status.Error(codes.PermissionDenied, forbiddenErr.ErrMsg)
+	}
+	var reqErr *gftErr.RequestErr
+	if errors.As(err, &reqErr) {
+		logger.Info("Response to client", zap.Any("error", reqErr.ErrMsg))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, reqErr.ErrMsg))
+		return status.Error(codes.InvalidArgument, utils.Marshal(reqErr.Response))
+	}
+	logger.Info("Response to client", zap.Any("error", err.Error()))
+	span.SetAttributes(attribute.String(exceptions.InternalServerError, err.Error()))
+	return status.Error(codes.Internal, exceptions.InternalServerError)
+}
@@ -8,6 +8,7 @@ import (
 	"giftcard/internal/adaptor/trace"
 	"giftcard/internal/exceptions"
 	"giftcard/internal/modules/customer/usecase"
+	"giftcard/pkg/logger"
 	"giftcard/pkg/requester"
 	"giftcard/pkg/responser"
 	"giftcard/pkg/utils"
@@ -53,7 +54,7 @@ func (h CustomerInfoHandler) CustomerInfo(c echo.Context) error {
 	}
 
 	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
-	logger := zap.L().With(
+	logger := logger.For(spannedContext, logger.ModuleCustomer).With(
 		zap.String("tracer", uniqueID),
 	)
 
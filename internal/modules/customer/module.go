@@ -1,6 +1,7 @@
 package customer
 
 import (
+	grpcDelivery "giftcard/internal/modules/customer/delivery/grpc"
 	"giftcard/internal/modules/customer/delivery/http"
 	"giftcard/internal/modules/customer/repository"
 	"giftcard/internal/modules/customer/usecase"
@@ -10,5 +11,7 @@ import (
 var Module = fx.Module("customer",
 	fx.Provide(usecase.NewCustomerUseCase),
 	fx.Provide(delivery.NewCustomerInfoHandler),
+	fx.Provide(grpcDelivery.NewCustomerGrpcHandler),
 	fx.Provide(repository.NewWalletRepository),
+	fx.Invoke(usecase.RegisterMetrics),
 )
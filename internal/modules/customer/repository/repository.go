@@ -24,3 +24,13 @@ func NewWalletRepository(params WalletRepositoryParams) IWalletRepository {
 func (repo *WalletRepository) InsertWallet(wallet *model.Wallet) error {
 	return repo.db.Create(wallet).Error
 }
+
+// LatestWallets returns the newest snapshot of every currency
+func (repo *WalletRepository) LatestWallets() ([]model.Wallet, error) {
+	var wallets []model.Wallet
+	if err := repo.db.Raw(`SELECT DISTINCT ON (currency) * FROM "Wallet" ORDER BY currency, id DESC`).
+		Scan(&wallets).Error; err != nil {
+		return nil, err
+	}
+	return wallets, nil
+}
@@ -4,4 +4,5 @@ import "giftcard/model"
 
 type IWalletRepository interface {
 	InsertWallet(wallet *model.Wallet) error
+	LatestWallets() ([]model.Wallet, error)
 }
@@ -7,7 +7,9 @@ import (
 	"giftcard/internal/adaptor/trace"
 	"giftcard/internal/exceptions"
 	"giftcard/internal/modules/customer/repository"
+	exchangeRateUseCase "giftcard/internal/modules/exchangerate/usecase"
 	"giftcard/model"
+	"giftcard/pkg/logger"
 	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 
@@ -19,18 +21,21 @@ import (
 type CustomerUseCase struct {
 	walletRepo repository.IWalletRepository
 	gf         giftcard.IGiftCard
+	rates      exchangeRateUseCase.IExchangeRateUseCase
 }
 
 type CustomerUseCaseParam struct {
 	fx.In
 	WalletRepo repository.IWalletRepository
 	Gf         *giftcard.GiftCard
+	Rates      exchangeRateUseCase.IExchangeRateUseCase
 }
 
 func NewCustomerUseCase(param CustomerUseCaseParam) *CustomerUseCase {
 	return &CustomerUseCase{
 		walletRepo: param.WalletRepo,
 		gf:         param.Gf,
+		rates:      param.Rates,
 	}
 }
 
@@ -43,7 +48,7 @@ func (us CustomerUseCase) GetCustomerInfoUseCase(ctx context.Context) (giftcard.
 
 	uniqueID, _ := ctx.Value("tracer").(string)
 
-	logger := zap.L().With(
+	logger := logger.For(ctx, logger.ModuleCustomer).With(
 		zap.String("tracer", uniqueID),
 	)
 
@@ -67,6 +72,10 @@ func (us CustomerUseCase) GetCustomerInfoUseCase(ctx context.Context) (giftcard.
 		return giftcard.CustomerInfoResponse{}, err
 	}
 
+	if _, err := us.rates.RecordRates(spannedContext, data.Data.ExchangeRates); err != nil {
+		logger.Error(exceptions.DBError, zap.String("error", err.Error()))
+	}
+
 	jsonData, err := json.Marshal(data.Data)
 	span.SetAttributes(attribute.String("data", string(jsonData)))
 	return data, nil
@@ -0,0 +1,51 @@
+package usecase
+
+import (
+	"context"
+	"giftcard/internal/adaptor/metrics"
+	"giftcard/internal/modules/customer/repository"
+	"giftcard/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// walletCollector reports the latest wallet snapshot of every currency on each scrape, the
+// snapshots are taken whenever the customer info is fetched from the provider
+type walletCollector struct {
+	repo      repository.IWalletRepository
+	balance   *prometheus.Desc
+	updatedAt *prometheus.Desc
+}
+
+func RegisterMetrics(registry *prometheus.Registry, repo repository.IWalletRepository) {
+	registry.MustRegister(&walletCollector{
+		repo: repo,
+		balance: prometheus.NewDesc(
+			prometheus.BuildFQName(metrics.Namespace, "wallet", "balance"),
+			"Provider wallet balance by currency and kind, balance, credit or frozen.",
+			[]string{"currency", "kind"}, nil),
+		updatedAt: prometheus.NewDesc(
+			prometheus.BuildFQName(metrics.Namespace, "wallet", "snapshot_timestamp_seconds"),
+			"Time the reported wallet balance was read from the provider.",
+			[]string{"currency"}, nil),
+	})
+}
+
+func (c *walletCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.balance
+	ch <- c.updatedAt
+}
+
+func (c *walletCollector) Collect(ch chan<- prometheus.Metric) {
+	wallets, err := c.repo.LatestWallets()
+	if err != nil {
+		logger.For(context.Background(), logger.ModuleCustomer).Error("error while read wallets for metrics", zap.String("error", err.Error()))
+		return
+	}
+	for _, wallet := range wallets {
+		ch <- prometheus.MustNewConstMetric(c.balance, prometheus.GaugeValue, wallet.Balance, wallet.Currency, "balance")
+		ch <- prometheus.MustNewConstMetric(c.balance, prometheus.GaugeValue, wallet.CreditBalance, wallet.Currency, "credit")
+		ch <- prometheus.MustNewConstMetric(c.balance, prometheus.GaugeValue, wallet.FrozenBalance, wallet.Currency, "frozen")
+		ch <- prometheus.MustNewConstMetric(c.updatedAt, prometheus.GaugeValue, float64(wallet.CreatedAt.Unix()), wallet.Currency)
+	}
+}
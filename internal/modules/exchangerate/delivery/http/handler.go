@@ -0,0 +1,185 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"giftcard/internal/adaptor/trace"
+	"giftcard/internal/exceptions"
+	"giftcard/internal/modules/exchangerate/usecase"
+	"giftcard/pkg/requester"
+	"giftcard/pkg/responser"
+	"giftcard/pkg/utils"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"net/http"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+type ExchangeRateHandler struct {
+	us usecase.IExchangeRateUseCase
+}
+
+type ExchangeRateHandlerParams struct {
+	fx.In
+	Us usecase.IExchangeRateUseCase
+}
+
+func NewExchangeRateHandler(params ExchangeRateHandlerParams) *ExchangeRateHandler {
+	return &ExchangeRateHandler{
+		us: params.Us,
+	}
+}
+
+// ExchangeRates serves GET /exchange-rates?base=&target= with either at= for the rate in
+// effect at that time, now by default, or from= and to= for the rates modified in that range
+func (h *ExchangeRateHandler) ExchangeRates(c echo.Context) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		utils.GetRequestCtx(c),
+		"ExchangeRates[ExchangeRateDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
+	logger := zap.L().With(
+		zap.String("tracer", uniqueID),
+	)
+
+	base, target := c.QueryParam("base"), c.QueryParam("target")
+	if len(base) != 3 || len(target) != 3 {
+		return badRequest(c, logger, span, errors.New("base and target must be currency codes"))
+	}
+
+	request := requester.Request{
+		ID:          uniqueID,
+		RequestBody: "",
+		UserIP:      c.RealIP(),
+		Uri:         c.Path(),
+		Method:      c.Request().Method,
+		Host:        c.Request().Host,
+		Header:      c.Request().Header,
+		Params:      c.QueryParams(),
+	}
+	logger.Info("Request from client", zap.Any("data", request))
+	span.SetAttributes(attribute.String("Request", utils.Marshal(request)))
+
+	ctx := context.WithValue(spannedContext, "tracer", uniqueID)
+	if c.QueryParam("from") != "" || c.QueryParam("to") != "" {
+		from, err := parseRateTime(c.QueryParam("from"), false)
+		if err != nil {
+			return badRequest(c, logger, span, fmt.Errorf("invalid from: %w", err))
+		}
+		to := time.Now()
+		if value := c.QueryParam("to"); value != "" {
+			if to, err = parseRateTime(value, true); err != nil {
+				return badRequest(c, logger, span, fmt.Errorf("invalid to: %w", err))
+			}
+		}
+
+		rates, err := h.us.ListRates(ctx, base, target, from, to)
+		if err != nil {
+			return internalError(c, logger, span, err)
+		}
+		logger.Info("Response to client", zap.Int("data", len(rates)))
+		return c.JSON(http.StatusOK, responser.Response{
+			Message: "",
+			Success: true,
+			Data:    rates,
+		})
+	}
+
+	at := time.Now()
+	if value := c.QueryParam("at"); value != "" {
+		var err error
+		if at, err = parseRateTime(value, false); err != nil {
+			return badRequest(c, logger, span, fmt.Errorf("invalid at: %w", err))
+		}
+	}
+	rate, err := h.us.RateAt(ctx, base, target, at)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Info("Response to client", zap.Any("error", err.Error()))
+			span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+			return c.JSON(http.StatusNotFound, responser.Response{
+				Message: exceptions.ExchangeRateNotFound,
+				Data:    "",
+				Success: false,
+			})
+		}
+		return internalError(c, logger, span, err)
+	}
+
+	logger.Info("Response to client", zap.Float64("data", rate.Rate))
+	return c.JSON(http.StatusOK, responser.Response{
+		Message: "",
+		Success: true,
+		Data:    rate,
+	})
+}
+
+// OrderExchangeRates returns the rates the provider priced the order with
+func (h *ExchangeRateHandler) OrderExchangeRates(c echo.Context) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		utils.GetRequestCtx(c),
+		"OrderExchangeRates[ExchangeRateDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
+	logger := zap.L().With(
+		zap.String("tracer", uniqueID),
+	)
+
+	ctx := context.WithValue(spannedContext, "tracer", uniqueID)
+	rates, err := h.us.OrderRates(ctx, c.Param("id"))
+	if err != nil {
+		return internalError(c, logger, span, err)
+	}
+
+	logger.Info("Response to client", zap.Int("data", len(rates)))
+	return c.JSON(http.StatusOK, responser.Response{
+		Message: "",
+		Success: true,
+		Data:    rates,
+	})
+}
+
+// parseRateTime accepts RFC 3339 times and plain dates, a plain date upper bound includes the whole day
+func parseRateTime(value string, upper bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(dateLayout, value, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+func badRequest(c echo.Context, logger *zap.Logger, span oteltrace.Span, err error) error {
+	logger.Info("Response to client", zap.Any("error", err.Error()))
+	span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+	return c.JSON(http.StatusBadRequest, responser.Response{
+		Message: exceptions.InvalidInput,
+		Data:    err.Error(),
+		Success: false})
+}
+
+func internalError(c echo.Context, logger *zap.Logger, span oteltrace.Span, err error) error {
+	logger.Info("Response to client", zap.Any("error", err.Error()))
+	span.SetAttributes(attribute.String(exceptions.InternalServerError, err.Error()))
+	return c.JSON(http.StatusInternalServerError, responser.Response{
+		Message: exceptions.InternalServerError,
+		Data:    "",
+		Success: false,
+	})
+}
@@ -0,0 +1,14 @@
+package exchangerate
+
+import (
+	"giftcard/internal/modules/exchangerate/delivery/http"
+	"giftcard/internal/modules/exchangerate/repository"
+	"giftcard/internal/modules/exchangerate/usecase"
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module("exchangerate",
+	fx.Provide(usecase.NewExchangeRateUseCase),
+	fx.Provide(delivery.NewExchangeRateHandler),
+	fx.Provide(repository.NewExchangeRateRepository),
+)
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"giftcard/internal/adaptor/postgres"
+	"giftcard/model"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type ExchangeRateRepository struct {
+	db      *gorm.DB
+	cluster *postgres.Cluster
+}
+
+type ExchangeRateRepositoryParams struct {
+	fx.In
+	Db      *gorm.DB
+	Cluster *postgres.Cluster
+}
+
+func NewExchangeRateRepository(params ExchangeRateRepositoryParams) IExchangeRateRepository {
+	return &ExchangeRateRepository{
+		db:      params.Db,
+		cluster: params.Cluster,
+	}
+}
+
+// SaveRates inserts the rates not stored yet and returns every rate with its stored id,
+// a rate is identified by its pair and provider modified date
+func (repo *ExchangeRateRepository) SaveRates(rates []model.ExchangeRate) ([]model.ExchangeRate, error) {
+	saved := make([]model.ExchangeRate, 0, len(rates))
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		for _, rate := range rates {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rate).Error; err != nil {
+				return err
+			}
+			if rate.ID == 0 {
+				if err := tx.Where("base_currency = ? AND target_currency = ? AND modified_date = ?",
+					rate.BaseCurrency, rate.TargetCurrency, rate.ModifiedDate).
+					First(&rate).Error; err != nil {
+					return err
+				}
+			}
+			saved = append(saved, rate)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+func (repo *ExchangeRateRepository) LinkOrder(orderRef uint, rateRefs []uint) error {
+	if len(rateRefs) == 0 {
+		return nil
+	}
+	links := make([]model.OrderExchangeRate, 0, len(rateRefs))
+	for _, rateRef := range rateRefs {
+		links = append(links, model.OrderExchangeRate{OrderRef: orderRef, ExchangeRateRef: rateRef})
+	}
+	return repo.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&links).Error
+}
+
+// RateAt returns the last rate of the pair modified at or before at
+func (repo *ExchangeRateRepository) RateAt(base string, target string, at time.Time) (*model.ExchangeRate, error) {
+	var rate model.ExchangeRate
+	if err := repo.db.
+		Where("base_currency = ? AND target_currency = ? AND modified_date <= ?", base, target, at).
+		Order("modified_date desc").
+		First(&rate).Error; err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// ListRates returns the rates of the pair modified in [from, to), oldest first
+func (repo *ExchangeRateRepository) ListRates(base string, target string, from time.Time, to time.Time, limit int) ([]model.ExchangeRate, error) {
+	var rates []model.ExchangeRate
+	if err := repo.cluster.Reader().
+		Where("base_currency = ? AND target_currency = ? AND modified_date >= ? AND modified_date < ?", base, target, from, to).
+		Order("modified_date").
+		Limit(limit).
+		Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+func (repo *ExchangeRateRepository) ListOrderRates(orderId string) ([]model.ExchangeRate, error) {
+	var rates []model.ExchangeRate
+	if err := repo.db.
+		Joins("JOIN order_exchange_rates ON order_exchange_rates.exchange_rate_ref = exchange_rates.id").
+		Joins("JOIN orders ON orders.id = order_exchange_rates.order_ref").
+		Where("orders.order_id = ?", orderId).
+		Order("exchange_rates.base_currency, exchange_rates.target_currency").
+		Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"giftcard/model"
+	"time"
+)
+
+type IExchangeRateRepository interface {
+	SaveRates(rates []model.ExchangeRate) ([]model.ExchangeRate, error)
+	LinkOrder(orderRef uint, rateRefs []uint) error
+	RateAt(base string, target string, at time.Time) (*model.ExchangeRate, error)
+	ListRates(base string, target string, from time.Time, to time.Time, limit int) ([]model.ExchangeRate, error)
+	ListOrderRates(orderId string) ([]model.ExchangeRate, error)
+}
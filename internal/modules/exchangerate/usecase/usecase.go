@@ -0,0 +1,164 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"giftcard/internal/adaptor/giftcard"
+	"giftcard/internal/adaptor/trace"
+	"giftcard/internal/modules/exchangerate/repository"
+	"giftcard/model"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+const ratesLimit = 1000
+
+type exchangeRateUseCase struct {
+	repo repository.IExchangeRateRepository
+}
+
+type ExchangeRateUseCaseParams struct {
+	fx.In
+	Repo repository.IExchangeRateRepository
+}
+
+func NewExchangeRateUseCase(params ExchangeRateUseCaseParams) IExchangeRateUseCase {
+	return &exchangeRateUseCase{
+		repo: params.Repo,
+	}
+}
+
+// RecordRates stores the provider rates, rates already stored for the same modified date are kept as is
+func (us exchangeRateUseCase) RecordRates(ctx context.Context, rates []giftcard.ExchangeRate) ([]model.ExchangeRate, error) {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"RecordRatesUseCase",
+		"UseCase")
+	defer span.End()
+
+	seen := map[model.ExchangeRate]bool{}
+	var records []model.ExchangeRate
+	for _, rate := range rates {
+		if rate.BaseCurrency == "" || rate.TargetCurrency == "" || rate.Rate <= 0 || rate.ModifiedDate.Seconds == 0 {
+			continue
+		}
+		record := model.ExchangeRate{
+			BaseCurrency:   strings.ToUpper(rate.BaseCurrency),
+			TargetCurrency: strings.ToUpper(rate.TargetCurrency),
+			ModifiedDate:   rate.ModifiedDate.Time().UTC(),
+			Rate:           rate.Rate,
+		}
+		if seen[record] {
+			continue
+		}
+		seen[record] = true
+		records = append(records, record)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	saved, err := us.repo.SaveRates(records)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	return saved, nil
+}
+
+// LinkOrderRates records the rates an order was priced with and links them to the order
+func (us exchangeRateUseCase) LinkOrderRates(ctx context.Context, orderRef uint, rates []giftcard.ExchangeRate) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		ctx,
+		"LinkOrderRatesUseCase",
+		"UseCase")
+	defer span.End()
+
+	saved, err := us.RecordRates(spannedContext, rates)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return err
+	}
+	rateRefs := make([]uint, 0, len(saved))
+	for _, rate := range saved {
+		rateRefs = append(rateRefs, rate.ID)
+	}
+	if err := us.repo.LinkOrder(orderRef, rateRefs); err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// RateAt returns the rate in effect at the given time. A pair the provider only quotes the
+// other way round is derived from the inverse rate, it has no id.
+func (us exchangeRateUseCase) RateAt(ctx context.Context, base string, target string, at time.Time) (*model.ExchangeRate, error) {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"RateAtUseCase",
+		"UseCase")
+	defer span.End()
+
+	base, target = strings.ToUpper(base), strings.ToUpper(target)
+	if base == target {
+		return &model.ExchangeRate{BaseCurrency: base, TargetCurrency: target, ModifiedDate: at, Rate: 1}, nil
+	}
+
+	rate, err := us.repo.RateAt(base, target, at)
+	if err == nil {
+		return rate, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+
+	inverse, err := us.repo.RateAt(target, base, at)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	return &model.ExchangeRate{
+		BaseCurrency:   base,
+		TargetCurrency: target,
+		ModifiedDate:   inverse.ModifiedDate,
+		Rate:           1 / inverse.Rate,
+		CreatedAt:      inverse.CreatedAt,
+	}, nil
+}
+
+// ListRates returns the rates of the pair modified in [from, to), oldest first
+func (us exchangeRateUseCase) ListRates(ctx context.Context, base string, target string, from time.Time, to time.Time) ([]model.ExchangeRate, error) {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"ListRatesUseCase",
+		"UseCase")
+	defer span.End()
+
+	rates, err := us.repo.ListRates(strings.ToUpper(base), strings.ToUpper(target), from, to, ratesLimit)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	return rates, nil
+}
+
+func (us exchangeRateUseCase) OrderRates(ctx context.Context, orderId string) ([]model.ExchangeRate, error) {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"OrderRatesUseCase",
+		"UseCase")
+	defer span.End()
+
+	rates, err := us.repo.ListOrderRates(orderId)
+	if err != nil {
+		zap.L().Error("error while list order exchange rates from DB", zap.String("error", err.Error()))
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	return rates, nil
+}
@@ -0,0 +1,16 @@
+package usecase
+
+import (
+	"context"
+	"giftcard/internal/adaptor/giftcard"
+	"giftcard/model"
+	"time"
+)
+
+type IExchangeRateUseCase interface {
+	RecordRates(ctx context.Context, rates []giftcard.ExchangeRate) ([]model.ExchangeRate, error)
+	LinkOrderRates(ctx context.Context, orderRef uint, rates []giftcard.ExchangeRate) error
+	RateAt(ctx context.Context, base string, target string, at time.Time) (*model.ExchangeRate, error)
+	ListRates(ctx context.Context, base string, target string, from time.Time, to time.Time) ([]model.ExchangeRate, error)
+	OrderRates(ctx context.Context, orderId string) ([]model.ExchangeRate, error)
+}
@@ -0,0 +1,301 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"giftcard/internal/adaptor/trace"
+	"giftcard/internal/exceptions"
+	"giftcard/internal/modules/export/repository"
+	"giftcard/internal/modules/export/usecase"
+	"giftcard/model"
+	"giftcard/pkg/requester"
+	"giftcard/pkg/responser"
+	"giftcard/pkg/utils"
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// contentTypes of the export formats, used for the download response
+var contentTypes = map[string]string{
+	model.ExportCSV:   "text/csv",
+	model.ExportXLSX:  "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	model.ExportJSONL: "application/jsonl",
+}
+
+type createExportRequestBody struct {
+	From     string `json:"from" validate:"required"`
+	To       string `json:"to" validate:"required"`
+	Format   string `json:"format" validate:"required,oneof=csv xlsx jsonl"`
+	Currency string `json:"currency" validate:"omitempty,len=3"`
+	Team     string `json:"team"`
+}
+
+type exportResponse struct {
+	model.OrderExport
+	DownloadURL string `json:"downloadUrl,omitempty"`
+}
+
+type ExportHandler struct {
+	us usecase.IExportUseCase
+}
+
+type ExportHandlerParams struct {
+	fx.In
+	Us usecase.IExportUseCase
+}
+
+func NewExportHandler(params ExportHandlerParams) *ExportHandler {
+	return &ExportHandler{
+		us: params.Us,
+	}
+}
+
+// CreateExport queues an export of the orders created in [from, to), a plain date "to" includes the whole day
+func (h *ExportHandler) CreateExport(c echo.Context) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		utils.GetRequestCtx(c),
+		"CreateExport[ExportDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
+	logger := zap.L().With(
+		zap.String("tracer", uniqueID),
+	)
+
+	var requestBody createExportRequestBody
+	if err := c.Bind(&requestBody); err != nil {
+		return badRequest(c, logger, span, err)
+	}
+	validate := validator.New()
+	if err := validate.Struct(&requestBody); err != nil {
+		return badRequest(c, logger, span, err)
+	}
+	filter, err := exportFilter(requestBody)
+	if err != nil {
+		return badRequest(c, logger, span, err)
+	}
+
+	request := requester.Request{
+		ID:          uniqueID,
+		RequestBody: requestBody,
+		UserIP:      c.RealIP(),
+		Uri:         c.Path(),
+		Method:      c.Request().Method,
+		Host:        c.Request().Host,
+		Header:      c.Request().Header,
+		Params:      c.QueryParams(),
+	}
+	logger.Info("Request from client", zap.Any("data", request))
+	span.SetAttributes(attribute.String("Request", utils.Marshal(request)))
+
+	ctx := utils.WithPrincipal(context.WithValue(spannedContext, "tracer", uniqueID), utils.GetPrincipal(c))
+	export, err := h.us.CreateExport(ctx, filter, requestBody.Format)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidFormat) {
+			return badRequest(c, logger, span, err)
+		}
+		return internalError(c, logger, span, err)
+	}
+
+	logger.Info("Response to client", zap.Uint("data", export.ID))
+	return c.JSON(http.StatusAccepted, responser.Response{
+		Message: "",
+		Success: true,
+		Data:    newExportResponse(*export),
+	})
+}
+
+func (h *ExportHandler) ListExports(c echo.Context) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		utils.GetRequestCtx(c),
+		"ListExports[ExportDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
+	logger := zap.L().With(
+		zap.String("tracer", uniqueID),
+	)
+
+	ctx := context.WithValue(spannedContext, "tracer", uniqueID)
+	exports, err := h.us.ListExports(ctx)
+	if err != nil {
+		return internalError(c, logger, span, err)
+	}
+
+	response := make([]exportResponse, 0, len(exports))
+	for _, export := range exports {
+		response = append(response, newExportResponse(export))
+	}
+
+	logger.Info("Response to client", zap.Int("data", len(exports)))
+	return c.JSON(http.StatusOK, responser.Response{
+		Message: "",
+		Success: true,
+		Data:    response,
+	})
+}
+
+func (h *ExportHandler) GetExport(c echo.Context) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		utils.GetRequestCtx(c),
+		"GetExport[ExportDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
+	logger := zap.L().With(
+		zap.String("tracer", uniqueID),
+	)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return badRequest(c, logger, span, err)
+	}
+
+	ctx := context.WithValue(spannedContext, "tracer", uniqueID)
+	export, err := h.us.GetExport(ctx, uint(id))
+	if err != nil {
+		return notFoundOrInternalError(c, logger, span, err)
+	}
+
+	logger.Info("Response to client", zap.Uint64("data", id))
+	return c.JSON(http.StatusOK, responser.Response{
+		Message: "",
+		Success: true,
+		Data:    newExportResponse(*export),
+	})
+}
+
+// DownloadExport streams the export file, 409 while the job runs and 410 once the file expired
+func (h *ExportHandler) DownloadExport(c echo.Context) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		utils.GetRequestCtx(c),
+		"DownloadExport[ExportDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
+	logger := zap.L().With(
+		zap.String("tracer", uniqueID),
+	)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return badRequest(c, logger, span, err)
+	}
+
+	ctx := context.WithValue(spannedContext, "tracer", uniqueID)
+	export, file, err := h.us.OpenExport(ctx, uint(id))
+	if err != nil {
+		status := 0
+		switch {
+		case errors.Is(err, usecase.ErrExportNotReady):
+			status = http.StatusConflict
+		case errors.Is(err, usecase.ErrExportExpired):
+			status = http.StatusGone
+		default:
+			return notFoundOrInternalError(c, logger, span, err)
+		}
+		logger.Info("Response to client", zap.Any("error", err.Error()))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+		return c.JSON(status, responser.Response{
+			Message: err.Error(),
+			Data:    "",
+			Success: false,
+		})
+	}
+	defer file.Close()
+
+	logger.Info("Response to client", zap.Uint64("data", id))
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("orders-%d.%s", export.ID, export.Format)))
+	return c.Stream(http.StatusOK, contentTypes[export.Format], file)
+}
+
+func newExportResponse(export model.OrderExport) exportResponse {
+	response := exportResponse{OrderExport: export}
+	if export.Status == model.ExportCompleted && export.HasFile {
+		response.DownloadURL = fmt.Sprintf("/v1/exports/%d/download", export.ID)
+	}
+	return response
+}
+
+func exportFilter(requestBody createExportRequestBody) (repository.OrderFilter, error) {
+	from, err := parseExportTime(requestBody.From, false)
+	if err != nil {
+		return repository.OrderFilter{}, fmt.Errorf("invalid from: %w", err)
+	}
+	to, err := parseExportTime(requestBody.To, true)
+	if err != nil {
+		return repository.OrderFilter{}, fmt.Errorf("invalid to: %w", err)
+	}
+	if !from.Before(to) {
+		return repository.OrderFilter{}, errors.New("from must be before to")
+	}
+	return repository.OrderFilter{
+		From:     from,
+		To:       to,
+		Currency: requestBody.Currency,
+		Team:     requestBody.Team,
+	}, nil
+}
+
+// parseExportTime accepts RFC 3339 times and plain dates, a plain date upper bound includes the whole day
+func parseExportTime(value string, upper bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(dateLayout, value, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+func badRequest(c echo.Context, logger *zap.Logger, span oteltrace.Span, err error) error {
+	logger.Info("Response to client", zap.Any("error", err.Error()))
+	span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+	return c.JSON(http.StatusBadRequest, responser.Response{
+		Message: exceptions.InvalidInput,
+		Data:    err.Error(),
+		Success: false})
+}
+
+func notFoundOrInternalError(c echo.Context, logger *zap.Logger, span oteltrace.Span, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Info("Response to client", zap.Any("error", err.Error()))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, gorm.ErrRecordNotFound.Error()))
+		return c.JSON(http.StatusNotFound, responser.Response{
+			Message: exceptions.ExportNotFound,
+			Data:    "",
+			Success: false,
+		})
+	}
+	return internalError(c, logger, span, err)
+}
+
+func internalError(c echo.Context, logger *zap.Logger, span oteltrace.Span, err error) error {
+	logger.Info("Response to client", zap.Any("error", err.Error()))
+	span.SetAttributes(attribute.String(exceptions.InternalServerError, err.Error()))
+	return c.JSON(http.StatusInternalServerError, responser.Response{
+		Message: exceptions.InternalServerError,
+		Data:    "",
+		Success: false,
+	})
+}
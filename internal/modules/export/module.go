@@ -0,0 +1,16 @@
+package export
+
+import (
+	"giftcard/internal/modules/export/delivery/http"
+	"giftcard/internal/modules/export/repository"
+	"giftcard/internal/modules/export/usecase"
+	"giftcard/internal/modules/export/worker"
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module("export",
+	fx.Provide(usecase.NewExportUseCase),
+	fx.Provide(delivery.NewExportHandler),
+	fx.Provide(repository.NewExportRepository),
+	fx.Invoke(worker.RunExportWorker),
+)
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"giftcard/internal/adaptor/postgres"
+	"giftcard/model"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// OrderFilter selects the exported orders, created in [From, To)
+type OrderFilter struct {
+	From     time.Time
+	To       time.Time
+	Currency string
+	Team     string
+}
+
+type ExportRepository struct {
+	db      *gorm.DB
+	cluster *postgres.Cluster
+}
+
+type ExportRepositoryParams struct {
+	fx.In
+	Db      *gorm.DB
+	Cluster *postgres.Cluster
+}
+
+func NewExportRepository(params ExportRepositoryParams) IExportRepository {
+	return &ExportRepository{
+		db:      params.Db,
+		cluster: params.Cluster,
+	}
+}
+
+func (repo *ExportRepository) InsertExport(export *model.OrderExport) error {
+	return repo.db.Create(export).Error
+}
+
+func (repo *ExportRepository) GetExport(id uint) (*model.OrderExport, error) {
+	var export model.OrderExport
+	if err := repo.db.First(&export, id).Error; err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (repo *ExportRepository) ListExports(limit int) ([]model.OrderExport, error) {
+	var exports []model.OrderExport
+	if err := repo.db.Order("id desc").Limit(limit).Find(&exports).Error; err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+// ClaimDueExport locks the oldest pending export, or a processing one whose worker
+// lease ran out, and leases it to the caller
+func (repo *ExportRepository) ClaimDueExport(lease time.Duration) (*model.OrderExport, error) {
+	var exports []model.OrderExport
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? OR (status = ? AND locked_until < ?)", model.ExportPending, model.ExportProcessing, now).
+			Order("id").
+			Limit(1).
+			Find(&exports).Error; err != nil {
+			return err
+		}
+		if len(exports) == 0 {
+			return nil
+		}
+
+		lockedUntil := now.Add(lease)
+		exports[0].Status = model.ExportProcessing
+		exports[0].LockedUntil = &lockedUntil
+		return tx.Model(&exports[0]).Updates(map[string]any{
+			"status":       model.ExportProcessing,
+			"locked_until": lockedUntil,
+		}).Error
+	})
+	if err != nil || len(exports) == 0 {
+		return nil, err
+	}
+	return &exports[0], nil
+}
+
+func (repo *ExportRepository) UpdateExport(export *model.OrderExport) error {
+	return repo.db.Save(export).Error
+}
+
+// SaveExportFile stores the file of the export together with the finished export
+func (repo *ExportRepository) SaveExportFile(export *model.OrderExport, content []byte) error {
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		file := model.OrderExportFile{ExportID: export.ID, Content: content}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "export_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"content", "created_at"}),
+		}).Create(&file).Error; err != nil {
+			return err
+		}
+		export.HasFile = true
+		return tx.Save(export).Error
+	})
+}
+
+func (repo *ExportRepository) GetExportFile(exportID uint) (*model.OrderExportFile, error) {
+	var file model.OrderExportFile
+	if err := repo.db.Where("export_id = ?", exportID).First(&file).Error; err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// DeleteExportFile removes the file of the export and records it is gone
+func (repo *ExportRepository) DeleteExportFile(export *model.OrderExport) error {
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("export_id = ?", export.ID).Delete(&model.OrderExportFile{}).Error; err != nil {
+			return err
+		}
+		export.HasFile = false
+		return tx.Save(export).Error
+	})
+}
+
+// ListExpiredExports returns the finished exports whose file is past retention
+func (repo *ExportRepository) ListExpiredExports(now time.Time) ([]model.OrderExport, error) {
+	var exports []model.OrderExport
+	if err := repo.db.
+		Where("expires_at < ? AND has_file", now).
+		Find(&exports).Error; err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+// ListOrders pages through the filtered orders by ascending id
+func (repo *ExportRepository) ListOrders(filter OrderFilter, afterID uint, limit int) ([]model.Order, error) {
+	query := repo.cluster.Reader().
+		Where("created_at >= ? AND created_at < ? AND id > ?", filter.From, filter.To, afterID)
+	if filter.Currency != "" {
+		query = query.Where("currency = ?", filter.Currency)
+	}
+	if filter.Team != "" {
+		query = query.Where("team = ?", filter.Team)
+	}
+
+	var orders []model.Order
+	if err := query.Order("id").Limit(limit).Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"giftcard/model"
+	"time"
+)
+
+type IExportRepository interface {
+	InsertExport(export *model.OrderExport) error
+	GetExport(id uint) (*model.OrderExport, error)
+	ListExports(limit int) ([]model.OrderExport, error)
+	ClaimDueExport(lease time.Duration) (*model.OrderExport, error)
+	UpdateExport(export *model.OrderExport) error
+	SaveExportFile(export *model.OrderExport, content []byte) error
+	GetExportFile(exportID uint) (*model.OrderExportFile, error)
+	DeleteExportFile(export *model.OrderExport) error
+	ListExpiredExports(now time.Time) ([]model.OrderExport, error)
+	ListOrders(filter OrderFilter, afterID uint, limit int) ([]model.Order, error)
+}
@@ -0,0 +1,12 @@
+package usecase
+
+import (
+	"errors"
+	"giftcard/internal/exceptions"
+)
+
+var (
+	ErrInvalidFormat  = errors.New(exceptions.InvalidExportFormat)
+	ErrExportNotReady = errors.New(exceptions.ExportNotReady)
+	ErrExportExpired  = errors.New(exceptions.ExportExpired)
+)
@@ -0,0 +1,271 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"giftcard/config"
+	"giftcard/internal/adaptor/trace"
+	"giftcard/internal/modules/export/repository"
+	"giftcard/model"
+	"giftcard/pkg/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"io"
+	"time"
+)
+
+const (
+	defaultLease     = 10 * time.Minute
+	defaultRetention = 7
+	exportsLimit     = 100
+	ordersPageSize   = 500
+)
+
+type exportUseCase struct {
+	repo repository.IExportRepository
+}
+
+type ExportUseCaseParams struct {
+	fx.In
+	Repo repository.IExportRepository
+}
+
+func NewExportUseCase(params ExportUseCaseParams) IExportUseCase {
+	return &exportUseCase{
+		repo: params.Repo,
+	}
+}
+
+// CreateExport queues an export job for the export worker
+func (us exportUseCase) CreateExport(ctx context.Context, filter repository.OrderFilter, format string) (*model.OrderExport, error) {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"CreateExportUseCase",
+		"UseCase")
+	defer span.End()
+
+	if !validFormat(format) {
+		span.SetAttributes(attribute.String("error", ErrInvalidFormat.Error()))
+		return nil, ErrInvalidFormat
+	}
+
+	export := &model.OrderExport{
+		Format:    format,
+		From:      filter.From,
+		To:        filter.To,
+		Currency:  filter.Currency,
+		Team:      filter.Team,
+		CreatedBy: utils.PrincipalFromCtx(ctx),
+		Status:    model.ExportPending,
+	}
+	if err := us.repo.InsertExport(export); err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	zap.L().Info("order export queued", zap.Uint("export", export.ID), zap.String("format", format))
+	return export, nil
+}
+
+func (us exportUseCase) GetExport(ctx context.Context, id uint) (*model.OrderExport, error) {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"GetExportUseCase",
+		"UseCase")
+	defer span.End()
+
+	export, err := us.repo.GetExport(id)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	return export, nil
+}
+
+func (us exportUseCase) ListExports(ctx context.Context) ([]model.OrderExport, error) {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"ListExportsUseCase",
+		"UseCase")
+	defer span.End()
+
+	exports, err := us.repo.ListExports(exportsLimit)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	return exports, nil
+}
+
+// OpenExport opens the file of a completed export, the caller closes it
+func (us exportUseCase) OpenExport(ctx context.Context, id uint) (*model.OrderExport, io.ReadCloser, error) {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"OpenExportUseCase",
+		"UseCase")
+	defer span.End()
+
+	export, err := us.repo.GetExport(id)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, nil, err
+	}
+	if export.Status != model.ExportCompleted {
+		span.SetAttributes(attribute.String("error", ErrExportNotReady.Error()))
+		return nil, nil, ErrExportNotReady
+	}
+	if !export.HasFile || (export.ExpiresAt != nil && export.ExpiresAt.Before(time.Now())) {
+		span.SetAttributes(attribute.String("error", ErrExportExpired.Error()))
+		return nil, nil, ErrExportExpired
+	}
+
+	file, err := us.repo.GetExportFile(export.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrExportExpired
+		}
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, nil, err
+	}
+	return export, io.NopCloser(bytes.NewReader(file.Content)), nil
+}
+
+// WriteExport streams the filtered orders to w page by page, it returns the number of lines written
+func (us exportUseCase) WriteExport(ctx context.Context, filter repository.OrderFilter, format string, w io.Writer) (int, error) {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"WriteExportUseCase",
+		"UseCase")
+	defer span.End()
+
+	writer, err := newOrderWriter(format, w)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return 0, err
+	}
+
+	lines := 0
+	var afterID uint
+	for {
+		if err := ctx.Err(); err != nil {
+			return lines, err
+		}
+		orders, err := us.repo.ListOrders(filter, afterID, ordersPageSize)
+		if err != nil {
+			span.SetAttributes(attribute.String("error", err.Error()))
+			return lines, err
+		}
+		for _, order := range orders {
+			written, err := writer.WriteOrder(order)
+			if err != nil {
+				span.SetAttributes(attribute.String("error", err.Error()))
+				return lines, err
+			}
+			lines += written
+		}
+		if len(orders) < ordersPageSize {
+			break
+		}
+		afterID = orders[len(orders)-1].ID
+	}
+
+	if err := writer.Close(); err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return lines, err
+	}
+	span.SetAttributes(attribute.Int("lines", lines))
+	return lines, nil
+}
+
+// ProcessDue claims one due export and writes its file, it returns the number of exports processed
+func (us exportUseCase) ProcessDue(ctx context.Context) (int, error) {
+	lease := time.Duration(config.C().Export.Lease) * time.Second
+	if lease <= 0 {
+		lease = defaultLease
+	}
+	export, err := us.repo.ClaimDueExport(lease)
+	if err != nil || export == nil {
+		return 0, err
+	}
+
+	span, spannedContext := trace.T.SpanFromContext(
+		ctx,
+		"ProcessExportUseCase",
+		"UseCase")
+	defer span.End()
+	span.SetAttributes(attribute.Int("export", int(export.ID)))
+
+	var content bytes.Buffer
+	filter := repository.OrderFilter{
+		From:     export.From,
+		To:       export.To,
+		Currency: export.Currency,
+		Team:     export.Team,
+	}
+	rows, err := us.WriteExport(spannedContext, filter, export.Format, &content)
+	now := time.Now()
+	export.LockedUntil = nil
+	export.FinishedAt = &now
+	if err != nil {
+		zap.L().Error("error while write order export",
+			zap.Uint("export", export.ID),
+			zap.String("error", err.Error()),
+		)
+		span.SetAttributes(attribute.String("error", err.Error()))
+		export.Status = model.ExportFailed
+		export.Error = err.Error()
+		err = us.repo.UpdateExport(export)
+	} else {
+		retention := config.C().Export.Retention
+		if retention <= 0 {
+			retention = defaultRetention
+		}
+		expiresAt := now.AddDate(0, 0, retention)
+		export.Status = model.ExportCompleted
+		export.Error = ""
+		export.Rows = rows
+		export.ExpiresAt = &expiresAt
+		err = us.repo.SaveExportFile(export, content.Bytes())
+	}
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return 1, err
+	}
+
+	zap.L().Info("order export finished",
+		zap.Uint("export", export.ID),
+		zap.String("status", export.Status),
+		zap.Int("rows", export.Rows),
+	)
+	return 1, nil
+}
+
+// PurgeExpired removes the files of exports past retention, it returns the number of files removed
+func (us exportUseCase) PurgeExpired(ctx context.Context) (int, error) {
+	exports, err := us.repo.ListExpiredExports(time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for i := range exports {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := us.repo.DeleteExportFile(&exports[i]); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+func validFormat(format string) bool {
+	switch format {
+	case model.ExportCSV, model.ExportXLSX, model.ExportJSONL:
+		return true
+	}
+	return false
+}
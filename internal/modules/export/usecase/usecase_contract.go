@@ -0,0 +1,18 @@
+package usecase
+
+import (
+	"context"
+	"giftcard/internal/modules/export/repository"
+	"giftcard/model"
+	"io"
+)
+
+type IExportUseCase interface {
+	CreateExport(ctx context.Context, filter repository.OrderFilter, format string) (*model.OrderExport, error)
+	GetExport(ctx context.Context, id uint) (*model.OrderExport, error)
+	ListExports(ctx context.Context) ([]model.OrderExport, error)
+	OpenExport(ctx context.Context, id uint) (*model.OrderExport, io.ReadCloser, error)
+	WriteExport(ctx context.Context, filter repository.OrderFilter, format string, w io.Writer) (int, error)
+	ProcessDue(ctx context.Context) (int, error)
+	PurgeExpired(ctx context.Context) (int, error)
+}
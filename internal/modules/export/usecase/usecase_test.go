@@ -0,0 +1,122 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"giftcard/internal/modules/export/repository"
+	"giftcard/model"
+	"gorm.io/gorm"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeExportRepository keeps one export and its file in memory, like the shared table every
+// replica reads
+type fakeExportRepository struct {
+	repository.IExportRepository
+	export *model.OrderExport
+	files  map[uint][]byte
+	orders []model.Order
+}
+
+func (repo *fakeExportRepository) ClaimDueExport(lease time.Duration) (*model.OrderExport, error) {
+	if repo.export.Status != model.ExportPending {
+		return nil, nil
+	}
+	repo.export.Status = model.ExportProcessing
+	copied := *repo.export
+	return &copied, nil
+}
+
+func (repo *fakeExportRepository) GetExport(id uint) (*model.OrderExport, error) {
+	if repo.export.ID != id {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *repo.export
+	return &copied, nil
+}
+
+func (repo *fakeExportRepository) UpdateExport(export *model.OrderExport) error {
+	copied := *export
+	repo.export = &copied
+	return nil
+}
+
+func (repo *fakeExportRepository) SaveExportFile(export *model.OrderExport, content []byte) error {
+	repo.files[export.ID] = append([]byte(nil), content...)
+	export.HasFile = true
+	return repo.UpdateExport(export)
+}
+
+func (repo *fakeExportRepository) GetExportFile(exportID uint) (*model.OrderExportFile, error) {
+	content, ok := repo.files[exportID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &model.OrderExportFile{ExportID: exportID, Content: content}, nil
+}
+
+func (repo *fakeExportRepository) DeleteExportFile(export *model.OrderExport) error {
+	delete(repo.files, export.ID)
+	export.HasFile = false
+	return repo.UpdateExport(export)
+}
+
+func (repo *fakeExportRepository) ListExpiredExports(now time.Time) ([]model.OrderExport, error) {
+	if !repo.export.HasFile || repo.export.ExpiresAt == nil || !repo.export.ExpiresAt.Before(now) {
+		return nil, nil
+	}
+	return []model.OrderExport{*repo.export}, nil
+}
+
+func (repo *fakeExportRepository) ListOrders(filter repository.OrderFilter, afterID uint, limit int) ([]model.Order, error) {
+	if afterID > 0 {
+		return nil, nil
+	}
+	return repo.orders, nil
+}
+
+func TestExportFileLifecycle(t *testing.T) {
+	repo := &fakeExportRepository{
+		export: &model.OrderExport{Model: gorm.Model{ID: 1}, Format: model.ExportJSONL, Status: model.ExportPending},
+		files:  map[uint][]byte{},
+		orders: []model.Order{{ID: 1, OrderID: "order-1"}},
+	}
+	us := exportUseCase{repo: repo}
+
+	if processed, err := us.ProcessDue(context.Background()); err != nil || processed != 1 {
+		t.Fatalf("expected one export processed, got %d, %v", processed, err)
+	}
+	if repo.export.Status != model.ExportCompleted || !repo.export.HasFile {
+		t.Fatalf("expected a completed export with its file, got %+v", repo.export)
+	}
+
+	// a replica other than the one that wrote the file serves it from the shared table
+	other := exportUseCase{repo: repo}
+	_, file, err := other.OpenExport(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(file)
+	file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "order-1") {
+		t.Fatalf("unexpected export content %q", content)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	repo.export.ExpiresAt = &past
+	if purged, err := other.PurgeExpired(context.Background()); err != nil || purged != 1 {
+		t.Fatalf("expected one file purged, got %d, %v", purged, err)
+	}
+	if len(repo.files) != 0 || repo.export.HasFile {
+		t.Fatal("the expired file was kept")
+	}
+	if _, _, err := us.OpenExport(context.Background(), 1); !errors.Is(err, ErrExportExpired) {
+		t.Fatalf("expected ErrExportExpired, got %v", err)
+	}
+}
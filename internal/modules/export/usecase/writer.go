@@ -0,0 +1,213 @@
+package usecase
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"giftcard/model"
+	"giftcard/pkg/xlsx"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// line types of the flat formats, the order line carries the totals and every invoice item
+// follows on an item line of its own, so summing a column never counts an order twice
+const (
+	lineOrder = "order"
+	lineItem  = "item"
+)
+
+// columns of the flat formats
+var columns = []string{
+	"line_type", "order_id", "id", "created_at", "status", "team", "created_by",
+	"sku", "product_type", "quote", "quantity",
+	"wallet", "invoice_total", "discount", "fee", "payment_method",
+	"record_sku", "item_type", "item_description", "item_amount", "item_currency",
+	"base_currency", "target_currency", "rate",
+}
+
+// orderWriter writes orders in one export format, it returns the number of lines written
+type orderWriter interface {
+	WriteOrder(order model.Order) (int, error)
+	Close() error
+}
+
+func newOrderWriter(format string, w io.Writer) (orderWriter, error) {
+	switch format {
+	case model.ExportCSV:
+		writer := &csvWriter{w: csv.NewWriter(w)}
+		return writer, writer.w.Write(columns)
+	case model.ExportXLSX:
+		xw, err := xlsx.NewWriter(w, "orders")
+		if err != nil {
+			return nil, err
+		}
+		header := make([]any, len(columns))
+		for i, column := range columns {
+			header[i] = column
+		}
+		return &xlsxWriter{w: xw}, xw.WriteRow(header...)
+	case model.ExportJSONL:
+		return &jsonlWriter{enc: json.NewEncoder(w)}, nil
+	}
+	return nil, ErrInvalidFormat
+}
+
+// orderLines flattens the order into its order line followed by one item line per invoice
+// item, item lines repeat the order identity but none of its totals
+func orderLines(order model.Order) [][]any {
+	var discount, fee float64
+	var paymentMethod string
+	if order.Invoice != nil {
+		discount = order.Invoice.Adjustment(model.InvoiceItemDiscount)
+		fee = order.Invoice.Adjustment(model.InvoiceItemFee)
+		paymentMethod = order.Invoice.PaymentMethod
+	}
+	identity := func(lineType string) []any {
+		return []any{
+			lineType, order.OrderID, order.ID, order.CreatedAt, order.Status, order.Team, order.CreatedBy,
+			order.SKU, order.ProductType, order.Quote, order.Quantity, order.Currency,
+		}
+	}
+
+	lines := [][]any{append(identity(lineOrder),
+		order.Total, discount, fee, paymentMethod,
+		nil, nil, nil, nil, nil, nil, nil, nil,
+	)}
+	if order.Invoice != nil {
+		for _, record := range order.Invoice.Records {
+			for _, item := range record.Items {
+				lines = append(lines, append(identity(lineItem),
+					nil, nil, nil, nil,
+					record.SKU, item.Type, item.Description, item.Amount, item.Currency,
+					item.BaseCurrency, item.TargetCurrency, item.Rate,
+				))
+			}
+		}
+	}
+	return lines
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvWriter) WriteOrder(order model.Order) (int, error) {
+	lines := orderLines(order)
+	for _, line := range lines {
+		record := make([]string, len(line))
+		for i, value := range line {
+			record[i] = formatValue(value)
+		}
+		if err := c.w.Write(record); err != nil {
+			return 0, err
+		}
+	}
+	return len(lines), nil
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type xlsxWriter struct {
+	w *xlsx.Writer
+}
+
+func (x *xlsxWriter) WriteOrder(order model.Order) (int, error) {
+	lines := orderLines(order)
+	for _, line := range lines {
+		for i, value := range line {
+			if text, ok := value.(string); ok {
+				line[i] = escapeFormula(text)
+			}
+		}
+		if err := x.w.WriteRow(line...); err != nil {
+			return 0, err
+		}
+	}
+	return len(lines), nil
+}
+
+func (x *xlsxWriter) Close() error {
+	return x.w.Close()
+}
+
+// jsonlOrder keeps the invoice nested, one json document per order
+type jsonlOrder struct {
+	OrderID       string                `json:"orderId"`
+	ID            uint                  `json:"id"`
+	CreatedAt     time.Time             `json:"createdAt"`
+	Status        string                `json:"status"`
+	Team          string                `json:"team,omitempty"`
+	CreatedBy     string                `json:"createdBy,omitempty"`
+	SKU           string                `json:"sku"`
+	ProductType   string                `json:"productType"`
+	Quote         uint                  `json:"quote"`
+	Quantity      uint                  `json:"quantity"`
+	Wallet        string                `json:"wallet"`
+	InvoiceTotal  float64               `json:"invoiceTotal"`
+	Discount      float64               `json:"discount"`
+	Fee           float64               `json:"fee"`
+	PaymentMethod string                `json:"paymentMethod,omitempty"`
+	Records       []model.InvoiceRecord `json:"records"`
+}
+
+type jsonlWriter struct {
+	enc *json.Encoder
+}
+
+func (j *jsonlWriter) WriteOrder(order model.Order) (int, error) {
+	line := jsonlOrder{
+		OrderID:      order.OrderID,
+		ID:           order.ID,
+		CreatedAt:    order.CreatedAt,
+		Status:       order.Status,
+		Team:         order.Team,
+		CreatedBy:    order.CreatedBy,
+		SKU:          order.SKU,
+		ProductType:  order.ProductType,
+		Quote:        order.Quote,
+		Quantity:     order.Quantity,
+		Wallet:       order.Currency,
+		InvoiceTotal: order.Total,
+	}
+	if order.Invoice != nil {
+		line.Discount = order.Invoice.Adjustment(model.InvoiceItemDiscount)
+		line.Fee = order.Invoice.Adjustment(model.InvoiceItemFee)
+		line.PaymentMethod = order.Invoice.PaymentMethod
+		line.Records = order.Invoice.Records
+	}
+	return 1, j.enc.Encode(line)
+}
+
+func (j *jsonlWriter) Close() error {
+	return nil
+}
+
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return escapeFormula(v)
+	case uint:
+		return strconv.FormatUint(uint64(v), 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	}
+	return ""
+}
+
+// escapeFormula keeps a spreadsheet from running text as a formula, a cell starting with
+// one of = + - @ or a tab or carriage return is prefixed with a quote
+func escapeFormula(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
@@ -0,0 +1,76 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/csv"
+	"giftcard/model"
+	"testing"
+)
+
+func TestCSVWriter(t *testing.T) {
+	order := model.Order{
+		OrderID:   "order-1",
+		Team:      "=HYPERLINK(\"http://evil\")",
+		CreatedBy: "-alice",
+		Currency:  "USD",
+		Total:     90,
+		Invoice: &model.OrderInvoice{
+			PaymentMethod: "wallet",
+			Records: []model.InvoiceRecord{
+				{SKU: "sku-1", Items: []model.InvoiceItem{
+					{Type: "product", Description: "@card", Amount: 100, Currency: "USD"},
+					{Type: model.InvoiceItemDiscount, Amount: -10, Currency: "USD"},
+				}},
+			},
+		},
+	}
+
+	var out bytes.Buffer
+	writer, err := newOrderWriter(model.ExportCSV, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	written, err := writer.WriteOrder(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if written != 3 {
+		t.Fatalf("expected an order line and two item lines, got %d", written)
+	}
+
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := map[string]int{}
+	for i, column := range records[0] {
+		index[column] = i
+	}
+	cell := func(line int, column string) string {
+		return records[line][index[column]]
+	}
+
+	if cell(1, "line_type") != lineOrder || cell(1, "invoice_total") != "90" || cell(1, "discount") != "-10" {
+		t.Errorf("unexpected order line %v", records[1])
+	}
+	for _, line := range []int{2, 3} {
+		if cell(line, "line_type") != lineItem || cell(line, "order_id") != "order-1" {
+			t.Errorf("unexpected item line %v", records[line])
+		}
+		for _, column := range []string{"invoice_total", "discount", "fee", "payment_method"} {
+			if cell(line, column) != "" {
+				t.Errorf("item line %d repeats the order %s", line, column)
+			}
+		}
+	}
+	if cell(3, "item_amount") != "-10" {
+		t.Errorf("a negative amount must stay a number, got %q", cell(3, "item_amount"))
+	}
+
+	if cell(1, "team") != "'=HYPERLINK(\"http://evil\")" || cell(1, "created_by") != "'-alice" || cell(2, "item_description") != "'@card" {
+		t.Errorf("formula cells are not escaped, %v %v", records[1], records[2])
+	}
+}
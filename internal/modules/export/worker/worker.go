@@ -0,0 +1,82 @@
+package worker
+
+import (
+	"context"
+	"giftcard/config"
+	"giftcard/internal/adaptor/metrics"
+	"giftcard/internal/modules/export/usecase"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"log"
+	"time"
+)
+
+const (
+	workerName          = "export"
+	defaultPollInterval = 5 * time.Second
+	purgeInterval       = time.Hour
+)
+
+// RunExportWorker writes queued exports and purges expired files for as long as the application runs
+func RunExportWorker(lc fx.Lifecycle, us usecase.IExportUseCase, workers *metrics.Workers) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				run(ctx, us, workers)
+			}()
+			log.Println("export worker started")
+			return nil
+		},
+		OnStop: func(c context.Context) error {
+			cancel()
+			select {
+			case <-done:
+			case <-c.Done():
+			}
+			log.Println("export worker stopped")
+			return nil
+		},
+	})
+}
+
+func run(ctx context.Context, us usecase.IExportUseCase, workers *metrics.Workers) {
+	interval := time.Duration(config.C().Export.PollInterval) * time.Second
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	workers.CaughtUp(workerName)
+
+	var lastPurge time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for ctx.Err() == nil {
+			processed, err := us.ProcessDue(ctx)
+			if err != nil {
+				zap.L().Error("error while process order exports", zap.String("error", err.Error()))
+				break
+			}
+			if processed == 0 {
+				workers.CaughtUp(workerName)
+				break
+			}
+		}
+
+		if time.Since(lastPurge) >= purgeInterval {
+			lastPurge = time.Now()
+			if _, err := us.PurgeExpired(ctx); err != nil {
+				zap.L().Error("error while purge expired exports", zap.String("error", err.Error()))
+			}
+		}
+	}
+}
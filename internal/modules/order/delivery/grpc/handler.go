@@ -0,0 +1,313 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"giftcard/api/pb"
+	"giftcard/config"
+	gftErr "giftcard/internal/adaptor/giftcard"
+	"giftcard/internal/adaptor/trace"
+	"giftcard/internal/exceptions"
+	"giftcard/internal/modules/order/usecase"
+	"giftcard/pkg/logger"
+	"giftcard/pkg/utils"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+	"time"
+)
+
+const defaultStatusPollInterval = 5 * time.Second
+
+type OrderGrpcHandler struct {
+	pb.UnimplementedOrderServiceServer
+	us usecase.IOrderUseCase
+}
+
+type OrderGrpcHandlerParams struct {
+	fx.In
+	Us usecase.IOrderUseCase
+}
+
+func NewOrderGrpcHandler(params OrderGrpcHandlerParams) *OrderGrpcHandler {
+	return &OrderGrpcHandler{
+		us: params.Us,
+	}
+}
+
+func (h *OrderGrpcHandler) CreateOrder(ctx context.Context, req *pb.CreateOrderRequest) (*pb.DataResponse, error) {
+	span, spannedContext := trace.T.SpanFromContext(
+		ctx,
+		"CreateOrder[OrderGrpcDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID, _ := ctx.Value("tracer").(string)
+	logger := logger.For(ctx, logger.ModuleOrder).With(
+		zap.String("tracer", uniqueID),
+	)
+
+	if len(req.GetProductList()) == 0 {
+		logger.Info("Response to client", zap.Any("error", exceptions.EmptyProductList))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, exceptions.EmptyProductList))
+		return nil, status.Error(codes.InvalidArgument, exceptions.EmptyProductList)
+	}
+
+	var productList []map[string]interface{}
+	for _, product := range req.GetProductList() {
+		if product.GetSku() == "" || product.GetProductType() == "" || product.GetQuote() == 0 || product.GetQuantity() == 0 {
+			logger.Info("Response to client", zap.Any("error", exceptions.InvalidCreateOrderInput))
+			span.SetAttributes(attribute.String(exceptions.StatusBadRequest, exceptions.InvalidCreateOrderInput))
+			return nil, status.Error(codes.InvalidArgument, exceptions.InvalidCreateOrderInput)
+		}
+		productMap := map[string]interface{}{
+			"sku":         product.GetSku(),
+			"productType": product.GetProductType(),
+			"quote":       uint(product.GetQuote()),
+			"quantity":    uint(product.GetQuantity()),
+		}
+		productList = append(productList, productMap)
+	}
+
+	data, err := h.us.CreateOrder(spannedContext, productList)
+	if err != nil {
+		return nil, toStatusError(logger, span, err)
+	}
+
+	value, err := utils.ToProtoValue(data.Data)
+	if err != nil {
+		return nil, toStatusError(logger, span, err)
+	}
+	return &pb.DataResponse{Data: value}, nil
+}
+
+func (h *OrderGrpcHandler) ConfirmOrder(ctx context.Context, req *pb.ConfirmOrderRequest) (*pb.DataResponse, error) {
+	span, spannedContext := trace.T.SpanFromContext(
+		ctx,
+		"ConfirmOrder[OrderGrpcDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID, _ := ctx.Value("tracer").(string)
+	logger := logger.For(ctx, logger.ModuleOrder).With(
+		zap.String("tracer", uniqueID),
+	)
+
+	if req.GetOrderId() == "" {
+		logger.Info("Response to client", zap.Any("error", exceptions.RequiredOrderID))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, exceptions.RequiredOrderID))
+		return nil, status.Error(codes.InvalidArgument, exceptions.RequiredOrderID)
+	}
+
+	data, err := h.us.ConfirmOrder(spannedContext, req.GetOrderId())
+	if err != nil {
+		return nil, toStatusError(logger, span, err)
+	}
+
+	value, err := utils.ToProtoValue(data["data"])
+	if err != nil {
+		return nil, toStatusError(logger, span, err)
+	}
+	return &pb.DataResponse{Data: value}, nil
+}
+
+func (h *OrderGrpcHandler) GetOrderStatus(ctx context.Context, req *pb.GetOrderStatusRequest) (*pb.DataResponse, error) {
+	span, spannedContext := trace.T.SpanFromContext(
+		ctx,
+		"RetrieveOrder[OrderGrpcDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID, _ := ctx.Value("tracer").(string)
+	logger := logger.For(ctx, logger.ModuleOrder).With(
+		zap.String("tracer", uniqueID),
+	)
+
+	if req.GetOrderId() == "" {
+		logger.Info("Response to client", zap.Any("error", exceptions.RequiredOrderID))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, exceptions.RequiredOrderID))
+		return nil, status.Error(codes.InvalidArgument, exceptions.RequiredOrderID)
+	}
+
+	data, err := h.us.GetOrderStatus(spannedContext, req.GetOrderId())
+	if err != nil {
+		return nil, toStatusError(logger, span, err)
+	}
+
+	value, err := utils.ToProtoValue(data["data"])
+	if err != nil {
+		return nil, toStatusError(logger, span, err)
+	}
+	return &pb.DataResponse{Data: value}, nil
+}
+
+// WatchOrderStatus pushes every status change of the order to the client. The changes come
+// from the order events every instance publishes, the provider is only polled while the
+// events are unavailable.
+func (h *OrderGrpcHandler) WatchOrderStatus(req *pb.WatchOrderStatusRequest, stream pb.OrderService_WatchOrderStatusServer) error {
+	ctx := stream.Context()
+	span, spannedContext := trace.T.SpanFromContext(
+		ctx,
+		"WatchOrderStatus[OrderGrpcDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID, _ := ctx.Value("tracer").(string)
+	logger := logger.For(ctx, logger.ModuleOrder).With(
+		zap.String("tracer", uniqueID),
+	)
+
+	if req.GetOrderId() == "" {
+		logger.Info("Response to client", zap.Any("error", exceptions.RequiredOrderID))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, exceptions.RequiredOrderID))
+		return status.Error(codes.InvalidArgument, exceptions.RequiredOrderID)
+	}
+
+	sender := &statusSender{stream: stream, orderId: req.GetOrderId(), logger: logger}
+
+	// subscribed before the first read, so no change falls between the two
+	eventsChan, err := h.us.SubscribeOrderEvents(spannedContext, req.GetOrderId(), "")
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return toStatusError(logger, span, err)
+	}
+	if err != nil {
+		logger.Warn("order events unavailable, polling the order status",
+			zap.String("error", err.Error()),
+		)
+		return h.pollOrderStatus(spannedContext, sender, span, logger)
+	}
+
+	data, err := h.us.GetOrderStatus(spannedContext, req.GetOrderId())
+	if err != nil {
+		return toStatusError(logger, span, err)
+	}
+	if err := sender.send(statusFromData(data), time.Now()); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-eventsChan:
+			if !ok {
+				if ctx.Err() != nil {
+					return nil
+				}
+				logger.Warn("order events closed, polling the order status")
+				return h.pollOrderStatus(spannedContext, sender, span, logger)
+			}
+			if err := sender.send(event.Status, event.OccurredAt); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// pollOrderStatus reads the order status every poll interval until the client leaves
+func (h *OrderGrpcHandler) pollOrderStatus(ctx context.Context, sender *statusSender, span oteltrace.Span, logger *zap.Logger) error {
+	interval := time.Duration(config.C().Grpc.StatusPollInterval) * time.Second
+	if interval <= 0 {
+		interval = defaultStatusPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		data, err := h.us.GetOrderStatus(ctx, sender.orderId)
+		if err != nil {
+			return toStatusError(logger, span, err)
+		}
+		if err := sender.send(statusFromData(data), time.Now()); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// statusSender pushes a status to the client when it differs from the last one sent
+type statusSender struct {
+	stream     pb.OrderService_WatchOrderStatusServer
+	orderId    string
+	logger     *zap.Logger
+	lastStatus string
+}
+
+func (s *statusSender) send(orderStatus string, observedAt time.Time) error {
+	if orderStatus == "" || orderStatus == s.lastStatus {
+		return nil
+	}
+	s.lastStatus = orderStatus
+	update := &pb.OrderStatusUpdate{
+		OrderId:    s.orderId,
+		Status:     orderStatus,
+		ObservedAt: timestamppb.New(observedAt),
+	}
+	if err := s.stream.Send(update); err != nil {
+		s.logger.Info("Response to client", zap.Any("error", err.Error()))
+		return err
+	}
+	s.logger.Info("Response to client", zap.Any("data", update))
+	return nil
+}
+
+func statusFromData(data map[string]any) string {
+	dataMap, ok := data["data"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	invoice, ok := dataMap["invoice"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	orderStatus, _ := invoice["status"].(string)
+	return orderStatus
+}
+
+func toStatusError(logger *zap.Logger, span oteltrace.Span, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Info("Response to client", zap.Any("error", err.Error()))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, gorm.ErrRecordNotFound.Error()))
+		return status.Error(codes.NotFound, exceptions.RecordNotFound)
+	}
+	if errors.Is(err, usecase.ErrOrderExpired) ||
+		errors.Is(err, usecase.ErrOrderNotReconcilable) ||
+		errors.Is(err, usecase.ErrOrderCanceled) ||
+		errors.Is(err, usecase.ErrOrderNotCancelable) ||
+		errors.Is(err, usecase.ErrApprovalPending) ||
+		errors.Is(err, usecase.ErrOrderRejected) {
+		logger.Info("Response to client", zap.Any("error", err.Error()))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	if errors.Is(err, usecase.ErrOrderLocked) || errors.Is(err, usecase.ErrLockLost) {
+		logger.Info("Response to client", zap.Any("error", err.Error()))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+		return status.Error(codes.Aborted, err.Error())
+	}
+	var forbiddenErr *gftErr.ForbiddenErr
+	if errors.As(err, &forbiddenErr) {
+		logger.Info("Response to client", zap.Any("error", forbiddenErr.ErrMsg))
+		span.SetAttributes(attribute.String(exceptions.StatusForbidden, forbiddenErr.ErrMsg))
+		return status.Error(codes.PermissionDenied, forbiddenErr.ErrMsg)
+	}
+	var reqErr *gftErr.RequestErr
+	if errors.As(err, &reqErr) {
+		logger.Info("Response to client", zap.Any("error", reqErr.ErrMsg))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, reqErr.ErrMsg))
+		return status.Error(codes.InvalidArgument, utils.Marshal(reqErr.Response))
+	}
+	logger.Info("Response to client", zap.Any("error", err.Error()))
+	span.SetAttributes(attribute.String(exceptions.InternalServerError, err.Error()))
+	return status.Error(codes.Internal, exceptions.InternalServerError)
+}
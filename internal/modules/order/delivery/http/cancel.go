@@ -0,0 +1,109 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"giftcard/internal/adaptor/trace"
+	"giftcard/internal/exceptions"
+	"giftcard/internal/modules/order/usecase"
+	"giftcard/pkg/logger"
+	"giftcard/pkg/requester"
+	"giftcard/pkg/responser"
+	"giftcard/pkg/utils"
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+type cancelOrderRequestBody struct {
+	OrderId string `json:"orderId" validate:"required"`
+	Reason  string `json:"reason" validate:"max=500"`
+}
+
+// CancelOrder abandons an unconfirmed order, 409 once it was confirmed or closed
+func (h *OrderHandler) CancelOrder(c echo.Context) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		utils.GetRequestCtx(c),
+		"CancelOrder[OrderDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
+	logger := logger.For(spannedContext, logger.ModuleOrder).With(
+		zap.String("tracer", uniqueID),
+	)
+
+	var requestBody cancelOrderRequestBody
+	if err := c.Bind(&requestBody); err != nil {
+		logger.Info("Response to client", zap.Any("error", err.Error()))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+		return c.JSON(http.StatusBadRequest, responser.Response{
+			Message: exceptions.InvalidInput,
+			Data:    "",
+			Success: false})
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&requestBody); err != nil {
+		logger.Info("Response to client", zap.Any("error", err.Error()))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+		return c.JSON(http.StatusBadRequest, responser.Response{
+			Message: exceptions.InvalidInput,
+			Data:    "",
+			Success: false})
+	}
+
+	request := requester.Request{
+		ID:          uniqueID,
+		RequestBody: requestBody,
+		UserIP:      c.RealIP(),
+		Uri:         c.Path(),
+		Method:      c.Request().Method,
+		Host:        c.Request().Host,
+		Header:      c.Request().Header,
+		Params:      c.QueryParams(),
+	}
+	logger.Info("Request from client", zap.Any("data", request))
+	span.SetAttributes(attribute.String("Request", utils.Marshal(request)))
+
+	ctx := context.WithValue(spannedContext, "tracer", uniqueID)
+	order, err := h.us.CancelOrder(ctx, requestBody.OrderId, requestBody.Reason)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Info("Response to client", zap.Any("error", err.Error()))
+			span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+			return c.JSON(http.StatusNotFound, responser.Response{
+				Message: exceptions.RecordNotFound,
+				Data:    "",
+				Success: false,
+			})
+		}
+		if errors.Is(err, usecase.ErrOrderNotCancelable) || errors.Is(err, usecase.ErrOrderLocked) ||
+			errors.Is(err, usecase.ErrLockLost) {
+			logger.Info("Response to client", zap.Any("error", err.Error()))
+			span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+			return c.JSON(http.StatusConflict, responser.Response{
+				Message: err.Error(),
+				Data:    "",
+				Success: false,
+			})
+		}
+		logger.Info("Response to client", zap.Any("error", err.Error()))
+		span.SetAttributes(attribute.String(exceptions.InternalServerError, err.Error()))
+		return c.JSON(http.StatusInternalServerError, responser.Response{
+			Message: exceptions.InternalServerError,
+			Data:    "",
+			Success: false,
+		})
+	}
+
+	logger.Info("Response to client", zap.String("data", order.OrderID))
+	return c.JSON(http.StatusOK, responser.Response{
+		Message: "",
+		Success: true,
+		Data:    order,
+	})
+}
@@ -0,0 +1,161 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"giftcard/config"
+	"giftcard/internal/adaptor/trace"
+	"giftcard/internal/exceptions"
+	"giftcard/pkg/logger"
+	"giftcard/pkg/requester"
+	"giftcard/pkg/responser"
+	"giftcard/pkg/utils"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHeartbeatInterval       = 15 * time.Second
+	defaultMaxConnectionsPerClient = 5
+)
+
+// connectionLimiter counts open event streams per client on this instance
+type connectionLimiter struct {
+	mu          sync.Mutex
+	connections map[string]int
+}
+
+func newConnectionLimiter() *connectionLimiter {
+	return &connectionLimiter{connections: map[string]int{}}
+}
+
+func (l *connectionLimiter) acquire(client string, limit int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.connections[client] >= limit {
+		return false
+	}
+	l.connections[client]++
+	return true
+}
+
+func (l *connectionLimiter) release(client string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.connections[client]--
+	if l.connections[client] <= 0 {
+		delete(l.connections, client)
+	}
+}
+
+// OrderEvents streams order status transitions as server sent events
+func (h *OrderHandler) OrderEvents(c echo.Context) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		utils.GetRequestCtx(c),
+		"OrderEvents[OrderDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
+	logger := logger.For(spannedContext, logger.ModuleOrder).With(
+		zap.String("tracer", uniqueID),
+	)
+
+	orderId := c.Param("id")
+	lastEventID := c.Request().Header.Get("Last-Event-ID")
+
+	request := requester.Request{
+		ID:          uniqueID,
+		RequestBody: "",
+		UserIP:      c.RealIP(),
+		Uri:         c.Path(),
+		Method:      c.Request().Method,
+		Host:        c.Request().Host,
+		Header:      c.Request().Header,
+		Params:      c.QueryParams(),
+	}
+	logger.Info("Request from client", zap.Any("data", request))
+	span.SetAttributes(attribute.String("Request", utils.Marshal(request)))
+
+	maxConnections := config.C().SSE.MaxConnectionsPerClient
+	if maxConnections <= 0 {
+		maxConnections = defaultMaxConnectionsPerClient
+	}
+	client := c.RealIP()
+	if !h.streams.acquire(client, maxConnections) {
+		logger.Info("Response to client", zap.Any("error", exceptions.TooManyConnections))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, exceptions.TooManyConnections))
+		return c.JSON(http.StatusTooManyRequests, responser.Response{
+			Message: exceptions.TooManyConnections,
+			Data:    "",
+			Success: false,
+		})
+	}
+	defer h.streams.release(client)
+
+	ctx, cancel := context.WithCancel(context.WithValue(spannedContext, "tracer", uniqueID))
+	defer cancel()
+
+	eventsChan, err := h.us.SubscribeOrderEvents(ctx, orderId, lastEventID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Info("Response to client", zap.Any("error", err.Error()))
+			span.SetAttributes(attribute.String(exceptions.StatusBadRequest, gorm.ErrRecordNotFound.Error()))
+			return c.JSON(http.StatusNotFound, responser.Response{
+				Message: exceptions.RecordNotFound,
+				Data:    "",
+				Success: false,
+			})
+		}
+		logger.Info("Response to client", zap.Any("error", err.Error()))
+		span.SetAttributes(attribute.String(exceptions.InternalServerError, err.Error()))
+		return c.JSON(http.StatusInternalServerError, responser.Response{
+			Message: exceptions.InternalServerError,
+			Data:    "",
+			Success: false,
+		})
+	}
+
+	heartbeatInterval := time.Duration(config.C().SSE.HeartbeatInterval) * time.Second
+	if heartbeatInterval <= 0 {
+		heartbeatInterval = defaultHeartbeatInterval
+	}
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			logger.Info("Response to client", zap.String("data", "client disconnected"))
+			return nil
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(res, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		case event, ok := <-eventsChan:
+			if !ok {
+				return nil
+			}
+			if _, err := fmt.Fprintf(res, "id: %s\nevent: status\ndata: %s\n\n", event.ID, utils.Marshal(event)); err != nil {
+				return nil
+			}
+			res.Flush()
+			logger.Info("Response to client", zap.Any("data", event))
+		}
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"giftcard/internal/adaptor/trace"
 	"giftcard/internal/exceptions"
 	"giftcard/internal/modules/order/usecase"
+	"giftcard/model"
+	"giftcard/pkg/logger"
 	"giftcard/pkg/requester"
 	"giftcard/pkg/responser"
 	"giftcard/pkg/utils"
@@ -27,7 +29,26 @@ type retrieveOrderRequest struct {
 	OrderId string `json:"orderId" query:"orderId" validate:"required"`
 }
 
+type reconcileOrderRequestBody struct {
+	ID      uint   `json:"id" validate:"required"`
+	OrderId string `json:"orderId" validate:"required"`
+}
+
+type approvalRequestBody struct {
+	OrderId string `json:"orderId" validate:"required"`
+	Comment string `json:"comment" validate:"max=500"`
+}
+
+type approvalResponse struct {
+	OrderId           string                `json:"orderId"`
+	ApprovalPolicy    string                `json:"approvalPolicy"`
+	RequiredApprovals int                   `json:"requiredApprovals"`
+	ApprovalStatus    string                `json:"approvalStatus"`
+	Approvals         []model.OrderApproval `json:"approvals"`
+}
+
 type Product struct {
+	ProductID   string `json:"productId"`
 	Sku         string `json:"sku" validate:"required"`
 	ProductType string `json:"productType" validate:"required"`
 	Quote       uint   `json:"quote" validate:"required,gt=0"`
@@ -39,8 +60,9 @@ type RequestBody struct {
 }
 
 type OrderHandler struct {
-	us     usecase.IOrderUseCase
-	Logger *zap.Logger
+	us      usecase.IOrderUseCase
+	streams *connectionLimiter
+	Logger  *zap.Logger
 }
 
 type OrderHandlerParams struct {
@@ -51,7 +73,8 @@ type OrderHandlerParams struct {
 
 func NewOrderHandler(params OrderHandlerParams) *OrderHandler {
 	return &OrderHandler{
-		us: params.Us,
+		us:      params.Us,
+		streams: newConnectionLimiter(),
 		//Logger: params.Logger,
 	}
 }
@@ -64,7 +87,7 @@ func (h *OrderHandler) ConfirmOrder(c echo.Context) error {
 	defer span.End()
 
 	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
-	logger := zap.L().With(
+	logger := logger.For(spannedContext, logger.ModuleOrder).With(
 		zap.String("tracer", uniqueID),
 	)
 	var requestBody confirmOrderRequestBody
@@ -114,6 +137,21 @@ func (h *OrderHandler) ConfirmOrder(c echo.Context) error {
 			})
 		}
 
+		if errors.Is(err, usecase.ErrOrderExpired) ||
+			errors.Is(err, usecase.ErrOrderCanceled) ||
+			errors.Is(err, usecase.ErrOrderLocked) ||
+			errors.Is(err, usecase.ErrLockLost) ||
+			errors.Is(err, usecase.ErrApprovalPending) ||
+			errors.Is(err, usecase.ErrOrderRejected) {
+			logger.Info("Response to client", zap.Any("error", err.Error()))
+			span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+			return c.JSON(http.StatusConflict, responser.Response{
+				Message: err.Error(),
+				Data:    "",
+				Success: false,
+			})
+		}
+
 		var forbiddenErr *gftErr.ForbiddenErr
 		if errors.As(err, &forbiddenErr) {
 			logger.Info("Response to client", zap.Any("error", err.Error()))
@@ -164,7 +202,7 @@ func (h *OrderHandler) CreateOrder(c echo.Context) error {
 	defer span.End()
 
 	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
-	logger := zap.L().With(
+	logger := logger.For(spannedContext, logger.ModuleOrder).With(
 		zap.String("tracer", uniqueID),
 	)
 
@@ -208,6 +246,9 @@ func (h *OrderHandler) CreateOrder(c echo.Context) error {
 			"quote":       product.Quote,
 			"quantity":    product.Quantity,
 		}
+		if product.ProductID != "" {
+			productMap["productId"] = product.ProductID
+		}
 		productList = append(productList, productMap)
 	}
 
@@ -224,7 +265,8 @@ func (h *OrderHandler) CreateOrder(c echo.Context) error {
 	logger.Info("Request from client", zap.Any("data", request))
 	span.SetAttributes(attribute.String("Request", utils.Marshal(request)))
 
-	ctx := context.WithValue(spannedContext, "tracer", uniqueID)
+	ctx := utils.WithPrincipal(context.WithValue(spannedContext, "tracer", uniqueID), utils.GetPrincipal(c))
+	ctx = utils.WithTeam(ctx, utils.GetTeam(c))
 	data, err := h.us.CreateOrder(ctx, productList)
 
 	if err != nil {
@@ -279,7 +321,7 @@ func (h *OrderHandler) RetrieveOrder(c echo.Context) error {
 	defer span.End()
 
 	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
-	logger := zap.L().With(
+	logger := logger.For(spannedContext, logger.ModuleOrder).With(
 		zap.String("tracer", uniqueID),
 	)
 
@@ -363,3 +405,222 @@ func (h *OrderHandler) RetrieveOrder(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, response)
 }
+
+func (h *OrderHandler) ReconcileOrder(c echo.Context) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		utils.GetRequestCtx(c),
+		"ReconcileOrder[OrderDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
+	logger := logger.For(spannedContext, logger.ModuleOrder).With(
+		zap.String("tracer", uniqueID),
+	)
+
+	var requestBody reconcileOrderRequestBody
+	if err := c.Bind(&requestBody); err != nil {
+		logger.Info("Response to client", zap.Any("error", err.Error()))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+		return c.JSON(http.StatusBadRequest, responser.Response{
+			Message: exceptions.InvalidInput,
+			Data:    "",
+			Success: false})
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&requestBody); err != nil {
+		logger.Info("Response to client", zap.Any("error", err.Error()))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+		return c.JSON(http.StatusBadRequest, responser.Response{
+			Message: exceptions.InvalidInput,
+			Data:    "",
+			Success: false})
+	}
+
+	request := requester.Request{
+		ID:          uniqueID,
+		RequestBody: requestBody,
+		UserIP:      c.RealIP(),
+		Uri:         c.Path(),
+		Method:      c.Request().Method,
+		Host:        c.Request().Host,
+		Header:      c.Request().Header,
+		Params:      c.QueryParams(),
+	}
+	logger.Info("Request from client", zap.Any("data", request))
+	span.SetAttributes(attribute.String("Request", utils.Marshal(request)))
+
+	ctx := context.WithValue(spannedContext, "tracer", uniqueID)
+	data, err := h.us.ReconcileOrder(ctx, requestBody.ID, requestBody.OrderId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Info("Response to client", zap.Any("error", err.Error()))
+			span.SetAttributes(attribute.String(exceptions.StatusBadRequest, gorm.ErrRecordNotFound.Error()))
+			return c.JSON(http.StatusBadRequest, responser.Response{
+				Message: exceptions.RecordNotFound,
+				Data:    "",
+				Success: false,
+			})
+		}
+		if errors.Is(err, usecase.ErrOrderNotReconcilable) {
+			logger.Info("Response to client", zap.Any("error", err.Error()))
+			span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+			return c.JSON(http.StatusConflict, responser.Response{
+				Message: err.Error(),
+				Data:    "",
+				Success: false,
+			})
+		}
+		var reqErr *gftErr.RequestErr
+		if errors.As(err, &reqErr) {
+			logger.Info("Response to client", zap.Any("error", err.Error()))
+			span.SetAttributes(attribute.String(exceptions.StatusBadRequest, reqErr.ErrMsg))
+			return c.JSON(http.StatusBadRequest, responser.Response{
+				Message: reqErr.ErrMsg,
+				Data:    reqErr.Response,
+				Success: false,
+			})
+		}
+		logger.Info("Response to client", zap.Any("error", err.Error()))
+		span.SetAttributes(attribute.String(exceptions.InternalServerError, err.Error()))
+		return c.JSON(http.StatusInternalServerError, responser.Response{
+			Data:    "",
+			Message: exceptions.InternalServerError,
+			Success: false})
+	}
+
+	response := responser.Response{
+		Message: "",
+		Success: true,
+		Data:    data["data"],
+	}
+	logger.Info("Response to client", zap.Any("data", response))
+	span.SetAttributes(attribute.String("Response", utils.Marshal(response)))
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *OrderHandler) ApproveOrder(c echo.Context) error {
+	return h.decideOrder(c, "ApproveOrder[OrderDelivery]", h.us.ApproveOrder)
+}
+
+func (h *OrderHandler) RejectOrder(c echo.Context) error {
+	return h.decideOrder(c, "RejectOrder[OrderDelivery]", h.us.RejectOrder)
+}
+
+type decideOrderFunc func(ctx context.Context, orderId string, principal string, comment string) (*model.Order, []model.OrderApproval, error)
+
+func (h *OrderHandler) decideOrder(c echo.Context, spanName string, decide decideOrderFunc) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		utils.GetRequestCtx(c),
+		spanName,
+		"delivery")
+	defer span.End()
+
+	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
+	logger := logger.For(spannedContext, logger.ModuleOrder).With(
+		zap.String("tracer", uniqueID),
+	)
+
+	principal := utils.GetPrincipal(c)
+	if principal == "" {
+		logger.Info("Response to client", zap.Any("error", exceptions.RequiredPrincipal))
+		span.SetAttributes(attribute.String(exceptions.AuthenticationError, exceptions.RequiredPrincipal))
+		return c.JSON(http.StatusUnauthorized, responser.Response{
+			Message: exceptions.RequiredPrincipal,
+			Data:    "",
+			Success: false})
+	}
+
+	var requestBody approvalRequestBody
+	if err := c.Bind(&requestBody); err != nil {
+		logger.Info("Response to client", zap.Any("error", err.Error()))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+		return c.JSON(http.StatusBadRequest, responser.Response{
+			Message: exceptions.InvalidInput,
+			Data:    "",
+			Success: false})
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&requestBody); err != nil {
+		logger.Info("Response to client", zap.Any("error", err.Error()))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+		return c.JSON(http.StatusBadRequest, responser.Response{
+			Message: exceptions.InvalidInput,
+			Data:    "",
+			Success: false})
+	}
+
+	request := requester.Request{
+		ID:          uniqueID,
+		RequestBody: requestBody,
+		UserIP:      c.RealIP(),
+		Uri:         c.Path(),
+		Method:      c.Request().Method,
+		Host:        c.Request().Host,
+		Header:      c.Request().Header,
+		Params:      c.QueryParams(),
+	}
+	logger.Info("Request from client", zap.Any("data", request))
+	span.SetAttributes(attribute.String("Request", utils.Marshal(request)))
+
+	ctx := context.WithValue(spannedContext, "tracer", uniqueID)
+	order, approvals, err := decide(ctx, requestBody.OrderId, principal, requestBody.Comment)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Info("Response to client", zap.Any("error", err.Error()))
+			span.SetAttributes(attribute.String(exceptions.StatusBadRequest, gorm.ErrRecordNotFound.Error()))
+			return c.JSON(http.StatusNotFound, responser.Response{
+				Message: exceptions.RecordNotFound,
+				Data:    "",
+				Success: false,
+			})
+		}
+		if errors.Is(err, usecase.ErrSelfApproval) || errors.Is(err, usecase.ErrUnknownCreator) {
+			logger.Info("Response to client", zap.Any("error", err.Error()))
+			span.SetAttributes(attribute.String(exceptions.StatusForbidden, err.Error()))
+			return c.JSON(http.StatusForbidden, responser.Response{
+				Message: err.Error(),
+				Data:    "",
+				Success: false,
+			})
+		}
+		if errors.Is(err, usecase.ErrApprovalNotRequired) ||
+			errors.Is(err, usecase.ErrOrderRejected) ||
+			errors.Is(err, usecase.ErrAlreadyDecided) ||
+			errors.Is(err, usecase.ErrOrderLocked) ||
+			errors.Is(err, usecase.ErrLockLost) {
+			logger.Info("Response to client", zap.Any("error", err.Error()))
+			span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+			return c.JSON(http.StatusConflict, responser.Response{
+				Message: err.Error(),
+				Data:    "",
+				Success: false,
+			})
+		}
+		logger.Info("Response to client", zap.Any("error", err.Error()))
+		span.SetAttributes(attribute.String(exceptions.InternalServerError, err.Error()))
+		return c.JSON(http.StatusInternalServerError, responser.Response{
+			Data:    "",
+			Message: exceptions.InternalServerError,
+			Success: false})
+	}
+
+	response := responser.Response{
+		Message: "",
+		Success: true,
+		Data: approvalResponse{
+			OrderId:           order.OrderID,
+			ApprovalPolicy:    order.ApprovalPolicy,
+			RequiredApprovals: order.RequiredApprovals,
+			ApprovalStatus:    order.ApprovalStatus,
+			Approvals:         approvals,
+		},
+	}
+	logger.Info("Response to client", zap.Any("data", response))
+	span.SetAttributes(attribute.String("Response", utils.Marshal(response)))
+
+	return c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,83 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"giftcard/internal/adaptor/trace"
+	"giftcard/internal/exceptions"
+	"giftcard/internal/modules/order/usecase"
+	"giftcard/pkg/logger"
+	"giftcard/pkg/requester"
+	"giftcard/pkg/responser"
+	"giftcard/pkg/utils"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+// InvoicePDF serves the invoice of a completed order, the POST route regenerates it first
+func (h *OrderHandler) InvoicePDF(c echo.Context) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		utils.GetRequestCtx(c),
+		"InvoicePDF[OrderDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
+	logger := logger.For(spannedContext, logger.ModuleOrder).With(
+		zap.String("tracer", uniqueID),
+	)
+
+	orderId := c.Param("id")
+	regenerate := c.Request().Method == http.MethodPost
+
+	request := requester.Request{
+		ID:          uniqueID,
+		RequestBody: "",
+		UserIP:      c.RealIP(),
+		Uri:         c.Path(),
+		Method:      c.Request().Method,
+		Host:        c.Request().Host,
+		Header:      c.Request().Header,
+		Params:      c.QueryParams(),
+	}
+	logger.Info("Request from client", zap.Any("data", request))
+	span.SetAttributes(attribute.String("Request", utils.Marshal(request)))
+
+	ctx := context.WithValue(spannedContext, "tracer", uniqueID)
+	document, err := h.us.InvoicePDF(ctx, orderId, regenerate)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Info("Response to client", zap.Any("error", err.Error()))
+			span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+			return c.JSON(http.StatusNotFound, responser.Response{
+				Message: exceptions.RecordNotFound,
+				Data:    "",
+				Success: false,
+			})
+		}
+		if errors.Is(err, usecase.ErrInvoiceUnavailable) {
+			logger.Info("Response to client", zap.Any("error", err.Error()))
+			span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+			return c.JSON(http.StatusConflict, responser.Response{
+				Message: err.Error(),
+				Data:    "",
+				Success: false,
+			})
+		}
+		logger.Info("Response to client", zap.Any("error", err.Error()))
+		span.SetAttributes(attribute.String(exceptions.InternalServerError, err.Error()))
+		return c.JSON(http.StatusInternalServerError, responser.Response{
+			Message: exceptions.InternalServerError,
+			Data:    "",
+			Success: false,
+		})
+	}
+
+	logger.Info("Response to client", zap.String("data", document.Number))
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("inline; filename=%q", document.Number+".pdf"))
+	return c.Blob(http.StatusOK, "application/pdf", document.Content)
+}
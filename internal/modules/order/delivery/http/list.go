@@ -0,0 +1,158 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"giftcard/internal/adaptor/trace"
+	"giftcard/internal/exceptions"
+	"giftcard/internal/modules/order/repository"
+	"giftcard/internal/modules/order/usecase"
+	"giftcard/pkg/logger"
+	"giftcard/pkg/requester"
+	"giftcard/pkg/responser"
+	"giftcard/pkg/utils"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+	dateLayout       = "2006-01-02"
+)
+
+// sortFields maps the sort query values to the sortable columns, a leading "-" sorts descending
+var sortFields = map[string]string{
+	"createdAt": repository.SortCreatedAt,
+	"updatedAt": repository.SortUpdatedAt,
+	"total":     repository.SortTotal,
+	"id":        repository.SortID,
+}
+
+// ListOrders serves GET /orders?status=a,b&sku=&productType=&createdBy=&from=&to=&sort=-createdAt&limit=&cursor=
+func (h *OrderHandler) ListOrders(c echo.Context) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		utils.GetRequestCtx(c),
+		"ListOrders[OrderDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
+	logger := logger.For(spannedContext, logger.ModuleOrder).With(
+		zap.String("tracer", uniqueID),
+	)
+
+	filter, sortBy, desc, limit, err := parseListQuery(c)
+	if err != nil {
+		logger.Info("Response to client", zap.Any("error", err.Error()))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+		return c.JSON(http.StatusBadRequest, responser.Response{
+			Message: exceptions.InvalidInput,
+			Data:    err.Error(),
+			Success: false})
+	}
+
+	request := requester.Request{
+		ID:          uniqueID,
+		RequestBody: "",
+		UserIP:      c.RealIP(),
+		Uri:         c.Path(),
+		Method:      c.Request().Method,
+		Host:        c.Request().Host,
+		Header:      c.Request().Header,
+		Params:      c.QueryParams(),
+	}
+	logger.Info("Request from client", zap.Any("data", request))
+	span.SetAttributes(attribute.String("Request", utils.Marshal(request)))
+
+	ctx := context.WithValue(spannedContext, "tracer", uniqueID)
+	list, err := h.us.ListOrders(ctx, filter, sortBy, desc, c.QueryParam("cursor"), limit)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidCursor) {
+			logger.Info("Response to client", zap.Any("error", err.Error()))
+			span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+			return c.JSON(http.StatusBadRequest, responser.Response{
+				Message: err.Error(),
+				Data:    "",
+				Success: false,
+			})
+		}
+		logger.Info("Response to client", zap.Any("error", err.Error()))
+		span.SetAttributes(attribute.String(exceptions.InternalServerError, err.Error()))
+		return c.JSON(http.StatusInternalServerError, responser.Response{
+			Data:    "",
+			Message: exceptions.InternalServerError,
+			Success: false})
+	}
+
+	logger.Info("Response to client", zap.Int("data", len(list.Items)))
+	return c.JSON(http.StatusOK, responser.Response{
+		Message: "",
+		Success: true,
+		Data:    list,
+	})
+}
+
+func parseListQuery(c echo.Context) (repository.OrderFilter, string, bool, int, error) {
+	filter := repository.OrderFilter{
+		SKU:         c.QueryParam("sku"),
+		ProductType: c.QueryParam("productType"),
+		CreatedBy:   c.QueryParam("createdBy"),
+	}
+	for _, status := range strings.Split(c.QueryParam("status"), ",") {
+		if status = strings.TrimSpace(status); status != "" {
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	var err error
+	if value := c.QueryParam("from"); value != "" {
+		if filter.From, err = parseListTime(value, false); err != nil {
+			return filter, "", false, 0, fmt.Errorf("invalid from: %w", err)
+		}
+	}
+	if value := c.QueryParam("to"); value != "" {
+		if filter.To, err = parseListTime(value, true); err != nil {
+			return filter, "", false, 0, fmt.Errorf("invalid to: %w", err)
+		}
+	}
+
+	sort := c.QueryParam("sort")
+	if sort == "" {
+		sort = "-createdAt"
+	}
+	desc := strings.HasPrefix(sort, "-")
+	sortBy, ok := sortFields[strings.TrimPrefix(sort, "-")]
+	if !ok {
+		return filter, "", false, 0, fmt.Errorf("invalid sort %q", sort)
+	}
+
+	limit := defaultListLimit
+	if value := c.QueryParam("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > maxListLimit {
+			return filter, "", false, 0, fmt.Errorf("limit must be between 1 and %d", maxListLimit)
+		}
+	}
+	return filter, sortBy, desc, limit, nil
+}
+
+// parseListTime accepts RFC 3339 times and plain dates, a plain date upper bound includes the whole day
+func parseListTime(value string, upper bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(dateLayout, value, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
@@ -0,0 +1,181 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"giftcard/config"
+	"giftcard/internal/adaptor/redis"
+	"giftcard/pkg/logger"
+	"go.uber.org/fx"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultHistorySize = 100
+	defaultHistoryTTL  = 24 * time.Hour
+)
+
+// StatusEvent is a status transition of an order observed by any instance
+type StatusEvent struct {
+	ID             string    `json:"id"`
+	OrderID        string    `json:"orderId"`
+	Status         string    `json:"status"`
+	PreviousStatus string    `json:"previousStatus"`
+	OccurredAt     time.Time `json:"occurredAt"`
+}
+
+// RedisBroker keeps a short history of every order in a redis stream for
+// Last-Event-ID replay and fans out new events through redis pub/sub
+type RedisBroker struct {
+	redis *redis.Store
+}
+
+type RedisBrokerParams struct {
+	fx.In
+	Redis *redis.Store
+}
+
+func NewRedisBroker(params RedisBrokerParams) IEventBroker {
+	return &RedisBroker{
+		redis: params.Redis,
+	}
+}
+
+func (b *RedisBroker) Publish(ctx context.Context, event StatusEvent) error {
+	historySize := config.C().SSE.HistorySize
+	if historySize <= 0 {
+		historySize = defaultHistorySize
+	}
+	historyTTL := time.Duration(config.C().SSE.HistoryTTL) * time.Second
+	if historyTTL <= 0 {
+		historyTTL = defaultHistoryTTL
+	}
+
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	id, err := b.redis.AddToStream(ctx, streamKey(event.OrderID), historySize, historyTTL, event)
+	if err != nil {
+		return err
+	}
+	event.ID = id
+	return b.redis.Publish(ctx, channelName(event.OrderID), event)
+}
+
+// Subscribe replays events after lastEventID, or only the latest one when it is empty,
+// then streams new events until ctx is done
+func (b *RedisBroker) Subscribe(ctx context.Context, orderId string, lastEventID string) (<-chan StatusEvent, error) {
+	pubsub := b.redis.Subscribe(ctx, channelName(orderId))
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return nil, err
+	}
+
+	backlog, err := b.backlog(ctx, orderId, lastEventID)
+	if err != nil {
+		_ = pubsub.Close()
+		return nil, err
+	}
+
+	out := make(chan StatusEvent, len(backlog)+16)
+	go func() {
+		defer close(out)
+		defer pubsub.Close()
+
+		lastID := lastEventID
+		for _, event := range backlog {
+			out <- event
+			lastID = event.ID
+		}
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-messages:
+				if !ok {
+					return
+				}
+				var event StatusEvent
+				if err := json.Unmarshal([]byte(message.Payload), &event); err != nil {
+					logger.For(ctx, logger.ModuleOrder).Error(err.Error())
+					continue
+				}
+				if compareEventID(event.ID, lastID) <= 0 {
+					continue
+				}
+				lastID = event.ID
+				select {
+				case out <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (b *RedisBroker) backlog(ctx context.Context, orderId string, lastEventID string) ([]StatusEvent, error) {
+	var entries []redis.StreamEntry
+	var err error
+	if lastEventID == "" {
+		entries, err = b.redis.LastFromStream(ctx, streamKey(orderId), 1)
+	} else {
+		entries, err = b.redis.RangeStream(ctx, streamKey(orderId), "("+lastEventID, "+")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]StatusEvent, 0, len(entries))
+	for _, entry := range entries {
+		var event StatusEvent
+		if err := json.Unmarshal(entry.Value, &event); err != nil {
+			return nil, err
+		}
+		event.ID = entry.ID
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func streamKey(orderId string) string {
+	return fmt.Sprintf("order_events:%s", orderId)
+}
+
+func channelName(orderId string) string {
+	return fmt.Sprintf("order_events_channel:%s", orderId)
+}
+
+// compareEventID compares two redis stream ids in the "<ms>-<seq>" format
+func compareEventID(a string, b string) int {
+	aMs, aSeq := parseEventID(a)
+	bMs, bSeq := parseEventID(b)
+	switch {
+	case aMs != bMs:
+		if aMs < bMs {
+			return -1
+		}
+		return 1
+	case aSeq != bSeq:
+		if aSeq < bSeq {
+			return -1
+		}
+		return 1
+	default:
+		return 0
+	}
+}
+
+func parseEventID(id string) (uint64, uint64) {
+	msPart, seqPart, _ := strings.Cut(id, "-")
+	ms, _ := strconv.ParseUint(msPart, 10, 64)
+	seq, _ := strconv.ParseUint(seqPart, 10, 64)
+	return ms, seq
+}
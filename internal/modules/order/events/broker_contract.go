@@ -0,0 +1,8 @@
+package events
+
+import "context"
+
+type IEventBroker interface {
+	Publish(ctx context.Context, event StatusEvent) error
+	Subscribe(ctx context.Context, orderId string, lastEventID string) (<-chan StatusEvent, error)
+}
@@ -0,0 +1,31 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"giftcard/model"
+	"giftcard/pkg/eventbus"
+)
+
+type statusChange struct {
+	OrderID        string `json:"orderId"`
+	Status         string `json:"status"`
+	PreviousStatus string `json:"previousStatus"`
+}
+
+// SubscribeStatusChanges fans the order status changes relayed from the outbox out to SSE clients
+func SubscribeStatusChanges(bus *eventbus.Bus, broker IEventBroker) {
+	bus.Subscribe(model.OrderStatusChangedEvent, func(ctx context.Context, event eventbus.Event) error {
+		var change statusChange
+		if err := json.Unmarshal(event.Payload, &change); err != nil {
+			return err
+		}
+
+		return broker.Publish(ctx, StatusEvent{
+			OrderID:        change.OrderID,
+			Status:         change.Status,
+			PreviousStatus: change.PreviousStatus,
+			OccurredAt:     event.OccurredAt,
+		})
+	})
+}
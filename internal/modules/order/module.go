@@ -1,14 +1,22 @@
 package order
 
 import (
+	grpcDelivery "giftcard/internal/modules/order/delivery/grpc"
 	"giftcard/internal/modules/order/delivery/http"
+	"giftcard/internal/modules/order/events"
 	"giftcard/internal/modules/order/repository"
 	"giftcard/internal/modules/order/usecase"
+	"giftcard/internal/modules/order/worker"
 	"go.uber.org/fx"
 )
 
 var Module = fx.Module("order",
 	fx.Provide(usecase.NewOrderUseCase),
 	fx.Provide(delivery.NewOrderHandler),
+	fx.Provide(grpcDelivery.NewOrderGrpcHandler),
 	fx.Provide(repository.NewOrderRepository),
+	fx.Provide(events.NewRedisBroker),
+	fx.Invoke(events.SubscribeStatusChanges),
+	fx.Invoke(worker.RunSweeper),
+	fx.Invoke(usecase.RegisterMetrics),
 )
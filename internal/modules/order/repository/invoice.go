@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"giftcard/model"
+	"gorm.io/gorm/clause"
+)
+
+func (repo *OrderRepository) GetInvoiceDocument(orderRef uint) (*model.InvoiceDocument, error) {
+	var document model.InvoiceDocument
+	if err := repo.db.Where("order_ref = ?", orderRef).First(&document).Error; err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
+
+// SaveInvoiceDocument stores the document of the order, replacing the content of an
+// earlier one while keeping its number
+func (repo *OrderRepository) SaveInvoiceDocument(document *model.InvoiceDocument) error {
+	return repo.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "order_ref"}},
+		DoUpdates: clause.AssignmentColumns([]string{"content", "generated_at", "updated_at"}),
+	}).Create(document).Error
+}
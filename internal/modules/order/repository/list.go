@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"giftcard/model"
+	"gorm.io/gorm"
+	"strconv"
+	"time"
+)
+
+// Sortable order listing columns
+const (
+	SortCreatedAt = "created_at"
+	SortUpdatedAt = "updated_at"
+	SortTotal     = "total"
+	SortID        = "id"
+)
+
+// OrderFilter narrows the order listing, zero values do not filter
+type OrderFilter struct {
+	Statuses    []string
+	SKU         string
+	ProductType string
+	CreatedBy   string
+	From        time.Time
+	To          time.Time
+}
+
+// OrderCursor is the position of the last order of a page, in the page sort
+type OrderCursor struct {
+	Value string
+	ID    uint
+}
+
+// OrderPageQuery selects one keyset page, After is nil for the first page
+type OrderPageQuery struct {
+	SortBy string
+	Desc   bool
+	After  *OrderCursor
+	Limit  int
+}
+
+// StatusTotal is the number and value of the filtered orders in one status and currency,
+// totals of different currencies are never added up
+type StatusTotal struct {
+	Status   string  `json:"status"`
+	Currency string  `json:"currency"`
+	Count    int64   `json:"count"`
+	Total    float64 `json:"total"`
+}
+
+// ListOrders returns up to query.Limit orders after the cursor, ties on the sort column are broken by id
+func (repo *OrderRepository) ListOrders(filter OrderFilter, query OrderPageQuery) ([]model.Order, error) {
+	direction := "ASC"
+	comparison := ">"
+	if query.Desc {
+		direction = "DESC"
+		comparison = "<"
+	}
+
+	db := repo.cluster.Reader().Scopes(filterOrders(filter))
+	if query.After != nil {
+		if query.SortBy == SortID {
+			db = db.Where("id "+comparison+" ?", query.After.ID)
+		} else {
+			value, err := ParseCursorValue(query.SortBy, query.After.Value)
+			if err != nil {
+				return nil, err
+			}
+			db = db.Where("("+query.SortBy+", id) "+comparison+" (?, ?)", value, query.After.ID)
+		}
+	}
+	if query.SortBy != SortID {
+		db = db.Order(query.SortBy + " " + direction)
+	}
+
+	var orders []model.Order
+	if err := db.Order("id " + direction).Limit(query.Limit).Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// CountOrdersByStatus aggregates the filtered orders by status and currency
+func (repo *OrderRepository) CountOrdersByStatus(filter OrderFilter) ([]StatusTotal, error) {
+	var totals []StatusTotal
+	if err := repo.cluster.Reader().Model(&model.Order{}).
+		Scopes(filterOrders(filter)).
+		Select("status, coalesce(currency, '') AS currency, count(*) AS count, coalesce(sum(total), 0) AS total").
+		Group("status, coalesce(currency, '')").
+		Order("status, currency").
+		Scan(&totals).Error; err != nil {
+		return nil, err
+	}
+	return totals, nil
+}
+
+// CursorFor returns the cursor pointing at order in the given sort
+func CursorFor(order model.Order, sortBy string) OrderCursor {
+	cursor := OrderCursor{ID: order.ID}
+	switch sortBy {
+	case SortCreatedAt:
+		cursor.Value = order.CreatedAt.Format(time.RFC3339Nano)
+	case SortUpdatedAt:
+		cursor.Value = order.UpdatedAt.Format(time.RFC3339Nano)
+	case SortTotal:
+		cursor.Value = strconv.FormatFloat(order.Total, 'g', -1, 64)
+	}
+	return cursor
+}
+
+// ParseCursorValue converts the cursor value back to the type of the sort column
+func ParseCursorValue(sortBy string, value string) (any, error) {
+	switch sortBy {
+	case SortCreatedAt, SortUpdatedAt:
+		return time.Parse(time.RFC3339Nano, value)
+	case SortTotal:
+		return strconv.ParseFloat(value, 64)
+	}
+	return value, nil
+}
+
+func filterOrders(filter OrderFilter) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(filter.Statuses) > 0 {
+			db = db.Where("status IN ?", filter.Statuses)
+		}
+		if filter.SKU != "" {
+			db = db.Where("sku = ?", filter.SKU)
+		}
+		if filter.ProductType != "" {
+			db = db.Where("product_type = ?", filter.ProductType)
+		}
+		if filter.CreatedBy != "" {
+			db = db.Where("created_by = ?", filter.CreatedBy)
+		}
+		if !filter.From.IsZero() {
+			db = db.Where("created_at >= ?", filter.From)
+		}
+		if !filter.To.IsZero() {
+			db = db.Where("created_at < ?", filter.To)
+		}
+		return db
+	}
+}
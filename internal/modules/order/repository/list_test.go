@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"giftcard/model"
+	"testing"
+	"time"
+)
+
+func TestCursorFor(t *testing.T) {
+	createdAt := time.Date(2024, 3, 10, 10, 15, 0, 123456789, time.UTC)
+	order := model.Order{Total: 12.5}
+	order.ID = 7
+	order.CreatedAt = createdAt
+	order.UpdatedAt = createdAt.Add(time.Hour)
+
+	tests := []struct {
+		sortBy string
+		value  string
+		parsed any
+	}{
+		{sortBy: SortCreatedAt, value: "2024-03-10T10:15:00.123456789Z", parsed: createdAt},
+		{sortBy: SortUpdatedAt, value: "2024-03-10T11:15:00.123456789Z", parsed: createdAt.Add(time.Hour)},
+		{sortBy: SortTotal, value: "12.5", parsed: 12.5},
+		{sortBy: SortID, value: "", parsed: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sortBy, func(t *testing.T) {
+			cursor := CursorFor(order, tt.sortBy)
+			if cursor.ID != 7 || cursor.Value != tt.value {
+				t.Fatalf("CursorFor() = %+v, want value %q and id 7", cursor, tt.value)
+			}
+			parsed, err := ParseCursorValue(tt.sortBy, cursor.Value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if at, ok := parsed.(time.Time); ok {
+				if !at.Equal(tt.parsed.(time.Time)) {
+					t.Fatalf("ParseCursorValue() = %s, want %s", at, tt.parsed)
+				}
+				return
+			}
+			if parsed != tt.parsed {
+				t.Fatalf("ParseCursorValue() = %v, want %v", parsed, tt.parsed)
+			}
+		})
+	}
+}
+
+func TestParseCursorValueInvalid(t *testing.T) {
+	for _, sortBy := range []string{SortCreatedAt, SortUpdatedAt, SortTotal} {
+		if _, err := ParseCursorValue(sortBy, "yesterday"); err == nil {
+			t.Errorf("expected %s to refuse a malformed value", sortBy)
+		}
+	}
+}
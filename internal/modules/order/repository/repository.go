@@ -1,28 +1,47 @@
 package repository
 
 import (
+	"errors"
+	"giftcard/internal/adaptor/postgres"
+	"giftcard/internal/exceptions"
 	"giftcard/model"
 	"go.uber.org/fx"
 	"gorm.io/gorm"
+	"time"
 )
 
+// ErrLockLost is returned when a newer order lock owner already saved the order
+var ErrLockLost = errors.New(exceptions.OrderLockLost)
+
 type OrderRepository struct {
-	db *gorm.DB
+	db      *gorm.DB
+	cluster *postgres.Cluster
 }
 
 type OrderRepositoryParams struct {
 	fx.In
-	Db *gorm.DB
+	Db      *gorm.DB
+	Cluster *postgres.Cluster
 }
 
 func NewOrderRepository(params OrderRepositoryParams) IOrderRepository {
 	return &OrderRepository{
-		db: params.Db,
+		db:      params.Db,
+		cluster: params.Cluster,
 	}
 }
 
-func (repo *OrderRepository) InsertOrder(order *model.Order) error {
-	return repo.db.Create(order).Error
+// InsertOrder saves the order together with every product line of it
+func (repo *OrderRepository) InsertOrder(order *model.Order, items []model.OrderItem) error {
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(order).Error; err != nil {
+			return err
+		}
+		for i := range items {
+			items[i].OrderRef = order.ID
+		}
+		return tx.Create(&items).Error
+	})
 }
 
 func (repo *OrderRepository) GetOrder(orderId string) (*model.Order, error) {
@@ -33,6 +52,22 @@ func (repo *OrderRepository) GetOrder(orderId string) (*model.Order, error) {
 	return &order, nil
 }
 
+func (repo *OrderRepository) GetOrderByID(id uint) (*model.Order, error) {
+	var order model.Order
+	if err := repo.db.First(&order, id).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (repo *OrderRepository) ListOrderItems(orderRef uint) ([]model.OrderItem, error) {
+	var items []model.OrderItem
+	if err := repo.db.Where("order_ref = ?", orderRef).Order("id").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 func (repo *OrderRepository) UpdateOrder(order *model.Order, newStatus string) error {
 	order.Status = newStatus
 	if err := repo.db.Save(order).Error; err != nil {
@@ -40,3 +75,75 @@ func (repo *OrderRepository) UpdateOrder(order *model.Order, newStatus string) e
 	}
 	return nil
 }
+
+// SaveOrderWithEvents saves the order and its outbox events in one transaction
+func (repo *OrderRepository) SaveOrderWithEvents(order *model.Order, events []model.OutboxEvent) error {
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := saveFenced(tx, order); err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		return tx.Create(&events).Error
+	})
+}
+
+// ListStaleOrders returns the orders still in status that were last updated before the given time
+func (repo *OrderRepository) ListStaleOrders(status string, before time.Time) ([]model.Order, error) {
+	var orders []model.Order
+	if err := repo.db.Where("status = ? AND updated_at < ?", status, before).Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// ListExpiredOrders returns the unconfirmed orders whose expiry has passed
+func (repo *OrderRepository) ListExpiredOrders(now time.Time, excludedStatuses []string) ([]model.Order, error) {
+	var orders []model.Order
+	if err := repo.db.
+		Where("confirmed_at IS NULL AND expires_at < ? AND status NOT IN ?", now, excludedStatuses).
+		Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// ListApprovals returns the approver decisions recorded on the order, oldest first
+func (repo *OrderRepository) ListApprovals(orderRef uint) ([]model.OrderApproval, error) {
+	var approvals []model.OrderApproval
+	if err := repo.db.Where("order_ref = ?", orderRef).Order("id").Find(&approvals).Error; err != nil {
+		return nil, err
+	}
+	return approvals, nil
+}
+
+// SaveApprovalWithEvents records the approver decision together with the order and its outbox events
+func (repo *OrderRepository) SaveApprovalWithEvents(approval *model.OrderApproval, order *model.Order, events []model.OutboxEvent) error {
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(approval).Error; err != nil {
+			return err
+		}
+		if err := saveFenced(tx, order); err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		return tx.Create(&events).Error
+	})
+}
+
+// saveFenced saves the order unless an owner of a newer order lock saved it since, the caller
+// sets order.LockFence to the token of its lock. Updates is used over Save, a Save matching no
+// row would insert the order again.
+func saveFenced(tx *gorm.DB, order *model.Order) error {
+	result := tx.Model(order).Where("lock_fence <= ?", order.LockFence).Select("*").Updates(order)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
@@ -1,9 +1,23 @@
 package repository
 
-import "giftcard/model"
+import (
+	"giftcard/model"
+	"time"
+)
 
 type IOrderRepository interface {
-	InsertOrder(order *model.Order) error
+	InsertOrder(order *model.Order, items []model.OrderItem) error
 	GetOrder(orderId string) (*model.Order, error)
+	GetOrderByID(id uint) (*model.Order, error)
+	ListOrderItems(orderRef uint) ([]model.OrderItem, error)
 	UpdateOrder(order *model.Order, newStatus string) error
+	SaveOrderWithEvents(order *model.Order, events []model.OutboxEvent) error
+	ListStaleOrders(status string, before time.Time) ([]model.Order, error)
+	ListExpiredOrders(now time.Time, excludedStatuses []string) ([]model.Order, error)
+	ListOrders(filter OrderFilter, query OrderPageQuery) ([]model.Order, error)
+	CountOrdersByStatus(filter OrderFilter) ([]StatusTotal, error)
+	ListApprovals(orderRef uint) ([]model.OrderApproval, error)
+	SaveApprovalWithEvents(approval *model.OrderApproval, order *model.Order, events []model.OutboxEvent) error
+	GetInvoiceDocument(orderRef uint) (*model.InvoiceDocument, error)
+	SaveInvoiceDocument(document *model.InvoiceDocument) error
 }
@@ -0,0 +1,203 @@
+package usecase
+
+import (
+	"context"
+	"giftcard/config"
+	"giftcard/internal/adaptor/trace"
+	"giftcard/model"
+	"giftcard/pkg/logger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ApproveOrder records the principal approval, the order becomes confirmable once
+// the number of approvals required by its policy is reached
+func (us giftCardOrderUseCase) ApproveOrder(ctx context.Context, orderId string, principal string, comment string) (*model.Order, []model.OrderApproval, error) {
+	return us.decideOrder(ctx, "ApproveOrderUseCase", orderId, principal, model.ApprovalDecisionApprove, comment)
+}
+
+// RejectOrder records the principal rejection, a single rejection blocks the confirmation
+func (us giftCardOrderUseCase) RejectOrder(ctx context.Context, orderId string, principal string, comment string) (*model.Order, []model.OrderApproval, error) {
+	return us.decideOrder(ctx, "RejectOrderUseCase", orderId, principal, model.ApprovalDecisionReject, comment)
+}
+
+func (us giftCardOrderUseCase) decideOrder(ctx context.Context, spanName string, orderId string, principal string, decision string, comment string) (*model.Order, []model.OrderApproval, error) {
+	span, spannedContext := trace.T.SpanFromContext(
+		ctx,
+		spanName,
+		"UseCase")
+	defer span.End()
+
+	uniqueID, _ := ctx.Value("tracer").(string)
+
+	logger := logger.For(ctx, logger.ModuleOrder).With(
+		zap.String("tracer", uniqueID),
+	)
+
+	// concurrent approvals would each count the others out and leave the order pending
+	lock, _, err := us.lockOrder(spannedContext, orderId, config.C().OrderLock.Wait)
+	if err != nil {
+		logger.Error("error while lock order",
+			zap.String("error", err.Error()),
+		)
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, nil, err
+	}
+	defer unlockOrder(lock, logger)
+
+	order, err := us.repo.GetOrder(orderId)
+	if err != nil {
+		logger.Error("error while get order from DB",
+			zap.String("error", err.Error()),
+		)
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, nil, err
+	}
+
+	switch order.ApprovalStatus {
+	case model.ApprovalNotRequired:
+		span.SetAttributes(attribute.String("error", ErrApprovalNotRequired.Error()))
+		return nil, nil, ErrApprovalNotRequired
+	case model.ApprovalRejected:
+		span.SetAttributes(attribute.String("error", ErrOrderRejected.Error()))
+		return nil, nil, ErrOrderRejected
+	}
+	if order.ConfirmedAt != nil || order.Status == model.OrderStatusExpired {
+		span.SetAttributes(attribute.String("error", ErrAlreadyDecided.Error()))
+		return nil, nil, ErrAlreadyDecided
+	}
+	// without a creator a self approval can not be ruled out
+	if order.CreatedBy == "" {
+		span.SetAttributes(attribute.String("error", ErrUnknownCreator.Error()))
+		return nil, nil, ErrUnknownCreator
+	}
+	if order.CreatedBy == principal {
+		span.SetAttributes(attribute.String("error", ErrSelfApproval.Error()))
+		return nil, nil, ErrSelfApproval
+	}
+
+	approvals, err := us.repo.ListApprovals(order.ID)
+	if err != nil {
+		logger.Error("error while get order approvals from DB",
+			zap.String("error", err.Error()),
+		)
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, nil, err
+	}
+
+	approved := 0
+	for _, approval := range approvals {
+		if approval.Principal == principal {
+			span.SetAttributes(attribute.String("error", ErrAlreadyDecided.Error()))
+			return nil, nil, ErrAlreadyDecided
+		}
+		if approval.Decision == model.ApprovalDecisionApprove {
+			approved++
+		}
+	}
+
+	approval := model.OrderApproval{
+		OrderRef:  order.ID,
+		OrderID:   order.OrderID,
+		Principal: principal,
+		Decision:  decision,
+		Comment:   comment,
+		CreatedAt: time.Now(),
+	}
+
+	var eventTypes []string
+	switch {
+	case decision == model.ApprovalDecisionReject:
+		order.ApprovalStatus = model.ApprovalRejected
+		eventTypes = append(eventTypes, model.OrderRejectedEvent)
+	case approved+1 >= order.RequiredApprovals:
+		order.ApprovalStatus = model.ApprovalApproved
+		eventTypes = append(eventTypes, model.OrderApprovedEvent)
+	}
+
+	outboxEvents, err := us.orderEvents(order, order.Status, eventTypes)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, nil, err
+	}
+	if err := fenceOrder(spannedContext, order, lock, logger); err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, nil, err
+	}
+	if err := us.repo.SaveApprovalWithEvents(&approval, order, outboxEvents); err != nil {
+		logger.Error("error while insert order approval from DB",
+			zap.String("error", err.Error()),
+		)
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, nil, err
+	}
+
+	logger.Info("order approval recorded",
+		zap.String("order", order.OrderID),
+		zap.String("principal", principal),
+		zap.String("decision", decision),
+		zap.String("approvalStatus", order.ApprovalStatus),
+	)
+	return order, append(approvals, approval), nil
+}
+
+// applyApprovalPolicy sets the approvals the order needs, the strictest matching policy wins
+func applyApprovalPolicy(order *model.Order, items []model.OrderItem) {
+	conf := config.C().Approval
+	if !conf.Enabled {
+		return
+	}
+	for _, policy := range conf.Policies {
+		if !policyMatches(policy, order, items) {
+			continue
+		}
+		approvers := max(policy.Approvers, 1)
+		if approvers > order.RequiredApprovals {
+			order.ApprovalPolicy = policy.Name
+			order.RequiredApprovals = approvers
+			order.ApprovalStatus = model.ApprovalPending
+		}
+	}
+}
+
+// policyMatches reports whether the policy covers any line of the order. A line whose country
+// is unknown matches every country, so a failed lookup asks for an approval instead of skipping it.
+// The amount of an order paid from another wallet is not compared, an order whose wallet is
+// unknown is compared as is.
+func policyMatches(policy config.ApprovalPolicy, order *model.Order, items []model.OrderItem) bool {
+	if policy.MinAmount > 0 {
+		if order.Currency != "" && !strings.EqualFold(order.Currency, policy.Currency) {
+			return false
+		}
+		if order.Total < policy.MinAmount {
+			return false
+		}
+	}
+	if len(policy.ProductTypes) == 0 && len(policy.Countries) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(items, func(item model.OrderItem) bool {
+		if len(policy.ProductTypes) > 0 && !containsFold(policy.ProductTypes, item.ProductType) {
+			return false
+		}
+		return len(policy.Countries) == 0 || item.Country == "" || containsFold(policy.Countries, item.Country)
+	})
+}
+
+// policiesNeedCountry reports whether any policy filters on the product country,
+// which costs an extra provider call per order
+func policiesNeedCountry() bool {
+	conf := config.C().Approval
+	return conf.Enabled && slices.ContainsFunc(conf.Policies, func(policy config.ApprovalPolicy) bool {
+		return len(policy.Countries) > 0
+	})
+}
+
+func containsFold(values []string, value string) bool {
+	return slices.ContainsFunc(values, func(v string) bool {
+		return strings.EqualFold(v, value)
+	})
+}
@@ -0,0 +1,93 @@
+package usecase
+
+import (
+	"giftcard/config"
+	"giftcard/model"
+	"testing"
+)
+
+func TestPolicyMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   config.ApprovalPolicy
+		total    float64
+		currency string
+		items    []model.OrderItem
+		want     bool
+	}{
+		{
+			name:   "below the amount",
+			policy: config.ApprovalPolicy{MinAmount: 100, Currency: "EUR"},
+			total:  50,
+			items:  []model.OrderItem{{ProductType: "game"}},
+			want:   false,
+		},
+		{
+			name:   "amount only",
+			policy: config.ApprovalPolicy{MinAmount: 100, Currency: "EUR"},
+			total:  150,
+			items:  []model.OrderItem{{ProductType: "game"}},
+			want:   true,
+		},
+		{
+			name:     "amount of another wallet",
+			policy:   config.ApprovalPolicy{MinAmount: 100, Currency: "EUR"},
+			total:    500,
+			currency: "DKK",
+			items:    []model.OrderItem{{ProductType: "game"}},
+			want:     false,
+		},
+		{
+			name:     "amount of the policy wallet",
+			policy:   config.ApprovalPolicy{MinAmount: 100, Currency: "EUR"},
+			total:    150,
+			currency: "eur",
+			items:    []model.OrderItem{{ProductType: "game"}},
+			want:     true,
+		},
+		{
+			name:   "product type of a later line",
+			policy: config.ApprovalPolicy{ProductTypes: []string{"crypto"}},
+			items:  []model.OrderItem{{ProductType: "game"}, {ProductType: "Crypto"}},
+			want:   true,
+		},
+		{
+			name:   "no line of the product type",
+			policy: config.ApprovalPolicy{ProductTypes: []string{"crypto"}},
+			items:  []model.OrderItem{{ProductType: "game"}},
+			want:   false,
+		},
+		{
+			name:   "country of a later line",
+			policy: config.ApprovalPolicy{Countries: []string{"us"}},
+			items:  []model.OrderItem{{Country: "DE"}, {Country: "US"}},
+			want:   true,
+		},
+		{
+			name:   "unknown country fails closed",
+			policy: config.ApprovalPolicy{Countries: []string{"US"}},
+			items:  []model.OrderItem{{Country: "DE"}, {}},
+			want:   true,
+		},
+		{
+			name:   "every country known and outside the policy",
+			policy: config.ApprovalPolicy{Countries: []string{"US"}},
+			items:  []model.OrderItem{{Country: "DE"}},
+			want:   false,
+		},
+		{
+			name:   "type and country must match on the same line",
+			policy: config.ApprovalPolicy{ProductTypes: []string{"crypto"}, Countries: []string{"US"}},
+			items:  []model.OrderItem{{ProductType: "crypto", Country: "DE"}, {ProductType: "game", Country: "US"}},
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &model.Order{Total: tt.total, Currency: tt.currency}
+			if got := policyMatches(tt.policy, order, tt.items); got != tt.want {
+				t.Errorf("policyMatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"context"
+	"giftcard/internal/adaptor/trace"
+	"giftcard/model"
+	"giftcard/pkg/logger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// CancelOrder abandons an order that was never confirmed. The provider has no cancel call
+// and does not charge unconfirmed orders, so the order is only closed locally and left to
+// expire there.
+func (us giftCardOrderUseCase) CancelOrder(ctx context.Context, orderId string, reason string) (*model.Order, error) {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"CancelOrderUseCase",
+		"UseCase")
+	defer span.End()
+
+	uniqueID, _ := ctx.Value("tracer").(string)
+
+	logger := logger.For(ctx, logger.ModuleOrder).With(
+		zap.String("tracer", uniqueID),
+	)
+
+	lock, _, err := us.lockOrder(ctx, orderId, false)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	defer unlockOrder(lock, logger)
+
+	order, err := us.repo.GetOrder(orderId)
+	if err != nil {
+		logger.Error("error while get order from DB",
+			zap.String("error", err.Error()),
+		)
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	if !cancelable(order) {
+		span.SetAttributes(attribute.String("error", ErrOrderNotCancelable.Error()))
+		return nil, ErrOrderNotCancelable
+	}
+
+	previousStatus := order.Status
+	order.Status = model.OrderStatusCanceled
+	outboxEvents, err := us.orderEvents(order, previousStatus, []string{model.OrderCanceledEvent, model.OrderStatusChangedEvent})
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	if err := fenceOrder(ctx, order, lock, logger); err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	if err := us.repo.SaveOrderWithEvents(order, outboxEvents); err != nil {
+		logger.Error("error while update order status from DB",
+			zap.String("error", err.Error()),
+		)
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+
+	logger.Info("order canceled",
+		zap.String("order", order.OrderID),
+		zap.String("previousStatus", previousStatus),
+		zap.String("reason", reason),
+	)
+	return order, nil
+}
+
+// cancelable reports whether the order exists at the provider and was never confirmed
+func cancelable(order *model.Order) bool {
+	if order.ConfirmedAt != nil {
+		return false
+	}
+	switch order.Status {
+	case model.OrderStatusCreating, model.OrderStatusCreateFailed, model.OrderStatusExpired, model.OrderStatusCanceled:
+		return false
+	}
+	return !model.DeliveredStatuses[order.Status] && !model.FailedStatuses[order.Status]
+}
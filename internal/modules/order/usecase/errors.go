@@ -0,0 +1,42 @@
+package usecase
+
+import (
+	"errors"
+	"giftcard/internal/exceptions"
+	"giftcard/internal/modules/order/repository"
+)
+
+var (
+	ErrOrderNotReconcilable = errors.New(exceptions.OrderNotReconcilable)
+	ErrOrderExpired         = errors.New(exceptions.OrderExpired)
+	ErrApprovalPending      = errors.New(exceptions.OrderApprovalPending)
+	ErrOrderRejected        = errors.New(exceptions.OrderRejected)
+	ErrApprovalNotRequired  = errors.New(exceptions.OrderApprovalNotRequired)
+	ErrAlreadyDecided       = errors.New(exceptions.OrderAlreadyDecided)
+	ErrSelfApproval         = errors.New(exceptions.OrderSelfApproval)
+	ErrUnknownCreator       = errors.New(exceptions.OrderUnknownCreator)
+	ErrInvalidCursor        = errors.New(exceptions.InvalidCursor)
+	ErrInvoiceUnavailable   = errors.New(exceptions.InvoiceUnavailable)
+	ErrOrderNotCancelable   = errors.New(exceptions.OrderNotCancelable)
+	ErrOrderCanceled        = errors.New(exceptions.OrderCanceled)
+	ErrOrderLocked          = errors.New(exceptions.OrderLocked)
+	ErrLocalStatus          = errors.New(exceptions.OrderLocalStatus)
+	// ErrLockLost is returned instead of saving when the order lock expired while it was held
+	ErrLockLost = repository.ErrLockLost
+)
+
+// UnknownOutcomeError is returned by CreateOrder when the provider call failed without an
+// answer, the provider may have created the order anyway. OrderRef is the local order left
+// in creating, reconcile it before ordering the same products again.
+type UnknownOutcomeError struct {
+	OrderRef uint
+	Err      error
+}
+
+func (e *UnknownOutcomeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UnknownOutcomeError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,288 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"giftcard/config"
+	"giftcard/internal/adaptor/trace"
+	"giftcard/model"
+	"giftcard/pkg/logger"
+	"giftcard/pkg/pdf"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// invoice layout, in points from the bottom left corner of an A4 page
+const (
+	marginLeft     = 50.0
+	marginRight    = pdf.PageWidth - 50
+	marginTop      = pdf.PageHeight - 50
+	marginBottom   = 70.0
+	lineHeight     = 14.0
+	columnType     = 300.0
+	columnQuantity = 390.0
+	columnRate     = 460.0
+)
+
+// InvoicePDF returns the stored invoice document of a completed order, rendering it on
+// first use. regenerate renders it again with the current company header.
+func (us giftCardOrderUseCase) InvoicePDF(ctx context.Context, orderId string, regenerate bool) (*model.InvoiceDocument, error) {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"InvoicePDFUseCase",
+		"UseCase")
+	defer span.End()
+
+	uniqueID, _ := ctx.Value("tracer").(string)
+
+	logger := logger.For(ctx, logger.ModuleOrder).With(
+		zap.String("tracer", uniqueID),
+	)
+
+	order, err := us.repo.GetOrder(orderId)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	if !model.DeliveredStatuses[order.Status] || order.Invoice == nil {
+		span.SetAttributes(attribute.String("error", ErrInvoiceUnavailable.Error()))
+		return nil, ErrInvoiceUnavailable
+	}
+
+	invoiceConfig := config.C().Invoice
+	number := fmt.Sprintf("%s%06d", invoiceConfig.NumberPrefix, order.ID)
+	document, err := us.repo.GetInvoiceDocument(order.ID)
+	switch {
+	case err == nil && !regenerate:
+		return document, nil
+	case err == nil:
+		// a regenerated invoice keeps its number
+		number = document.Number
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+
+	var content bytes.Buffer
+	if _, err := renderInvoice(order, number, invoiceConfig.Company).WriteTo(&content); err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	document = &model.InvoiceDocument{
+		OrderRef:    order.ID,
+		Number:      number,
+		Content:     content.Bytes(),
+		GeneratedAt: time.Now(),
+	}
+	if err := us.repo.SaveInvoiceDocument(document); err != nil {
+		logger.Error("error while save invoice document to DB",
+			zap.String("error", err.Error()),
+		)
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+
+	logger.Info("invoice generated",
+		zap.String("orderId", order.OrderID),
+		zap.String("number", number),
+		zap.Bool("regenerate", regenerate),
+	)
+	return document, nil
+}
+
+// invoiceLayout places the invoice lines, starting a new page with the table header
+// whenever the current one is full
+type invoiceLayout struct {
+	doc  *pdf.Document
+	page *pdf.Page
+	y    float64
+}
+
+func (l *invoiceLayout) newPage() {
+	l.page = l.doc.AddPage()
+	l.y = marginTop
+}
+
+func (l *invoiceLayout) tableHeader() {
+	l.page.Text(marginLeft, l.y, pdf.Bold, 9, "Description")
+	l.page.Text(columnType, l.y, pdf.Bold, 9, "Type")
+	l.page.TextRight(columnQuantity, l.y, pdf.Bold, 9, "Qty")
+	l.page.TextRight(columnRate, l.y, pdf.Bold, 9, "Rate")
+	l.page.TextRight(marginRight, l.y, pdf.Bold, 9, "Amount")
+	l.page.Line(marginLeft, l.y-4, marginRight, l.y-4, 0.5)
+	l.y -= lineHeight + 2
+}
+
+// next moves to the next line, breaking the page when needed
+func (l *invoiceLayout) next(table bool) {
+	l.y -= lineHeight
+	if l.y < marginBottom {
+		l.newPage()
+		if table {
+			l.tableHeader()
+		}
+	}
+}
+
+func renderInvoice(order *model.Order, number string, company config.InvoiceCompany) *pdf.Document {
+	invoice := order.Invoice
+	l := &invoiceLayout{doc: pdf.New()}
+	l.newPage()
+
+	// company header on the left, invoice details on the right
+	l.page.Text(marginLeft, l.y, pdf.Bold, 16, company.Name)
+	l.page.TextRight(marginRight, l.y, pdf.Bold, 20, "INVOICE")
+	headerY := l.y - 20
+	for _, line := range companyLines(company) {
+		l.page.Text(marginLeft, headerY, pdf.Regular, 9, line)
+		headerY -= 12
+	}
+
+	issued := order.CreatedAt
+	if order.ConfirmedAt != nil {
+		issued = *order.ConfirmedAt
+	}
+	detailsY := l.y - 20
+	for _, detail := range [][2]string{
+		{"Invoice", number},
+		{"Date", issued.Format("2006-01-02")},
+		{"Order", order.OrderID},
+		{"Status", invoice.Status},
+		{"Payment", invoice.PaymentMethod},
+		{"Wallet", invoice.Wallet},
+	} {
+		if detail[1] == "" {
+			continue
+		}
+		l.page.TextRight(columnRate, detailsY, pdf.Bold, 9, detail[0])
+		l.page.TextRight(marginRight, detailsY, pdf.Regular, 9, detail[1])
+		detailsY -= 12
+	}
+	l.y = min(headerY, detailsY) - 2*lineHeight
+
+	l.tableHeader()
+	var subtotal float64
+	rates := map[[2]string]float64{}
+	for _, record := range invoice.Records {
+		l.page.Text(marginLeft, l.y, pdf.Bold, 9, record.SKU)
+		l.next(true)
+		for _, item := range record.Items {
+			l.page.Text(marginLeft+10, l.y, pdf.Regular, 9, truncate(item.Description, columnType-marginLeft-20, 9))
+			l.page.Text(columnType, l.y, pdf.Regular, 9, item.Type)
+			if item.Quantity > 0 {
+				quantity := strconv.Itoa(item.Quantity)
+				if item.Quote > 0 {
+					quantity = fmt.Sprintf("%d x %d", item.Quantity, item.Quote)
+				}
+				l.page.TextRight(columnQuantity, l.y, pdf.Regular, 9, quantity)
+			}
+			if item.Rate != 0 {
+				l.page.TextRight(columnRate, l.y, pdf.Regular, 9, strconv.FormatFloat(item.Rate, 'f', -1, 64))
+				rates[[2]string{item.BaseCurrency, item.TargetCurrency}] = item.Rate
+			}
+			l.page.TextRight(marginRight, l.y, pdf.Regular, 9, money(item.Amount, item.Currency))
+			if item.Type != model.InvoiceItemDiscount && item.Type != model.InvoiceItemFee {
+				subtotal += item.Amount
+			}
+			l.next(true)
+		}
+		for _, currency := range sortedKeys(record.Total) {
+			l.page.TextRight(columnRate, l.y, pdf.Regular, 9, "Record total")
+			l.page.TextRight(marginRight, l.y, pdf.Bold, 9, money(record.Total[currency], currency))
+			l.next(true)
+		}
+		l.y -= 4
+	}
+
+	// totals
+	l.page.Line(columnType, l.y+6, marginRight, l.y+6, 0.5)
+	l.next(false)
+	for _, total := range []struct {
+		label  string
+		amount float64
+		font   pdf.Font
+	}{
+		{"Subtotal", subtotal, pdf.Regular},
+		{"Discounts", invoice.Adjustment(model.InvoiceItemDiscount), pdf.Regular},
+		{"Fees", invoice.Adjustment(model.InvoiceItemFee), pdf.Regular},
+		{"Total", invoice.Total, pdf.Bold},
+	} {
+		l.page.TextRight(columnRate, l.y, total.font, 10, total.label)
+		l.page.TextRight(marginRight, l.y, total.font, 10, money(total.amount, invoice.Wallet))
+		l.next(false)
+	}
+
+	if len(rates) > 0 {
+		l.next(false)
+		l.page.Text(marginLeft, l.y, pdf.Bold, 9, "Exchange rates at order time")
+		l.next(false)
+		pairs := make([][2]string, 0, len(rates))
+		for pair := range rates {
+			pairs = append(pairs, pair)
+		}
+		sort.Slice(pairs, func(i, j int) bool {
+			return pairs[i][0]+pairs[i][1] < pairs[j][0]+pairs[j][1]
+		})
+		for _, pair := range pairs {
+			l.page.Text(marginLeft, l.y, pdf.Regular, 9,
+				fmt.Sprintf("1 %s = %s %s", pair[0], strconv.FormatFloat(rates[pair], 'f', -1, 64), pair[1]))
+			l.next(false)
+		}
+	}
+
+	return footers(l.doc, number)
+}
+
+func footers(doc *pdf.Document, number string) *pdf.Document {
+	pages := doc.Pages()
+	for i, page := range pages {
+		page.Text(marginLeft, 40, pdf.Regular, 8, number)
+		page.TextRight(marginRight, 40, pdf.Regular, 8, fmt.Sprintf("Page %d of %d", i+1, len(pages)))
+	}
+	return doc
+}
+
+func companyLines(company config.InvoiceCompany) []string {
+	lines := append([]string{}, company.Address...)
+	if company.TaxID != "" {
+		lines = append(lines, "Tax ID: "+company.TaxID)
+	}
+	if company.Email != "" {
+		lines = append(lines, company.Email)
+	}
+	if company.Phone != "" {
+		lines = append(lines, company.Phone)
+	}
+	return lines
+}
+
+func money(amount float64, currency string) string {
+	return fmt.Sprintf("%.2f %s", amount, currency)
+}
+
+// truncate shortens s with an ellipsis to fit width
+func truncate(s string, width float64, size float64) string {
+	if pdf.TextWidth(s, size) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && pdf.TextWidth(string(runes)+"...", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
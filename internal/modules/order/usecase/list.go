@@ -0,0 +1,160 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"giftcard/internal/adaptor/trace"
+	"giftcard/internal/modules/order/repository"
+	"giftcard/model"
+	"giftcard/pkg/logger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"time"
+)
+
+// OrderList is one page of orders, Totals is only computed for the first page
+type OrderList struct {
+	Items      []OrderSummary           `json:"items"`
+	NextCursor string                   `json:"nextCursor,omitempty"`
+	Totals     []repository.StatusTotal `json:"totals,omitempty"`
+}
+
+// OrderSummary is an order as the listing shows it, the invoice has an endpoint of its own
+type OrderSummary struct {
+	ID                uint       `json:"id"`
+	OrderID           string     `json:"orderId"`
+	SKU               string     `json:"sku"`
+	ProductType       string     `json:"productType"`
+	Quote             uint       `json:"quote"`
+	Quantity          uint       `json:"quantity"`
+	Status            string     `json:"status"`
+	Total             float64    `json:"total"`
+	Currency          string     `json:"currency"`
+	Country           string     `json:"country,omitempty"`
+	CreatedBy         string     `json:"createdBy,omitempty"`
+	Team              string     `json:"team,omitempty"`
+	ScheduleID        *uint      `json:"scheduleId,omitempty"`
+	ApprovalPolicy    string     `json:"approvalPolicy,omitempty"`
+	RequiredApprovals int        `json:"requiredApprovals,omitempty"`
+	ApprovalStatus    string     `json:"approvalStatus,omitempty"`
+	ExpiresAt         *time.Time `json:"expiresAt,omitempty"`
+	ConfirmedAt       *time.Time `json:"confirmedAt,omitempty"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	UpdatedAt         time.Time  `json:"updatedAt"`
+}
+
+func summaryOf(order model.Order) OrderSummary {
+	return OrderSummary{
+		ID:                order.ID,
+		OrderID:           order.OrderID,
+		SKU:               order.SKU,
+		ProductType:       order.ProductType,
+		Quote:             order.Quote,
+		Quantity:          order.Quantity,
+		Status:            order.Status,
+		Total:             order.Total,
+		Currency:          order.Currency,
+		Country:           order.Country,
+		CreatedBy:         order.CreatedBy,
+		Team:              order.Team,
+		ScheduleID:        order.ScheduleID,
+		ApprovalPolicy:    order.ApprovalPolicy,
+		RequiredApprovals: order.RequiredApprovals,
+		ApprovalStatus:    order.ApprovalStatus,
+		ExpiresAt:         order.ExpiresAt,
+		ConfirmedAt:       order.ConfirmedAt,
+		CreatedAt:         order.CreatedAt,
+		UpdatedAt:         order.UpdatedAt,
+	}
+}
+
+// pageToken is the opaque cursor handed to clients, it remembers the sort it was made for
+type pageToken struct {
+	SortBy string `json:"s"`
+	Desc   bool   `json:"d"`
+	Value  string `json:"v,omitempty"`
+	ID     uint   `json:"i"`
+}
+
+// ListOrders returns one keyset page of the filtered orders
+func (us giftCardOrderUseCase) ListOrders(ctx context.Context, filter repository.OrderFilter, sortBy string, desc bool, cursor string, limit int) (OrderList, error) {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"ListOrdersUseCase",
+		"UseCase")
+	defer span.End()
+
+	uniqueID, _ := ctx.Value("tracer").(string)
+
+	logger := logger.For(ctx, logger.ModuleOrder).With(
+		zap.String("tracer", uniqueID),
+	)
+
+	query := repository.OrderPageQuery{
+		SortBy: sortBy,
+		Desc:   desc,
+		Limit:  limit + 1,
+	}
+	if cursor != "" {
+		token, err := decodePageToken(cursor)
+		if err == nil && (token.SortBy != sortBy || token.Desc != desc) {
+			err = ErrInvalidCursor
+		}
+		if err == nil {
+			_, err = repository.ParseCursorValue(sortBy, token.Value)
+		}
+		if err != nil {
+			span.SetAttributes(attribute.String("error", ErrInvalidCursor.Error()))
+			return OrderList{}, ErrInvalidCursor
+		}
+		query.After = &repository.OrderCursor{Value: token.Value, ID: token.ID}
+	}
+
+	orders, err := us.repo.ListOrders(filter, query)
+	if err != nil {
+		logger.Error("error while list orders from DB",
+			zap.String("error", err.Error()),
+		)
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return OrderList{}, err
+	}
+
+	list := OrderList{Items: make([]OrderSummary, 0, min(len(orders), limit))}
+	if len(orders) > limit {
+		orders = orders[:limit]
+		last := repository.CursorFor(orders[limit-1], sortBy)
+		list.NextCursor = encodePageToken(pageToken{SortBy: sortBy, Desc: desc, Value: last.Value, ID: last.ID})
+	}
+	for _, order := range orders {
+		list.Items = append(list.Items, summaryOf(order))
+	}
+
+	// the totals do not depend on the page, clients keep the ones of the first page
+	if cursor == "" {
+		list.Totals, err = us.repo.CountOrdersByStatus(filter)
+		if err != nil {
+			logger.Error("error while count orders from DB",
+				zap.String("error", err.Error()),
+			)
+			span.SetAttributes(attribute.String("error", err.Error()))
+			return OrderList{}, err
+		}
+	}
+	return list, nil
+}
+
+func encodePageToken(token pageToken) string {
+	b, _ := json.Marshal(token)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodePageToken(cursor string) (pageToken, error) {
+	var token pageToken
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return token, err
+	}
+	err = json.Unmarshal(b, &token)
+	return token, err
+}
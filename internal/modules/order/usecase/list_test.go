@@ -0,0 +1,163 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"giftcard/internal/modules/order/repository"
+	"giftcard/model"
+	"testing"
+)
+
+// fakeListRepository returns its orders for any page and keeps the last query
+type fakeListRepository struct {
+	repository.IOrderRepository
+	orders []model.Order
+	query  repository.OrderPageQuery
+}
+
+func (repo *fakeListRepository) ListOrders(filter repository.OrderFilter, query repository.OrderPageQuery) ([]model.Order, error) {
+	repo.query = query
+	return repo.orders, nil
+}
+
+func (repo *fakeListRepository) CountOrdersByStatus(filter repository.OrderFilter) ([]repository.StatusTotal, error) {
+	return nil, nil
+}
+
+func TestDecodePageToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor string
+		want   pageToken
+		err    bool
+	}{
+		{
+			name:   "round trip",
+			cursor: encodePageToken(pageToken{SortBy: repository.SortTotal, Desc: true, Value: "12.5", ID: 7}),
+			want:   pageToken{SortBy: repository.SortTotal, Desc: true, Value: "12.5", ID: 7},
+		},
+		{
+			name:   "round trip without value",
+			cursor: encodePageToken(pageToken{SortBy: repository.SortID, ID: 3}),
+			want:   pageToken{SortBy: repository.SortID, ID: 3},
+		},
+		{name: "not base64", cursor: "not a cursor!", err: true},
+		{name: "not json", cursor: "bm90IGpzb24", err: true},
+		{name: "padded base64", cursor: "e30=", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodePageToken(tt.cursor)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("decodePageToken() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListOrdersCursor(t *testing.T) {
+	tests := []struct {
+		name   string
+		sortBy string
+		desc   bool
+		cursor string
+		err    error
+	}{
+		{
+			name:   "first page",
+			sortBy: repository.SortTotal,
+		},
+		{
+			name:   "next page",
+			sortBy: repository.SortTotal,
+			desc:   true,
+			cursor: encodePageToken(pageToken{SortBy: repository.SortTotal, Desc: true, Value: "12.5", ID: 7}),
+		},
+		{
+			name:   "garbage",
+			sortBy: repository.SortTotal,
+			cursor: "not a cursor!",
+			err:    ErrInvalidCursor,
+		},
+		{
+			name:   "made for another sort",
+			sortBy: repository.SortCreatedAt,
+			cursor: encodePageToken(pageToken{SortBy: repository.SortTotal, Value: "12.5", ID: 7}),
+			err:    ErrInvalidCursor,
+		},
+		{
+			name:   "made for the other direction",
+			sortBy: repository.SortTotal,
+			cursor: encodePageToken(pageToken{SortBy: repository.SortTotal, Desc: true, Value: "12.5", ID: 7}),
+			err:    ErrInvalidCursor,
+		},
+		{
+			name:   "value of the wrong type",
+			sortBy: repository.SortCreatedAt,
+			cursor: encodePageToken(pageToken{SortBy: repository.SortCreatedAt, Value: "12.5", ID: 7}),
+			err:    ErrInvalidCursor,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeListRepository{}
+			us := giftCardOrderUseCase{repo: repo}
+			_, err := us.ListOrders(context.Background(), repository.OrderFilter{}, tt.sortBy, tt.desc, tt.cursor, 10)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v, got %v", tt.err, err)
+			}
+			if tt.err != nil || tt.cursor == "" {
+				return
+			}
+			if after := repo.query.After; after == nil || after.Value != "12.5" || after.ID != 7 {
+				t.Fatalf("the page does not start after the cursor, got %+v", after)
+			}
+		})
+	}
+}
+
+func TestListOrdersNextCursor(t *testing.T) {
+	orders := make([]model.Order, 3)
+	for i := range orders {
+		orders[i].ID = uint(i + 1)
+		orders[i].Total = float64(10 * (i + 1))
+	}
+	repo := &fakeListRepository{orders: orders}
+	us := giftCardOrderUseCase{repo: repo}
+
+	list, err := us.ListOrders(context.Background(), repository.OrderFilter{}, repository.SortTotal, false, "", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.query.Limit != 3 {
+		t.Fatalf("expected one order more than the page to be read, got a limit of %d", repo.query.Limit)
+	}
+	if len(list.Items) != 2 {
+		t.Fatalf("expected a page of 2, got %d", len(list.Items))
+	}
+	token, err := decodePageToken(list.NextCursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != (pageToken{SortBy: repository.SortTotal, Value: "20", ID: 2}) {
+		t.Fatalf("the next cursor does not point at the last order of the page, got %+v", token)
+	}
+
+	repo.orders = orders[:2]
+	list, err = us.ListOrders(context.Background(), repository.OrderFilter{}, repository.SortTotal, false, "", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.NextCursor != "" {
+		t.Fatalf("expected no next cursor on the last page, got %q", list.NextCursor)
+	}
+}
@@ -0,0 +1,75 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"giftcard/config"
+	"giftcard/internal/adaptor/redis"
+	"giftcard/model"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	defaultLockTTL         = 30 * time.Second
+	defaultLockWaitTimeout = 20 * time.Second
+	// confirmResultTTL keeps the provider confirm response for the confirms that waited on it
+	confirmResultTTL = 5 * time.Minute
+)
+
+func orderLockKey(orderId string) string {
+	return "order_lock:" + orderId
+}
+
+func confirmResultKey(orderId string) string {
+	return "order_confirm_result:" + orderId
+}
+
+// lockOrder takes the lock that serializes the changes of one order. When wait is set a busy
+// lock is waited for, up to the configured timeout, and waited reports it.
+func (us giftCardOrderUseCase) lockOrder(ctx context.Context, orderId string, wait bool) (lock *redis.Lock, waited bool, err error) {
+	lockConfig := config.C().OrderLock
+	ttl := time.Duration(lockConfig.TTL) * time.Second
+	if ttl <= 0 {
+		ttl = defaultLockTTL
+	}
+
+	lock, err = us.redis.TryLock(ctx, orderLockKey(orderId), ttl)
+	if errors.Is(err, redis.ErrLockNotAcquired) && wait {
+		waitTimeout := time.Duration(lockConfig.WaitTimeout) * time.Second
+		if waitTimeout <= 0 {
+			waitTimeout = defaultLockWaitTimeout
+		}
+		waited = true
+		lock, err = us.redis.Lock(ctx, orderLockKey(orderId), ttl, waitTimeout)
+	}
+	if errors.Is(err, redis.ErrLockNotAcquired) {
+		return nil, waited, ErrOrderLocked
+	}
+	return lock, waited, err
+}
+
+// unlockOrder releases the order lock even when the request context is already done,
+// a failure is only logged since the lock expires on its own
+func unlockOrder(lock *redis.Lock, logger *zap.Logger) {
+	if err := lock.Unlock(context.Background()); err != nil {
+		logger.Error("error while release order lock",
+			zap.Int64("token", lock.Token()),
+			zap.String("error", err.Error()),
+		)
+	}
+}
+
+// fenceOrder stamps the order with the fencing token of the lock before it is saved, the save
+// is refused once a newer owner saved the order. A lock that already expired gives up the save
+// right away, another owner may be changing the order.
+func fenceOrder(ctx context.Context, order *model.Order, lock *redis.Lock, logger *zap.Logger) error {
+	if held, err := lock.Held(ctx); err == nil && !held {
+		logger.Warn("order lock expired before the order was saved",
+			zap.Int64("token", lock.Token()),
+		)
+		return ErrLockLost
+	}
+	order.LockFence = lock.Token()
+	return nil
+}
@@ -0,0 +1,204 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"giftcard/internal/adaptor/giftcard"
+	"giftcard/internal/adaptor/redis/redistest"
+	"giftcard/internal/modules/order/repository"
+	"giftcard/model"
+	"testing"
+	"time"
+)
+
+// fakeOrderRepository keeps the orders in memory and records every save
+type fakeOrderRepository struct {
+	repository.IOrderRepository
+	orders map[string]*model.Order
+	items  map[uint][]model.OrderItem
+	saves  []model.Order
+}
+
+func (repo *fakeOrderRepository) GetOrder(orderId string) (*model.Order, error) {
+	order, ok := repo.orders[orderId]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	copied := *order
+	return &copied, nil
+}
+
+// SaveOrderWithEvents refuses a save under an older fence like the repository does
+func (repo *fakeOrderRepository) SaveOrderWithEvents(order *model.Order, events []model.OutboxEvent) error {
+	if stored, ok := repo.orders[order.OrderID]; ok && stored.LockFence > order.LockFence {
+		return repository.ErrLockLost
+	}
+	repo.saves = append(repo.saves, *order)
+	copied := *order
+	repo.orders[order.OrderID] = &copied
+	return nil
+}
+
+func (repo *fakeOrderRepository) ListExpiredOrders(now time.Time, excludedStatuses []string) ([]model.Order, error) {
+	var orders []model.Order
+	for _, order := range repo.orders {
+		orders = append(orders, *order)
+	}
+	return orders, nil
+}
+
+// fakeGiftCard answers every retrieve with the same invoice
+type fakeGiftCard struct {
+	giftcard.IGiftCard
+	status string
+	total  float64
+	wallet string
+}
+
+func (gf *fakeGiftCard) RetrieveOrder(ctx context.Context, orderId string) (map[string]any, error) {
+	return map[string]any{
+		"data": map[string]interface{}{
+			"id":      orderId,
+			"invoice": map[string]interface{}{"status": gf.status, "total": gf.total, "wallet": gf.wallet},
+		},
+	}, nil
+}
+
+func newLockTestUseCase(orders ...model.Order) (giftCardOrderUseCase, *fakeOrderRepository, *redistest.Fake) {
+	repo := &fakeOrderRepository{orders: map[string]*model.Order{}}
+	for i := range orders {
+		repo.orders[orders[i].OrderID] = &orders[i]
+	}
+	store, fake := redistest.NewStore()
+	return giftCardOrderUseCase{repo: repo, gf: &fakeGiftCard{status: "completed"}, redis: store}, repo, fake
+}
+
+func TestGetOrderStatusLocking(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+		setup  func(t *testing.T, us giftCardOrderUseCase, fake *redistest.Fake)
+		saved  bool
+		// locked is set when the lock belongs to someone else than the poll
+		locked bool
+	}{
+		{
+			name:   "free lock saves the provider status",
+			status: "pending",
+			setup:  func(t *testing.T, us giftCardOrderUseCase, fake *redistest.Fake) {},
+			saved:  true,
+		},
+		{
+			name:   "lock held by a confirm only reads",
+			status: "pending",
+			setup: func(t *testing.T, us giftCardOrderUseCase, fake *redistest.Fake) {
+				if _, _, err := us.lockOrder(context.Background(), "order-1", false); err != nil {
+					t.Fatal(err)
+				}
+			},
+			locked: true,
+		},
+		{
+			name:   "lock backend down only reads",
+			status: "pending",
+			setup: func(t *testing.T, us giftCardOrderUseCase, fake *redistest.Fake) {
+				fake.SetDown(true)
+			},
+		},
+		{
+			name:   "canceled order keeps its status",
+			status: model.OrderStatusCanceled,
+			setup:  func(t *testing.T, us giftCardOrderUseCase, fake *redistest.Fake) {},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			us, repo, fake := newLockTestUseCase(model.Order{ID: 1, OrderID: "order-1", Status: tt.status})
+			tt.setup(t, us, fake)
+
+			data, err := us.GetOrderStatus(context.Background(), "order-1")
+			if err != nil {
+				t.Fatalf("expected the provider status, got %v", err)
+			}
+			if data == nil {
+				t.Fatal("expected the provider data")
+			}
+			if saved := len(repo.saves) > 0; saved != tt.saved {
+				t.Fatalf("saved = %v, want %v", saved, tt.saved)
+			}
+			if tt.saved && repo.orders["order-1"].Status != "completed" {
+				t.Fatalf("saved status %q, want completed", repo.orders["order-1"].Status)
+			}
+			fake.SetDown(false)
+			if _, ok := fake.Value(orderLockKey("order-1")); ok != tt.locked {
+				t.Fatalf("lock set = %v after the poll, want %v", ok, tt.locked)
+			}
+		})
+	}
+}
+
+func TestExpireOrdersLocking(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	confirmedAt := time.Now()
+	us, repo, _ := newLockTestUseCase(
+		model.Order{ID: 1, OrderID: "free", Status: "pending", ExpiresAt: &past},
+		model.Order{ID: 2, OrderID: "confirming", Status: "pending", ExpiresAt: &past},
+		model.Order{ID: 3, OrderID: "confirmed", Status: "pending", ExpiresAt: &past, ConfirmedAt: &confirmedAt},
+		model.Order{ID: 4, OrderID: "canceled", Status: model.OrderStatusCanceled, ExpiresAt: &past},
+	)
+	if _, _, err := us.lockOrder(context.Background(), "confirming", false); err != nil {
+		t.Fatal(err)
+	}
+
+	expired, err := us.ExpireOrders(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expired != 1 || len(repo.saves) != 1 || repo.saves[0].OrderID != "free" {
+		t.Fatalf("expected only the free order to expire, got %d expired and saves %+v", expired, repo.saves)
+	}
+
+	// a second replica sweeping the same rows finds them done
+	expired, err = us.ExpireOrders(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expired != 0 || len(repo.saves) != 1 {
+		t.Fatalf("expected the second sweep to expire nothing, got %d", expired)
+	}
+}
+
+func TestDecideOrderLocked(t *testing.T) {
+	us, repo, _ := newLockTestUseCase(model.Order{ID: 1, OrderID: "order-1", Status: "pending", CreatedBy: "alice", ApprovalStatus: model.ApprovalPending, RequiredApprovals: 2})
+	if _, _, err := us.lockOrder(context.Background(), "order-1", false); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := us.ApproveOrder(context.Background(), "order-1", "bob", ""); !errors.Is(err, ErrOrderLocked) {
+		t.Fatalf("expected ErrOrderLocked, got %v", err)
+	}
+	if len(repo.saves) != 0 {
+		t.Fatal("a decision was saved without the order lock")
+	}
+}
+
+func TestGetOrderStatusFencing(t *testing.T) {
+	us, repo, _ := newLockTestUseCase(model.Order{ID: 1, OrderID: "order-1", Status: "pending"})
+	if _, err := us.GetOrderStatus(context.Background(), "order-1"); err != nil {
+		t.Fatal(err)
+	}
+	fence := repo.orders["order-1"].LockFence
+	if fence == 0 {
+		t.Fatal("expected the save to carry the lock token")
+	}
+
+	// an owner whose lock expired saves after a newer owner did
+	repo.orders["order-1"].Status = "pending"
+	repo.orders["order-1"].LockFence = fence + 100
+	if _, err := us.GetOrderStatus(context.Background(), "order-1"); !errors.Is(err, ErrLockLost) {
+		t.Fatalf("expected ErrLockLost, got %v", err)
+	}
+	if len(repo.saves) != 1 || repo.orders["order-1"].Status != "pending" {
+		t.Fatalf("the stale owner overwrote the order, saves %+v", repo.saves)
+	}
+}
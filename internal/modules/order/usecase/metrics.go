@@ -0,0 +1,76 @@
+package usecase
+
+import (
+	"context"
+	"giftcard/internal/adaptor/metrics"
+	"giftcard/internal/modules/order/repository"
+	"giftcard/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+// orderMetricsMaxAge is how long a count of the orders is reported before it is taken again,
+// the count scans the whole table so every scrape must not run it
+const orderMetricsMaxAge = time.Minute
+
+// orderCollector reports the orders by status, counted at most once per orderMetricsMaxAge on
+// a replica when there is one
+type orderCollector struct {
+	repo   repository.IOrderRepository
+	orders *prometheus.Desc
+	amount *prometheus.Desc
+
+	mu      sync.Mutex
+	totals  []repository.StatusTotal
+	countAt time.Time
+}
+
+func RegisterMetrics(registry *prometheus.Registry, repo repository.IOrderRepository) {
+	registry.MustRegister(&orderCollector{
+		repo: repo,
+		orders: prometheus.NewDesc(
+			prometheus.BuildFQName(metrics.Namespace, "orders", "count"),
+			"Number of orders by status.",
+			[]string{"status"}, nil),
+		amount: prometheus.NewDesc(
+			prometheus.BuildFQName(metrics.Namespace, "orders", "amount"),
+			"Sum of the order totals by status and currency.",
+			[]string{"status", "currency"}, nil),
+	})
+}
+
+func (c *orderCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.orders
+	ch <- c.amount
+}
+
+func (c *orderCollector) Collect(ch chan<- prometheus.Metric) {
+	counts := map[string]int64{}
+	for _, total := range c.count() {
+		counts[total.Status] += total.Count
+		ch <- prometheus.MustNewConstMetric(c.amount, prometheus.GaugeValue, total.Total, total.Status, total.Currency)
+	}
+	for status, count := range counts {
+		ch <- prometheus.MustNewConstMetric(c.orders, prometheus.GaugeValue, float64(count), status)
+	}
+}
+
+// count returns the last count of the orders, taken again once it is older than
+// orderMetricsMaxAge. A failed count keeps reporting the previous one.
+func (c *orderCollector) count() []repository.StatusTotal {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Since(c.countAt) < orderMetricsMaxAge {
+		return c.totals
+	}
+	totals, err := c.repo.CountOrdersByStatus(repository.OrderFilter{})
+	if err != nil {
+		logger.For(context.Background(), logger.ModuleOrder).Error("error while count orders for metrics", zap.String("error", err.Error()))
+		return c.totals
+	}
+	c.totals = totals
+	c.countAt = time.Now()
+	return c.totals
+}
@@ -0,0 +1,17 @@
+package usecase
+
+import "context"
+
+type scheduleCtxKey struct{}
+
+// WithSchedule marks the orders created with the returned context as runs of the schedule
+func WithSchedule(ctx context.Context, scheduleID uint) context.Context {
+	return context.WithValue(ctx, scheduleCtxKey{}, scheduleID)
+}
+
+func scheduleFromCtx(ctx context.Context) *uint {
+	if scheduleID, ok := ctx.Value(scheduleCtxKey{}).(uint); ok {
+		return &scheduleID
+	}
+	return nil
+}
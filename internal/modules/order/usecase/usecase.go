@@ -3,32 +3,64 @@ package usecase
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"giftcard/config"
 	"giftcard/internal/adaptor/giftcard"
+	"giftcard/internal/adaptor/redis"
 	"giftcard/internal/adaptor/trace"
+	exchangeRateUseCase "giftcard/internal/modules/exchangerate/usecase"
+	"giftcard/internal/modules/order/events"
 	"giftcard/internal/modules/order/repository"
+	"giftcard/internal/modules/outbox"
 	"giftcard/model"
+	"giftcard/pkg/logger"
+	"giftcard/pkg/utils"
 	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
+	"slices"
 	"time"
 )
 
+const defaultOrphanAfter = 5 * time.Minute
+
+// orderEventData is the payload of the order outbox events
+type orderEventData struct {
+	OrderID        string `json:"orderId"`
+	Status         string `json:"status"`
+	PreviousStatus string `json:"previousStatus"`
+	SKU            string `json:"sku"`
+	ProductType    string `json:"productType"`
+	Quote          uint   `json:"quote"`
+	Quantity       uint   `json:"quantity"`
+	ApprovalStatus string `json:"approvalStatus,omitempty"`
+}
+
 type giftCardOrderUseCase struct {
-	repo repository.IOrderRepository
-	gf   giftcard.IGiftCard
+	repo   repository.IOrderRepository
+	gf     giftcard.IGiftCard
+	events events.IEventBroker
+	rates  exchangeRateUseCase.IExchangeRateUseCase
+	redis  *redis.Store
 }
 
 type GiftCardOrderUseCaseParams struct {
 	fx.In
-	Repo repository.IOrderRepository
-	Gf   *giftcard.GiftCard
+	Repo   repository.IOrderRepository
+	Gf     *giftcard.GiftCard
+	Events events.IEventBroker
+	Rates  exchangeRateUseCase.IExchangeRateUseCase
+	Redis  *redis.Store
 }
 
 func NewOrderUseCase(params GiftCardOrderUseCaseParams) IOrderUseCase {
 	return &giftCardOrderUseCase{
-		repo: params.Repo,
-		gf:   params.Gf,
+		repo:   params.Repo,
+		gf:     params.Gf,
+		events: params.Events,
+		rates:  params.Rates,
+		redis:  params.Redis,
 	}
 }
 
@@ -41,10 +73,23 @@ func (us giftCardOrderUseCase) GetOrderStatus(ctx context.Context, orderId strin
 
 	uniqueID, _ := ctx.Value("tracer").(string)
 
-	logger := zap.L().With(
+	logger := logger.For(ctx, logger.ModuleOrder).With(
 		zap.String("tracer", uniqueID),
 	)
 
+	// a confirm holding the lock saves the order itself, the poll then only reads the provider.
+	// Without the lock backend the poll is read only as well, status reads outlive redis.
+	lock, _, err := us.lockOrder(spannedContext, orderId, false)
+	if err != nil && !errors.Is(err, ErrOrderLocked) {
+		logger.Warn("order lock unavailable, polling without saving",
+			zap.String("error", err.Error()),
+		)
+		span.SetAttributes(attribute.String("lockError", err.Error()))
+	}
+	if lock != nil {
+		defer unlockOrder(lock, logger)
+	}
+
 	order, err := us.repo.GetOrder(orderId)
 	if err != nil {
 		logger.Error("error while get order from DB",
@@ -79,7 +124,20 @@ func (us giftCardOrderUseCase) GetOrderStatus(ctx context.Context, orderId strin
 	if !ok {
 		return nil, err
 	}
-	if err := us.repo.UpdateOrder(order, status); err != nil {
+	// a canceled or expired order keeps its local status whatever the provider still reports
+	if lock == nil || model.LocalStatuses[order.Status] {
+		jsonData, _ := json.Marshal(data)
+		span.SetAttributes(attribute.String("data", string(jsonData)))
+		return data, nil
+	}
+	if orderInvoice := invoiceFromOrderData(data); orderInvoice != nil {
+		order.Invoice = orderInvoice
+	}
+	if err := fenceOrder(spannedContext, order, lock, logger); err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	if err := us.changeStatus(order, status); err != nil {
 		logger.Error("error while update order status from DB",
 			zap.String("error", err.Error()),
 		)
@@ -100,30 +158,29 @@ func (us giftCardOrderUseCase) CreateOrder(ctx context.Context, productList []ma
 
 	uniqueID, _ := ctx.Value("tracer").(string)
 
-	logger := zap.L().With(
+	logger := logger.For(ctx, logger.ModuleOrder).With(
 		zap.String("tracer", uniqueID),
 	)
 
-	data, err := us.gf.CreateOrder(spannedContext, productList)
-	if err != nil {
-		logger.Error("error while processing gift card create order",
-			zap.String("error", err.Error()),
-		)
-		span.SetAttributes(attribute.String("error", err.Error()))
-		return giftcard.OrderResponse{}, err
-	}
-
+	// the order is recorded before the provider call, so a crash in between
+	// leaves a creating order behind instead of a provider order we never saw
 	order := &model.Order{
-		OrderID:     data.Data.ID,
 		SKU:         productList[0]["sku"].(string),
 		ProductType: productList[0]["productType"].(string),
 		Quote:       productList[0]["quote"].(uint),
 		Quantity:    productList[0]["quantity"].(uint),
-		Status:      data.Data.Invoice.Status,
+		Status:      model.OrderStatusCreating,
+		CreatedBy:   utils.PrincipalFromCtx(ctx),
+		Team:        utils.TeamFromCtx(ctx),
+		ScheduleID:  scheduleFromCtx(ctx),
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
-	err = us.repo.InsertOrder(order)
+
+	items := us.orderItems(spannedContext, productList)
+	order.Country = items[0].Country
+
+	err := us.repo.InsertOrder(order, items)
 	if err != nil {
 		logger.Error("error while insert new order from DB",
 			zap.String("error", err.Error()),
@@ -132,6 +189,44 @@ func (us giftCardOrderUseCase) CreateOrder(ctx context.Context, productList []ma
 		return giftcard.OrderResponse{}, err
 	}
 
+	data, err := us.gf.CreateOrder(spannedContext, providerProductList(productList))
+	if err != nil {
+		logger.Error("error while processing gift card create order",
+			zap.String("error", err.Error()),
+		)
+		span.SetAttributes(attribute.String("error", err.Error()))
+
+		// the provider answered, so we know no order exists there
+		var reqErr *giftcard.RequestErr
+		var forbiddenErr *giftcard.ForbiddenErr
+		if errors.As(err, &reqErr) || errors.As(err, &forbiddenErr) {
+			if updateErr := us.repo.UpdateOrder(order, model.OrderStatusCreateFailed); updateErr != nil {
+				logger.Error("error while update order status from DB",
+					zap.String("error", updateErr.Error()),
+				)
+			}
+			return giftcard.OrderResponse{}, err
+		}
+		return giftcard.OrderResponse{}, &UnknownOutcomeError{OrderRef: order.ID, Err: err}
+	}
+
+	order.OrderID = data.Data.ID
+	order.ExpiresAt = expiresAt(data.Data.ExpiresAt)
+	order.Total = data.Data.Invoice.Total
+	order.Currency = data.Data.Invoice.Wallet
+	order.Invoice = invoiceFromResponse(data.Data.Invoice)
+	applyApprovalPolicy(order, items)
+	err = us.changeStatus(order, data.Data.Invoice.Status, model.OrderCreatedEvent)
+	if err != nil {
+		logger.Error("error while insert new order from DB",
+			zap.String("order", data.Data.ID),
+			zap.String("error", err.Error()),
+		)
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return giftcard.OrderResponse{}, err
+	}
+	us.linkExchangeRates(spannedContext, order, data.Data.ExchangeRates)
+
 	jsonData, err := json.Marshal(data)
 	span.SetAttributes(attribute.String("data", string(jsonData)))
 	return data, nil
@@ -145,10 +240,20 @@ func (us giftCardOrderUseCase) ConfirmOrder(ctx context.Context, orderId string)
 
 	uniqueID, _ := ctx.Value("tracer").(string)
 
-	logger := zap.L().With(
+	logger := logger.For(ctx, logger.ModuleOrder).With(
 		zap.String("tracer", uniqueID),
 	)
 
+	lock, waited, err := us.lockOrder(spannedContext, orderId, config.C().OrderLock.Wait)
+	if err != nil {
+		logger.Error("error while lock order",
+			zap.String("error", err.Error()),
+		)
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	defer unlockOrder(lock, logger)
+
 	order, err := us.repo.GetOrder(orderId)
 	if err != nil {
 		logger.Error("error while get order from DB",
@@ -158,11 +263,54 @@ func (us giftCardOrderUseCase) ConfirmOrder(ctx context.Context, orderId string)
 		return nil, err
 	}
 
+	if order.Status == model.OrderStatusCanceled {
+		span.SetAttributes(attribute.String("error", ErrOrderCanceled.Error()))
+		return nil, ErrOrderCanceled
+	}
+
+	// a confirm that waited for a concurrent one answers with its result instead of confirming again
+	if waited && order.ConfirmedAt != nil {
+		var data map[string]any
+		if err := us.redis.Get(spannedContext, confirmResultKey(orderId), &data); err == nil {
+			return data, nil
+		}
+	}
+
+	// the provider rejects expired orders with an opaque error, fail early with a clear one
+	if order.Status == model.OrderStatusExpired || (order.ExpiresAt != nil && time.Now().After(*order.ExpiresAt)) {
+		logger.Error("error while confirm order",
+			zap.String("error", ErrOrderExpired.Error()),
+		)
+		span.SetAttributes(attribute.String("error", ErrOrderExpired.Error()))
+		return nil, ErrOrderExpired
+	}
+
+	switch order.ApprovalStatus {
+	case model.ApprovalPending:
+		logger.Error("error while confirm order",
+			zap.String("error", ErrApprovalPending.Error()),
+		)
+		span.SetAttributes(attribute.String("error", ErrApprovalPending.Error()))
+		return nil, ErrApprovalPending
+	case model.ApprovalRejected:
+		logger.Error("error while confirm order",
+			zap.String("error", ErrOrderRejected.Error()),
+		)
+		span.SetAttributes(attribute.String("error", ErrOrderRejected.Error()))
+		return nil, ErrOrderRejected
+	}
+
 	data, err := us.gf.ConfirmOrder(spannedContext, orderId)
 	if err != nil {
 		logger.Error("error while processing gift card confirm order",
 			zap.String("error", err.Error()),
 		)
+		var unknownErr *giftcard.UnknownOutcomeErr
+		if errors.As(err, &unknownErr) {
+			// the provider may have confirmed it, status polls and the reconciliation pick the
+			// provider status up instead of confirming again
+			logger.Warn("confirm outcome unknown, left to reconciliation", zap.String("order", orderId))
+		}
 		span.SetAttributes(attribute.String("error", err.Error()))
 		return nil, err
 	}
@@ -177,7 +325,13 @@ func (us giftCardOrderUseCase) ConfirmOrder(ctx context.Context, orderId string)
 		return nil, err
 	}
 
-	err = us.repo.UpdateOrder(order, state)
+	confirmedAt := time.Now()
+	order.ConfirmedAt = &confirmedAt
+	if err := fenceOrder(spannedContext, order, lock, logger); err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	err = us.changeStatus(order, state, model.OrderConfirmedEvent)
 	if err != nil {
 		logger.Error("error while update order status from DB",
 			zap.String("error", err.Error()),
@@ -185,8 +339,500 @@ func (us giftCardOrderUseCase) ConfirmOrder(ctx context.Context, orderId string)
 		span.SetAttributes(attribute.String("error", err.Error()))
 		return nil, err
 	}
+	if err := us.redis.Set(spannedContext, confirmResultKey(orderId), data, confirmResultTTL); err != nil {
+		logger.Error("error while cache confirm result",
+			zap.String("error", err.Error()),
+		)
+	}
 
 	jsonData, err := json.Marshal(data)
 	span.SetAttributes(attribute.String("data", string(jsonData)))
 	return data, nil
 }
+
+func (us giftCardOrderUseCase) SubscribeOrderEvents(ctx context.Context, orderId string, lastEventID string) (<-chan events.StatusEvent, error) {
+	span, spannedContext := trace.T.SpanFromContext(
+		ctx,
+		"SubscribeOrderEventsUseCase",
+		"UseCase")
+	defer span.End()
+
+	uniqueID, _ := ctx.Value("tracer").(string)
+
+	logger := logger.For(ctx, logger.ModuleOrder).With(
+		zap.String("tracer", uniqueID),
+	)
+
+	if _, err := us.repo.GetOrder(orderId); err != nil {
+		logger.Error("error while get order from DB",
+			zap.String("error", err.Error()),
+		)
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+
+	eventsChan, err := us.events.Subscribe(spannedContext, orderId, lastEventID)
+	if err != nil {
+		logger.Error("error while subscribe to order events",
+			zap.String("error", err.Error()),
+		)
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	return eventsChan, nil
+}
+
+// MarkOrphanedOrders moves orders stuck in creating status to orphaned, so they
+// can be reconciled with the provider by hand
+func (us giftCardOrderUseCase) MarkOrphanedOrders(ctx context.Context) (int, error) {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"MarkOrphanedOrdersUseCase",
+		"UseCase")
+	defer span.End()
+
+	orphanAfter := time.Duration(config.C().Outbox.OrphanAfter) * time.Second
+	if orphanAfter <= 0 {
+		orphanAfter = defaultOrphanAfter
+	}
+
+	orders, err := us.repo.ListStaleOrders(model.OrderStatusCreating, time.Now().Add(-orphanAfter))
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return 0, err
+	}
+
+	for i := range orders {
+		logger.For(ctx, logger.ModuleOrder).Error("order creation never completed, reconcile it with the provider",
+			zap.Uint("id", orders[i].ID),
+			zap.String("sku", orders[i].SKU),
+			zap.Time("createdAt", orders[i].CreatedAt),
+		)
+		if err := us.changeStatus(&orders[i], model.OrderStatusOrphaned); err != nil {
+			span.SetAttributes(attribute.String("error", err.Error()))
+			return i, err
+		}
+	}
+	return len(orders), nil
+}
+
+// ExpireOrders moves the unconfirmed orders whose provider expiry has passed to expired. Every
+// order is expired under its lock and checked again once locked, so a concurrent confirm or
+// another replica running the sweep wins instead of being overwritten. Nothing is reserved
+// locally for an unconfirmed order, schedule budgets only count confirmed spend and the
+// provider frees its frozen balance on its own expiry, so there is nothing to release here.
+func (us giftCardOrderUseCase) ExpireOrders(ctx context.Context) (int, error) {
+	span, spannedContext := trace.T.SpanFromContext(
+		ctx,
+		"ExpireOrdersUseCase",
+		"UseCase")
+	defer span.End()
+
+	logger := logger.For(ctx, logger.ModuleOrder)
+
+	now := time.Now()
+	orders, err := us.repo.ListExpiredOrders(now, unexpirableStatuses())
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return 0, err
+	}
+
+	expired := 0
+	for i := range orders {
+		ok, err := us.expireOrder(spannedContext, orders[i].OrderID, now, logger)
+		if err != nil {
+			span.SetAttributes(attribute.String("error", err.Error()))
+			return expired, err
+		}
+		if ok {
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// expireOrder expires one order under its lock, a busy lock means a confirm or another sweep
+// owns the order and it is left to them
+func (us giftCardOrderUseCase) expireOrder(ctx context.Context, orderId string, now time.Time, logger *zap.Logger) (bool, error) {
+	lock, _, err := us.lockOrder(ctx, orderId, false)
+	if errors.Is(err, ErrOrderLocked) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer unlockOrder(lock, logger)
+
+	order, err := us.repo.GetOrder(orderId)
+	if err != nil {
+		return false, err
+	}
+	if !expirable(order, now) {
+		return false, nil
+	}
+	if err := fenceOrder(ctx, order, lock, logger); err != nil {
+		return false, err
+	}
+	if err := us.changeStatus(order, model.OrderStatusExpired, model.OrderExpiredEvent); err != nil {
+		return false, err
+	}
+	logger.Info("order expired before confirmation", zap.String("order", order.OrderID))
+	return true, nil
+}
+
+// unexpirableStatuses are the statuses the provider expiry no longer applies to
+func unexpirableStatuses() []string {
+	statuses := []string{
+		model.OrderStatusExpired,
+		model.OrderStatusCanceled,
+		model.OrderStatusCreating,
+		model.OrderStatusCreateFailed,
+		model.OrderStatusOrphaned,
+	}
+	for status := range model.DeliveredStatuses {
+		statuses = append(statuses, status)
+	}
+	for status := range model.FailedStatuses {
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// expirable repeats the ListExpiredOrders filter on an order read under its lock
+func expirable(order *model.Order, now time.Time) bool {
+	if order.ConfirmedAt != nil || order.ExpiresAt == nil || !order.ExpiresAt.Before(now) {
+		return false
+	}
+	return !slices.Contains(unexpirableStatuses(), order.Status)
+}
+
+// SyncOrderStatus moves the order to the status the provider reports, under the order lock and
+// with the same outbox events as any other status change. Orders in a local status keep it.
+func (us giftCardOrderUseCase) SyncOrderStatus(ctx context.Context, orderId string, status string) (*model.Order, error) {
+	span, spannedContext := trace.T.SpanFromContext(
+		ctx,
+		"SyncOrderStatusUseCase",
+		"UseCase")
+	defer span.End()
+
+	uniqueID, _ := ctx.Value("tracer").(string)
+
+	logger := logger.For(ctx, logger.ModuleOrder).With(
+		zap.String("tracer", uniqueID),
+	)
+
+	lock, _, err := us.lockOrder(spannedContext, orderId, false)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	defer unlockOrder(lock, logger)
+
+	order, err := us.repo.GetOrder(orderId)
+	if err != nil {
+		logger.Error("error while get order from DB",
+			zap.String("error", err.Error()),
+		)
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	if model.LocalStatuses[order.Status] {
+		span.SetAttributes(attribute.String("error", ErrLocalStatus.Error()))
+		return nil, ErrLocalStatus
+	}
+	if order.Status == status {
+		return order, nil
+	}
+
+	if err := fenceOrder(spannedContext, order, lock, logger); err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	if err := us.changeStatus(order, status); err != nil {
+		logger.Error("error while update order status from DB",
+			zap.String("error", err.Error()),
+		)
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	return order, nil
+}
+
+// ReconcileOrder attaches a provider order to a local order whose creation never completed
+func (us giftCardOrderUseCase) ReconcileOrder(ctx context.Context, id uint, orderId string) (map[string]any, error) {
+	span, spannedContext := trace.T.SpanFromContext(
+		ctx,
+		"ReconcileOrderUseCase",
+		"UseCase")
+	defer span.End()
+
+	uniqueID, _ := ctx.Value("tracer").(string)
+
+	logger := logger.For(ctx, logger.ModuleOrder).With(
+		zap.String("tracer", uniqueID),
+	)
+
+	order, err := us.repo.GetOrderByID(id)
+	if err != nil {
+		logger.Error("error while get order from DB",
+			zap.String("error", err.Error()),
+		)
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+
+	if order.Status != model.OrderStatusCreating && order.Status != model.OrderStatusOrphaned {
+		span.SetAttributes(attribute.String("error", ErrOrderNotReconcilable.Error()))
+		return nil, ErrOrderNotReconcilable
+	}
+
+	data, err := us.gf.RetrieveOrder(spannedContext, orderId)
+	if err != nil {
+		logger.Error("error while processing gift card retrieve order",
+			zap.String("error", err.Error()),
+		)
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+
+	status := statusFromOrderData(data)
+	if status == "" {
+		span.SetAttributes(attribute.String("error", ErrOrderNotReconcilable.Error()))
+		return nil, ErrOrderNotReconcilable
+	}
+
+	order.OrderID = orderId
+	response, decoded := decodeOrderData(data)
+	if orderInvoice := invoiceFromOrderData(data); orderInvoice != nil {
+		order.Invoice = orderInvoice
+		order.Total = orderInvoice.Total
+		order.Currency = orderInvoice.Wallet
+	}
+	if dataMap, ok := data["data"].(map[string]interface{}); ok {
+		if value, ok := dataMap["expiresAt"].(float64); ok {
+			order.ExpiresAt = expiresAt(int64(value))
+		}
+	}
+	// the order never got its approval policy, CreateOrder did not get past the provider call
+	items, err := us.repo.ListOrderItems(order.ID)
+	if err != nil {
+		logger.Error("error while get order items from DB",
+			zap.String("error", err.Error()),
+		)
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	if len(items) == 0 {
+		items = []model.OrderItem{{
+			OrderRef:    order.ID,
+			SKU:         order.SKU,
+			ProductType: order.ProductType,
+			Quote:       order.Quote,
+			Quantity:    order.Quantity,
+		}}
+	}
+	applyApprovalPolicy(order, items)
+	if err := us.changeStatus(order, status, model.OrderCreatedEvent); err != nil {
+		logger.Error("error while update order status from DB",
+			zap.String("error", err.Error()),
+		)
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	if decoded {
+		us.linkExchangeRates(spannedContext, order, response.Data.ExchangeRates)
+	}
+	return data, nil
+}
+
+// linkExchangeRates records the rates the order was priced with, a failure is only logged
+// since the order itself is already stored
+func (us giftCardOrderUseCase) linkExchangeRates(ctx context.Context, order *model.Order, rates []giftcard.ExchangeRate) {
+	if err := us.rates.LinkOrderRates(ctx, order.ID, rates); err != nil {
+		logger.For(ctx, logger.ModuleOrder).Error("error while link order exchange rates",
+			zap.String("order", order.OrderID),
+			zap.String("error", err.Error()),
+		)
+	}
+}
+
+// changeStatus saves the new status together with the outbox events describing the change
+func (us giftCardOrderUseCase) changeStatus(order *model.Order, newStatus string, lifecycleEvents ...string) error {
+	previousStatus := order.Status
+	order.Status = newStatus
+
+	eventTypes := lifecycleEvents
+	if previousStatus != newStatus {
+		eventTypes = append(eventTypes, model.OrderStatusChangedEvent)
+		switch {
+		case model.DeliveredStatuses[newStatus]:
+			eventTypes = append(eventTypes, model.OrderDeliveredEvent)
+		case model.FailedStatuses[newStatus]:
+			eventTypes = append(eventTypes, model.OrderFailedEvent)
+		}
+	}
+
+	outboxEvents, err := us.orderEvents(order, previousStatus, eventTypes)
+	if err != nil {
+		return err
+	}
+	return us.repo.SaveOrderWithEvents(order, outboxEvents)
+}
+
+// orderEvents builds the outbox events of the given types for the order current state
+func (us giftCardOrderUseCase) orderEvents(order *model.Order, previousStatus string, eventTypes []string) ([]model.OutboxEvent, error) {
+	data := orderEventData{
+		OrderID:        order.OrderID,
+		Status:         order.Status,
+		PreviousStatus: previousStatus,
+		SKU:            order.SKU,
+		ProductType:    order.ProductType,
+		Quote:          order.Quote,
+		Quantity:       order.Quantity,
+		ApprovalStatus: order.ApprovalStatus,
+	}
+
+	outboxEvents := make([]model.OutboxEvent, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		event, err := outbox.NewEvent(eventType, order.OrderID, data)
+		if err != nil {
+			return nil, err
+		}
+		outboxEvents = append(outboxEvents, event)
+	}
+	return outboxEvents, nil
+}
+
+// orderItems describes every line of the order. The product country is only looked up when an
+// approval policy filters on it, a line without a product id or whose lookup failed keeps an
+// empty country.
+func (us giftCardOrderUseCase) orderItems(ctx context.Context, productList []map[string]any) []model.OrderItem {
+	needCountry := policiesNeedCountry()
+	countries := map[string]string{}
+	items := make([]model.OrderItem, 0, len(productList))
+	for _, product := range productList {
+		item := model.OrderItem{}
+		item.ProductID, _ = product["productId"].(string)
+		item.SKU, _ = product["sku"].(string)
+		item.ProductType, _ = product["productType"].(string)
+		item.Quote, _ = product["quote"].(uint)
+		item.Quantity, _ = product["quantity"].(uint)
+		if item.ProductID != "" && needCountry {
+			country, ok := countries[item.ProductID]
+			if !ok {
+				data, err := us.gf.ShopItem(ctx, item.ProductID)
+				if err != nil {
+					logger.For(ctx, logger.ModuleOrder).Warn("error while processing gift card shop item, the product country is unknown",
+						zap.String("productId", item.ProductID),
+						zap.String("error", err.Error()),
+					)
+				} else {
+					country = data.Data.Country
+				}
+				countries[item.ProductID] = country
+			}
+			item.Country = country
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// providerProductList drops the local only product fields before sending the list to the provider
+func providerProductList(productList []map[string]any) []map[string]any {
+	result := make([]map[string]any, 0, len(productList))
+	for _, product := range productList {
+		providerProduct := make(map[string]any, len(product))
+		for key, value := range product {
+			if key == "productId" {
+				continue
+			}
+			providerProduct[key] = value
+		}
+		result = append(result, providerProduct)
+	}
+	return result
+}
+
+// expiresAt converts the provider expiry, sent in seconds or milliseconds since epoch
+func expiresAt(value int64) *time.Time {
+	if value <= 0 {
+		return nil
+	}
+	var t time.Time
+	if value > 1e12 {
+		t = time.UnixMilli(value)
+	} else {
+		t = time.Unix(value, 0)
+	}
+	return &t
+}
+
+// invoiceFromResponse copies the provider invoice into the stored order invoice
+func invoiceFromResponse(invoice giftcard.Invoice) *model.OrderInvoice {
+	result := &model.OrderInvoice{
+		PaymentMethod: invoice.PaymentMethod,
+		Status:        invoice.Status,
+		Total:         invoice.Total,
+		Wallet:        invoice.Wallet,
+	}
+	for _, record := range invoice.Records {
+		invoiceRecord := model.InvoiceRecord{
+			SKU: record.Sku,
+			Total: map[string]float64{
+				"DKK": record.Total.DKK,
+				"EUR": record.Total.EUR,
+			},
+		}
+		for _, item := range record.Items {
+			invoiceRecord.Items = append(invoiceRecord.Items, model.InvoiceItem{
+				Description:    item.Description,
+				Type:           item.Type,
+				Amount:         item.Effect.Amount,
+				Currency:       item.Effect.Currency,
+				BaseCurrency:   item.MetaData.BaseCurrency,
+				TargetCurrency: item.MetaData.TargetCurrency,
+				Rate:           item.MetaData.Rate,
+				Quantity:       item.MetaData.Quantity,
+				Quote:          item.MetaData.Quote,
+			})
+		}
+		result.Records = append(result.Records, invoiceRecord)
+	}
+	return result
+}
+
+// invoiceFromOrderData decodes the invoice of a retrieve order response, nil when it has none
+func invoiceFromOrderData(data map[string]any) *model.OrderInvoice {
+	response, ok := decodeOrderData(data)
+	if !ok || response.Data.Invoice.Status == "" {
+		return nil
+	}
+	return invoiceFromResponse(response.Data.Invoice)
+}
+
+// decodeOrderData converts a retrieve order response to the typed create order response
+func decodeOrderData(data map[string]any) (giftcard.OrderResponse, bool) {
+	var response giftcard.OrderResponse
+	b, err := json.Marshal(data)
+	if err != nil {
+		return response, false
+	}
+	if err := json.Unmarshal(b, &response); err != nil {
+		return response, false
+	}
+	return response, true
+}
+
+func statusFromOrderData(data map[string]any) string {
+	dataMap, ok := data["data"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	invoice, ok := dataMap["invoice"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	status, _ := invoice["status"].(string)
+	return status
+}
@@ -3,10 +3,23 @@ package usecase
 import (
 	"context"
 	"giftcard/internal/adaptor/giftcard"
+	"giftcard/internal/modules/order/events"
+	"giftcard/internal/modules/order/repository"
+	"giftcard/model"
 )
 
 type IOrderUseCase interface {
 	GetOrderStatus(ctx context.Context, orderId string) (map[string]any, error)
 	CreateOrder(ctx context.Context, productList []map[string]any) (giftcard.OrderResponse, error)
 	ConfirmOrder(ctx context.Context, orderId string) (map[string]any, error)
+	SubscribeOrderEvents(ctx context.Context, orderId string, lastEventID string) (<-chan events.StatusEvent, error)
+	MarkOrphanedOrders(ctx context.Context) (int, error)
+	ExpireOrders(ctx context.Context) (int, error)
+	SyncOrderStatus(ctx context.Context, orderId string, status string) (*model.Order, error)
+	ReconcileOrder(ctx context.Context, id uint, orderId string) (map[string]any, error)
+	ListOrders(ctx context.Context, filter repository.OrderFilter, sortBy string, desc bool, cursor string, limit int) (OrderList, error)
+	ApproveOrder(ctx context.Context, orderId string, principal string, comment string) (*model.Order, []model.OrderApproval, error)
+	RejectOrder(ctx context.Context, orderId string, principal string, comment string) (*model.Order, []model.OrderApproval, error)
+	CancelOrder(ctx context.Context, orderId string, reason string) (*model.Order, error)
+	InvoicePDF(ctx context.Context, orderId string, regenerate bool) (*model.InvoiceDocument, error)
 }
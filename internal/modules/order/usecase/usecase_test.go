@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"giftcard/config"
+	"giftcard/internal/adaptor/giftcard"
+	"giftcard/internal/modules/exchangerate/usecase"
+	"giftcard/model"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func (repo *fakeOrderRepository) GetOrderByID(id uint) (*model.Order, error) {
+	for _, order := range repo.orders {
+		if order.ID == id {
+			copied := *order
+			return &copied, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func (repo *fakeOrderRepository) ListOrderItems(orderRef uint) ([]model.OrderItem, error) {
+	return repo.items[orderRef], nil
+}
+
+// fakeRates accepts every rate link
+type fakeRates struct {
+	usecase.IExchangeRateUseCase
+}
+
+func (fakeRates) LinkOrderRates(ctx context.Context, orderRef uint, rates []giftcard.ExchangeRate) error {
+	return nil
+}
+
+const testConfig = `
+service:
+  client_id: "id"
+  client_secret: "secret"
+postgres:
+  username: "root"
+  schema: "gift_card_db"
+tracer:
+  enabled: false
+logstash:
+  endpoint: "localhost:5000"
+grpc:
+  auth_disabled: true
+`
+
+// loadConfig applies content over the settings every config needs until the test ends
+func loadConfig(t *testing.T, content string) {
+	load := func(content string) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(path, []byte(testConfig+content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := config.Load(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	load(content)
+	t.Cleanup(func() { load("") })
+}
+
+func TestReconcileOrderApproval(t *testing.T) {
+	loadConfig(t, `
+approval:
+  enabled: true
+  policies:
+    - name: "large orders"
+      min_amount: 100
+      currency: "EUR"
+      approvers: 1
+`)
+
+	tests := []struct {
+		name     string
+		total    float64
+		items    []model.OrderItem
+		approval string
+	}{
+		{name: "above the threshold", total: 250, items: []model.OrderItem{{ProductType: "game"}}, approval: model.ApprovalPending},
+		{name: "above the threshold without items", total: 250, approval: model.ApprovalPending},
+		{name: "below the threshold", total: 50, items: []model.OrderItem{{ProductType: "game"}}, approval: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := model.Order{ID: 1, Status: model.OrderStatusOrphaned, SKU: "sku", ProductType: "game"}
+			us, repo, _ := newLockTestUseCase(order)
+			us.gf = &fakeGiftCard{status: "pending", total: tt.total, wallet: "EUR"}
+			us.rates = fakeRates{}
+			repo.items = map[uint][]model.OrderItem{1: tt.items}
+
+			if _, err := us.ReconcileOrder(context.Background(), 1, "provider-1"); err != nil {
+				t.Fatal(err)
+			}
+			reconciled, err := repo.GetOrder("provider-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reconciled.ApprovalStatus != tt.approval {
+				t.Fatalf("approval status %q, want %q", reconciled.ApprovalStatus, tt.approval)
+			}
+			if tt.approval != model.ApprovalPending {
+				return
+			}
+			if _, err := us.ConfirmOrder(context.Background(), "provider-1"); !errors.Is(err, ErrApprovalPending) {
+				t.Fatalf("expected the reconciled order to wait for its approval, got %v", err)
+			}
+		})
+	}
+}
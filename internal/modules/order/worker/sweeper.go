@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"context"
+	"giftcard/internal/adaptor/metrics"
+	"giftcard/internal/modules/order/usecase"
+	"giftcard/pkg/logger"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"log"
+	"time"
+)
+
+const (
+	workerName    = "order_sweeper"
+	sweepInterval = time.Minute
+)
+
+// RunSweeper periodically flags orders whose creation never completed and
+// expires the unconfirmed orders whose provider expiry has passed
+func RunSweeper(lc fx.Lifecycle, us usecase.IOrderUseCase, workers *metrics.Workers) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				ticker := time.NewTicker(sweepInterval)
+				defer ticker.Stop()
+				workers.CaughtUp(workerName)
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+					}
+					_, orphanErr := us.MarkOrphanedOrders(ctx)
+					if orphanErr != nil {
+						logger.For(ctx, logger.ModuleOrder).Error("error while mark orphaned orders", zap.String("error", orphanErr.Error()))
+					}
+					_, expireErr := us.ExpireOrders(ctx)
+					if expireErr != nil {
+						logger.For(ctx, logger.ModuleOrder).Error("error while expire orders", zap.String("error", expireErr.Error()))
+					}
+					if orphanErr == nil && expireErr == nil {
+						workers.CaughtUp(workerName)
+					}
+				}
+			}()
+			log.Println("order sweeper started")
+			return nil
+		},
+		OnStop: func(c context.Context) error {
+			cancel()
+			select {
+			case <-done:
+			case <-c.Done():
+			}
+			log.Println("order sweeper stopped")
+			return nil
+		},
+	})
+}
@@ -0,0 +1,290 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"giftcard/internal/adaptor/trace"
+	"giftcard/internal/exceptions"
+	"giftcard/internal/modules/orderimport/usecase"
+	"giftcard/pkg/requester"
+	"giftcard/pkg/responser"
+	"giftcard/pkg/utils"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const maxUploadSize = 10 << 20
+
+type OrderImportHandler struct {
+	us usecase.IOrderImportUseCase
+}
+
+type OrderImportHandlerParams struct {
+	fx.In
+	Us usecase.IOrderImportUseCase
+}
+
+func NewOrderImportHandler(params OrderImportHandlerParams) *OrderImportHandler {
+	return &OrderImportHandler{
+		us: params.Us,
+	}
+}
+
+// CreateImport accepts the csv as a multipart "file" field or as a text/csv body
+func (h *OrderImportHandler) CreateImport(c echo.Context) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		utils.GetRequestCtx(c),
+		"CreateImport[OrderImportDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
+	logger := zap.L().With(
+		zap.String("tracer", uniqueID),
+	)
+
+	chunkSize := 0
+	if value := c.QueryParam("chunkSize"); value != "" {
+		var err error
+		if chunkSize, err = strconv.Atoi(value); err != nil || chunkSize <= 0 {
+			logger.Info("Response to client", zap.Any("error", exceptions.InvalidInput))
+			span.SetAttributes(attribute.String(exceptions.StatusBadRequest, exceptions.InvalidInput))
+			return c.JSON(http.StatusBadRequest, responser.Response{
+				Message: exceptions.InvalidInput,
+				Data:    "",
+				Success: false})
+		}
+	}
+
+	fileName, file, err := uploadedFile(c)
+	if err != nil {
+		logger.Info("Response to client", zap.Any("error", err.Error()))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+		return c.JSON(http.StatusBadRequest, responser.Response{
+			Message: exceptions.InvalidOrderImport,
+			Data:    "",
+			Success: false})
+	}
+	defer file.Close()
+
+	request := requester.Request{
+		ID:          uniqueID,
+		RequestBody: fileName,
+		UserIP:      c.RealIP(),
+		Uri:         c.Path(),
+		Method:      c.Request().Method,
+		Host:        c.Request().Host,
+		Header:      c.Request().Header,
+		Params:      c.QueryParams(),
+	}
+	logger.Info("Request from client", zap.Any("data", request))
+	span.SetAttributes(attribute.String("Request", utils.Marshal(request)))
+
+	ctx := utils.WithPrincipal(context.WithValue(spannedContext, "tracer", uniqueID), utils.GetPrincipal(c))
+	ctx = utils.WithTeam(ctx, utils.GetTeam(c))
+	orderImport, err := h.us.CreateImport(ctx, fileName, io.LimitReader(file, maxUploadSize), chunkSize)
+	if err != nil {
+		var invalidRows *usecase.InvalidRowsError
+		if errors.As(err, &invalidRows) {
+			logger.Info("Response to client", zap.Any("error", err.Error()))
+			span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+			return c.JSON(http.StatusUnprocessableEntity, responser.Response{
+				Message: exceptions.InvalidOrderImport,
+				Data:    invalidRows.Rows,
+				Success: false,
+			})
+		}
+		return internalError(c, logger, span, err)
+	}
+
+	logger.Info("Response to client", zap.Uint("data", orderImport.ID))
+	return c.JSON(http.StatusAccepted, responser.Response{
+		Message: "",
+		Success: true,
+		Data:    orderImport,
+	})
+}
+
+func (h *OrderImportHandler) ListImports(c echo.Context) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		utils.GetRequestCtx(c),
+		"ListImports[OrderImportDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
+	logger := zap.L().With(
+		zap.String("tracer", uniqueID),
+	)
+
+	ctx := context.WithValue(spannedContext, "tracer", uniqueID)
+	imports, err := h.us.ListImports(ctx)
+	if err != nil {
+		return internalError(c, logger, span, err)
+	}
+
+	logger.Info("Response to client", zap.Int("data", len(imports)))
+	return c.JSON(http.StatusOK, responser.Response{
+		Message: "",
+		Success: true,
+		Data:    imports,
+	})
+}
+
+func (h *OrderImportHandler) GetImport(c echo.Context) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		utils.GetRequestCtx(c),
+		"GetImport[OrderImportDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
+	logger := zap.L().With(
+		zap.String("tracer", uniqueID),
+	)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return badRequest(c, logger, span, err)
+	}
+
+	ctx := context.WithValue(spannedContext, "tracer", uniqueID)
+	orderImport, err := h.us.GetImport(ctx, uint(id))
+	if err != nil {
+		return notFoundOrInternalError(c, logger, span, err)
+	}
+
+	logger.Info("Response to client", zap.Uint64("data", id))
+	return c.JSON(http.StatusOK, responser.Response{
+		Message: "",
+		Success: true,
+		Data:    orderImport,
+	})
+}
+
+// ListRows returns the per row results, filtered by the optional status query param
+func (h *OrderImportHandler) ListRows(c echo.Context) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		utils.GetRequestCtx(c),
+		"ListRows[OrderImportDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
+	logger := zap.L().With(
+		zap.String("tracer", uniqueID),
+	)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return badRequest(c, logger, span, err)
+	}
+
+	ctx := context.WithValue(spannedContext, "tracer", uniqueID)
+	rows, err := h.us.ListRows(ctx, uint(id), c.QueryParam("status"))
+	if err != nil {
+		return notFoundOrInternalError(c, logger, span, err)
+	}
+
+	logger.Info("Response to client", zap.Int("data", len(rows)))
+	return c.JSON(http.StatusOK, responser.Response{
+		Message: "",
+		Success: true,
+		Data:    rows,
+	})
+}
+
+func (h *OrderImportHandler) RetryImport(c echo.Context) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		utils.GetRequestCtx(c),
+		"RetryImport[OrderImportDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
+	logger := zap.L().With(
+		zap.String("tracer", uniqueID),
+	)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return badRequest(c, logger, span, err)
+	}
+
+	ctx := context.WithValue(spannedContext, "tracer", uniqueID)
+	orderImport, err := h.us.RetryImport(ctx, uint(id))
+	if err != nil {
+		if errors.Is(err, usecase.ErrImportInProgress) {
+			logger.Info("Response to client", zap.Any("error", err.Error()))
+			span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+			return c.JSON(http.StatusConflict, responser.Response{
+				Message: err.Error(),
+				Data:    "",
+				Success: false,
+			})
+		}
+		return notFoundOrInternalError(c, logger, span, err)
+	}
+
+	logger.Info("Response to client", zap.Uint64("data", id))
+	return c.JSON(http.StatusAccepted, responser.Response{
+		Message: "",
+		Success: true,
+		Data:    orderImport,
+	})
+}
+
+func uploadedFile(c echo.Context) (string, io.ReadCloser, error) {
+	if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return "", nil, err
+		}
+		file, err := header.Open()
+		if err != nil {
+			return "", nil, err
+		}
+		return header.Filename, file, nil
+	}
+	return c.QueryParam("fileName"), c.Request().Body, nil
+}
+
+func badRequest(c echo.Context, logger *zap.Logger, span oteltrace.Span, err error) error {
+	logger.Info("Response to client", zap.Any("error", err.Error()))
+	span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+	return c.JSON(http.StatusBadRequest, responser.Response{
+		Message: exceptions.InvalidInput,
+		Data:    "",
+		Success: false})
+}
+
+func notFoundOrInternalError(c echo.Context, logger *zap.Logger, span oteltrace.Span, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Info("Response to client", zap.Any("error", err.Error()))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, gorm.ErrRecordNotFound.Error()))
+		return c.JSON(http.StatusNotFound, responser.Response{
+			Message: exceptions.OrderImportNotFound,
+			Data:    "",
+			Success: false,
+		})
+	}
+	return internalError(c, logger, span, err)
+}
+
+func internalError(c echo.Context, logger *zap.Logger, span oteltrace.Span, err error) error {
+	logger.Info("Response to client", zap.Any("error", err.Error()))
+	span.SetAttributes(attribute.String(exceptions.InternalServerError, err.Error()))
+	return c.JSON(http.StatusInternalServerError, responser.Response{
+		Message: exceptions.InternalServerError,
+		Data:    "",
+		Success: false,
+	})
+}
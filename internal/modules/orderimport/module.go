@@ -0,0 +1,16 @@
+package orderimport
+
+import (
+	"giftcard/internal/modules/orderimport/delivery/http"
+	"giftcard/internal/modules/orderimport/repository"
+	"giftcard/internal/modules/orderimport/usecase"
+	"giftcard/internal/modules/orderimport/worker"
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module("orderimport",
+	fx.Provide(usecase.NewOrderImportUseCase),
+	fx.Provide(delivery.NewOrderImportHandler),
+	fx.Provide(repository.NewOrderImportRepository),
+	fx.Invoke(worker.RunImportWorker),
+)
@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"giftcard/model"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+const insertBatchSize = 500
+
+type OrderImportRepository struct {
+	db *gorm.DB
+}
+
+type OrderImportRepositoryParams struct {
+	fx.In
+	Db *gorm.DB
+}
+
+func NewOrderImportRepository(params OrderImportRepositoryParams) IOrderImportRepository {
+	return &OrderImportRepository{
+		db: params.Db,
+	}
+}
+
+// InsertImport saves the import and all of its rows in one transaction
+func (repo *OrderImportRepository) InsertImport(orderImport *model.OrderImport, rows []model.OrderImportRow) error {
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(orderImport).Error; err != nil {
+			return err
+		}
+		for i := range rows {
+			rows[i].ImportID = orderImport.ID
+		}
+		return tx.CreateInBatches(&rows, insertBatchSize).Error
+	})
+}
+
+func (repo *OrderImportRepository) GetImport(id uint) (*model.OrderImport, error) {
+	var orderImport model.OrderImport
+	if err := repo.db.First(&orderImport, id).Error; err != nil {
+		return nil, err
+	}
+	return &orderImport, nil
+}
+
+func (repo *OrderImportRepository) ListImports(limit int) ([]model.OrderImport, error) {
+	var imports []model.OrderImport
+	if err := repo.db.Order("id desc").Limit(limit).Find(&imports).Error; err != nil {
+		return nil, err
+	}
+	return imports, nil
+}
+
+func (repo *OrderImportRepository) ListRows(importID uint, status string) ([]model.OrderImportRow, error) {
+	var rows []model.OrderImportRow
+	query := repo.db.Where("import_id = ?", importID).Order("row_number")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// ClaimDueImport locks the oldest pending import, or a processing one whose worker
+// lease ran out, and leases it to the caller
+func (repo *OrderImportRepository) ClaimDueImport(lease time.Duration) (*model.OrderImport, error) {
+	return repo.claim(lease, func(db *gorm.DB) *gorm.DB {
+		return db
+	})
+}
+
+// ClaimImport leases the given import if it is due, it returns nil when another worker holds it
+func (repo *OrderImportRepository) ClaimImport(id uint, lease time.Duration) (*model.OrderImport, error) {
+	return repo.claim(lease, func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ?", id)
+	})
+}
+
+func (repo *OrderImportRepository) claim(lease time.Duration, scope func(db *gorm.DB) *gorm.DB) (*model.OrderImport, error) {
+	var imports []model.OrderImport
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Scopes(scope).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? OR (status = ? AND locked_until < ?)", model.ImportPending, model.ImportProcessing, now).
+			Order("id").
+			Limit(1).
+			Find(&imports).Error; err != nil {
+			return err
+		}
+		if len(imports) == 0 {
+			return nil
+		}
+
+		lockedUntil := now.Add(lease)
+		imports[0].Status = model.ImportProcessing
+		imports[0].LockedUntil = &lockedUntil
+		return tx.Model(&imports[0]).Updates(map[string]any{
+			"status":       model.ImportProcessing,
+			"locked_until": lockedUntil,
+		}).Error
+	})
+	if err != nil || len(imports) == 0 {
+		return nil, err
+	}
+	return &imports[0], nil
+}
+
+// ExtendLease keeps a long running import claimed by the current worker
+func (repo *OrderImportRepository) ExtendLease(id uint, lease time.Duration) error {
+	return repo.db.Model(&model.OrderImport{}).
+		Where("id = ? AND status = ?", id, model.ImportProcessing).
+		Update("locked_until", time.Now().Add(lease)).Error
+}
+
+func (repo *OrderImportRepository) MarkRowsProcessing(ids []uint) error {
+	return repo.db.Model(&model.OrderImportRow{}).
+		Where("id IN ?", ids).
+		Updates(map[string]any{
+			"status":     model.ImportRowProcessing,
+			"attempts":   gorm.Expr("attempts + 1"),
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// MarkInterruptedRowsUnknown flags the rows a previous worker sent to the provider without
+// recording the outcome, resending them could buy the same cards twice
+func (repo *OrderImportRepository) MarkInterruptedRowsUnknown(importID uint, reason string) error {
+	return repo.db.Model(&model.OrderImportRow{}).
+		Where("import_id = ? AND status = ?", importID, model.ImportRowProcessing).
+		Updates(map[string]any{
+			"status":     model.ImportRowUnknown,
+			"error":      reason,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// ResolveUnknownRows settles the unknown rows whose local order was reconciled since, a row
+// succeeds once its order got a provider id and fails when the provider refused the order
+func (repo *OrderImportRepository) ResolveUnknownRows(importID uint) (int64, error) {
+	var resolved int64
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec(`UPDATE "order_import_rows" AS r
+			SET "status" = ?, "order_id" = o."order_id", "error" = '', "updated_at" = ?
+			FROM "orders" AS o
+			WHERE r."order_ref" = o."id" AND r."import_id" = ? AND r."status" = ?
+				AND o."order_id" <> '' AND o."status" <> ?`,
+			model.ImportRowSucceeded, time.Now(), importID, model.ImportRowUnknown, model.OrderStatusCreateFailed)
+		if result.Error != nil {
+			return result.Error
+		}
+		resolved += result.RowsAffected
+
+		result = tx.Exec(`UPDATE "order_import_rows" AS r
+			SET "status" = ?, "updated_at" = ?
+			FROM "orders" AS o
+			WHERE r."order_ref" = o."id" AND r."import_id" = ? AND r."status" = ? AND o."status" = ?`,
+			model.ImportRowFailed, time.Now(), importID, model.ImportRowUnknown, model.OrderStatusCreateFailed)
+		resolved += result.RowsAffected
+		return result.Error
+	})
+	return resolved, err
+}
+
+func (repo *OrderImportRepository) UpdateRows(rows []model.OrderImportRow) error {
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		for i := range rows {
+			if err := tx.Model(&rows[i]).Updates(map[string]any{
+				"status":     rows[i].Status,
+				"order_id":   rows[i].OrderID,
+				"order_ref":  rows[i].OrderRef,
+				"error":      rows[i].Error,
+				"updated_at": time.Now(),
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FinishImport refreshes the row counters and closes the import
+func (repo *OrderImportRepository) FinishImport(id uint) (*model.OrderImport, error) {
+	var counts []struct {
+		Status string
+		Count  int
+	}
+	if err := repo.db.Model(&model.OrderImportRow{}).
+		Select("status, count(*) as count").
+		Where("import_id = ?", id).
+		Group("status").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+
+	succeeded, failed, unknown := 0, 0, 0
+	for _, count := range counts {
+		switch count.Status {
+		case model.ImportRowSucceeded:
+			succeeded = count.Count
+		case model.ImportRowFailed:
+			failed = count.Count
+		case model.ImportRowUnknown:
+			unknown = count.Count
+		}
+	}
+
+	status := model.ImportCompleted
+	if failed > 0 || unknown > 0 {
+		status = model.ImportCompletedWithErrors
+	}
+	finishedAt := time.Now()
+	if err := repo.db.Model(&model.OrderImport{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":         status,
+			"succeeded_rows": succeeded,
+			"failed_rows":    failed,
+			"unknown_rows":   unknown,
+			"locked_until":   nil,
+			"finished_at":    finishedAt,
+		}).Error; err != nil {
+		return nil, err
+	}
+	return repo.GetImport(id)
+}
+
+// RetryFailedRows puts the failed rows of a finished import back in the queue
+func (repo *OrderImportRepository) RetryFailedRows(id uint) (int64, error) {
+	var retried int64
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.OrderImport{}).
+			Where("id = ? AND status IN ?", id, []string{model.ImportCompleted, model.ImportCompletedWithErrors}).
+			Updates(map[string]any{
+				"status":      model.ImportPending,
+				"finished_at": nil,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		result = tx.Model(&model.OrderImportRow{}).
+			Where("import_id = ? AND status = ?", id, model.ImportRowFailed).
+			Updates(map[string]any{
+				"status":     model.ImportRowPending,
+				"error":      "",
+				"updated_at": time.Now(),
+			})
+		retried = result.RowsAffected
+		return result.Error
+	})
+	return retried, err
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"giftcard/model"
+	"time"
+)
+
+type IOrderImportRepository interface {
+	InsertImport(orderImport *model.OrderImport, rows []model.OrderImportRow) error
+	GetImport(id uint) (*model.OrderImport, error)
+	ListImports(limit int) ([]model.OrderImport, error)
+	ListRows(importID uint, status string) ([]model.OrderImportRow, error)
+	ClaimDueImport(lease time.Duration) (*model.OrderImport, error)
+	ClaimImport(id uint, lease time.Duration) (*model.OrderImport, error)
+	ExtendLease(id uint, lease time.Duration) error
+	MarkRowsProcessing(ids []uint) error
+	MarkInterruptedRowsUnknown(importID uint, reason string) error
+	ResolveUnknownRows(importID uint) (int64, error)
+	UpdateRows(rows []model.OrderImportRow) error
+	FinishImport(id uint) (*model.OrderImport, error)
+	RetryFailedRows(id uint) (int64, error)
+}
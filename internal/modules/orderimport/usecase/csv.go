@@ -0,0 +1,197 @@
+package usecase
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"giftcard/internal/adaptor/giftcard"
+	"giftcard/model"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Csv columns, product_id is required to look the sku up in the catalog
+const (
+	columnProductID   = "product_id"
+	columnSKU         = "sku"
+	columnProductType = "product_type"
+	columnQuote       = "quote"
+	columnQuantity    = "quantity"
+)
+
+var requiredColumns = []string{columnProductID, columnSKU, columnProductType, columnQuote, columnQuantity}
+
+// RowError describes why a csv row was refused, Row is the 1-based line in the file
+type RowError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// InvalidRowsError is returned when the file does not pass validation, nothing is imported
+type InvalidRowsError struct {
+	Rows []RowError
+}
+
+func (e *InvalidRowsError) Error() string {
+	return fmt.Sprintf("%d invalid rows in order import", len(e.Rows))
+}
+
+// parseRows reads the csv file, the header row may use product_id or productId style names
+func parseRows(r io.Reader, maxRows int) ([]model.OrderImportRow, []RowError, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, []RowError{{Row: 1, Message: "empty file"}}, nil
+		}
+		return nil, nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[normalizeColumn(name)] = i
+	}
+	var rowErrors []RowError
+	for _, name := range requiredColumns {
+		if _, ok := columns[name]; !ok {
+			rowErrors = append(rowErrors, RowError{Row: 1, Column: name, Message: "missing column"})
+		}
+	}
+	if len(rowErrors) > 0 {
+		return nil, rowErrors, nil
+	}
+
+	var rows []model.OrderImportRow
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rowErrors = append(rowErrors, RowError{Row: line, Message: parseErr.Err.Error()})
+				continue
+			}
+			return nil, nil, err
+		}
+		if len(rows) >= maxRows {
+			return nil, []RowError{{Row: line, Message: fmt.Sprintf("more than %d rows", maxRows)}}, nil
+		}
+
+		row := model.OrderImportRow{
+			RowNumber:   line,
+			ProductID:   strings.TrimSpace(record[columns[columnProductID]]),
+			SKU:         strings.TrimSpace(record[columns[columnSKU]]),
+			ProductType: strings.TrimSpace(record[columns[columnProductType]]),
+			Status:      model.ImportRowPending,
+		}
+		for _, column := range []string{columnProductID, columnSKU, columnProductType} {
+			if strings.TrimSpace(record[columns[column]]) == "" {
+				rowErrors = append(rowErrors, RowError{Row: line, Column: column, Message: "required"})
+			}
+		}
+		quote, err := parsePositive(record[columns[columnQuote]])
+		if err != nil {
+			rowErrors = append(rowErrors, RowError{Row: line, Column: columnQuote, Message: err.Error()})
+		}
+		quantity, err := parsePositive(record[columns[columnQuantity]])
+		if err != nil {
+			rowErrors = append(rowErrors, RowError{Row: line, Column: columnQuantity, Message: err.Error()})
+		}
+		row.Quote = quote
+		row.Quantity = quantity
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 && len(rowErrors) == 0 {
+		rowErrors = append(rowErrors, RowError{Row: 2, Message: "no rows"})
+	}
+	return rows, rowErrors, nil
+}
+
+// validateCatalog checks every row against the provider catalog, each product is fetched once
+func (us orderImportUseCase) validateCatalog(ctx context.Context, rows []model.OrderImportRow) ([]RowError, error) {
+	products := make(map[string]*giftcard.ProductResponse)
+	var rowErrors []RowError
+
+	for _, row := range rows {
+		product, ok := products[row.ProductID]
+		if !ok {
+			data, err := us.shop.GetShopItem(ctx, row.ProductID)
+			if err != nil {
+				var reqErr *giftcard.RequestErr
+				if !errors.As(err, &reqErr) {
+					return nil, err
+				}
+			} else {
+				product = &data
+			}
+			products[row.ProductID] = product
+		}
+
+		if product == nil {
+			rowErrors = append(rowErrors, RowError{Row: row.RowNumber, Column: columnProductID, Message: "unknown product"})
+			continue
+		}
+		if !strings.EqualFold(product.Data.ProductType, row.ProductType) {
+			rowErrors = append(rowErrors, RowError{
+				Row:     row.RowNumber,
+				Column:  columnProductType,
+				Message: fmt.Sprintf("product type is %s", product.Data.ProductType),
+			})
+		}
+		if !hasVariant(product, row.SKU) {
+			rowErrors = append(rowErrors, RowError{Row: row.RowNumber, Column: columnSKU, Message: "unknown sku for product"})
+		}
+	}
+	return rowErrors, nil
+}
+
+func hasVariant(product *giftcard.ProductResponse, sku string) bool {
+	if _, ok := product.Data.Variants[sku]; ok {
+		return true
+	}
+	for _, variant := range product.Data.Variants {
+		if variant.SKU == sku {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeColumn maps "Product Type", "productType" and "product-type" to product_type
+func normalizeColumn(name string) string {
+	name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+	var b strings.Builder
+	var previous rune
+	for _, r := range name {
+		switch {
+		case r == ' ' || r == '-':
+			b.WriteRune('_')
+		case unicode.IsUpper(r):
+			if unicode.IsLower(previous) {
+				b.WriteRune('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+		previous = r
+	}
+	return b.String()
+}
+
+func parsePositive(value string) (uint, error) {
+	n, err := strconv.ParseUint(strings.TrimSpace(value), 10, 32)
+	if err != nil || n == 0 {
+		return 0, errors.New("must be a positive integer")
+	}
+	return uint(n), nil
+}
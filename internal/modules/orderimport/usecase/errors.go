@@ -0,0 +1,10 @@
+package usecase
+
+import (
+	"errors"
+	"giftcard/internal/exceptions"
+)
+
+var (
+	ErrImportInProgress = errors.New(exceptions.OrderImportInProgress)
+)
@@ -0,0 +1,370 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"giftcard/config"
+	"giftcard/internal/adaptor/giftcard"
+	"giftcard/internal/adaptor/trace"
+	orderUseCase "giftcard/internal/modules/order/usecase"
+	"giftcard/internal/modules/orderimport/repository"
+	shopUseCase "giftcard/internal/modules/shop/usecase"
+	"giftcard/model"
+	"giftcard/pkg/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	defaultChunkSize   = 10
+	defaultConcurrency = 2
+	defaultMaxRows     = 5000
+	defaultLease       = 5 * time.Minute
+	importsLimit       = 100
+)
+
+const (
+	interruptedRowError = "worker stopped before the provider answered, check orphaned orders before retrying"
+	unknownRowError     = "provider did not answer, reconcile order %d with /v1/order/reconcile and retry the import: %s"
+)
+
+type orderImportUseCase struct {
+	repo   repository.IOrderImportRepository
+	orders orderUseCase.IOrderUseCase
+	shop   shopUseCase.IShopUseCase
+}
+
+type OrderImportUseCaseParams struct {
+	fx.In
+	Repo   repository.IOrderImportRepository
+	Orders orderUseCase.IOrderUseCase
+	Shop   shopUseCase.IShopUseCase
+}
+
+func NewOrderImportUseCase(params OrderImportUseCaseParams) IOrderImportUseCase {
+	return &orderImportUseCase{
+		repo:   params.Repo,
+		orders: params.Orders,
+		shop:   params.Shop,
+	}
+}
+
+// CreateImport validates the whole file, format first and then against the catalog,
+// and queues it for the import worker. An invalid file returns *InvalidRowsError.
+func (us orderImportUseCase) CreateImport(ctx context.Context, fileName string, file io.Reader, chunkSize int) (*model.OrderImport, error) {
+	span, spannedContext := trace.T.SpanFromContext(
+		ctx,
+		"CreateImportUseCase",
+		"UseCase")
+	defer span.End()
+
+	uniqueID, _ := ctx.Value("tracer").(string)
+
+	logger := zap.L().With(
+		zap.String("tracer", uniqueID),
+	)
+
+	maxRows := config.C().Import.MaxRows
+	if maxRows <= 0 {
+		maxRows = defaultMaxRows
+	}
+	if chunkSize <= 0 {
+		chunkSize = config.C().Import.ChunkSize
+	}
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+
+	rows, rowErrors, err := parseRows(file, maxRows)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	if len(rowErrors) == 0 {
+		rowErrors, err = us.validateCatalog(spannedContext, rows)
+		if err != nil {
+			logger.Error("error while validate order import against catalog",
+				zap.String("error", err.Error()),
+			)
+			span.SetAttributes(attribute.String("error", err.Error()))
+			return nil, err
+		}
+	}
+	if len(rowErrors) > 0 {
+		err := &InvalidRowsError{Rows: rowErrors}
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+
+	orderImport := &model.OrderImport{
+		FileName:  fileName,
+		CreatedBy: utils.PrincipalFromCtx(ctx),
+		Team:      utils.TeamFromCtx(ctx),
+		Status:    model.ImportPending,
+		ChunkSize: chunkSize,
+		TotalRows: len(rows),
+	}
+	if err := us.repo.InsertImport(orderImport, rows); err != nil {
+		logger.Error("error while insert order import from DB",
+			zap.String("error", err.Error()),
+		)
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+
+	logger.Info("order import queued",
+		zap.Uint("import", orderImport.ID),
+		zap.Int("rows", orderImport.TotalRows),
+	)
+	return orderImport, nil
+}
+
+func (us orderImportUseCase) GetImport(ctx context.Context, id uint) (*model.OrderImport, error) {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"GetImportUseCase",
+		"UseCase")
+	defer span.End()
+
+	orderImport, err := us.repo.GetImport(id)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	return orderImport, nil
+}
+
+func (us orderImportUseCase) ListImports(ctx context.Context) ([]model.OrderImport, error) {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"ListImportsUseCase",
+		"UseCase")
+	defer span.End()
+
+	imports, err := us.repo.ListImports(importsLimit)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	return imports, nil
+}
+
+func (us orderImportUseCase) ListRows(ctx context.Context, id uint, status string) ([]model.OrderImportRow, error) {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"ListImportRowsUseCase",
+		"UseCase")
+	defer span.End()
+
+	if _, err := us.repo.GetImport(id); err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	rows, err := us.repo.ListRows(id, status)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	return rows, nil
+}
+
+// RetryImport settles the unknown rows whose order was reconciled and queues the failed rows
+// of a finished import again, rows still unknown are left out
+func (us orderImportUseCase) RetryImport(ctx context.Context, id uint) (*model.OrderImport, error) {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"RetryImportUseCase",
+		"UseCase")
+	defer span.End()
+
+	orderImport, err := us.repo.GetImport(id)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	if orderImport.Status == model.ImportPending || orderImport.Status == model.ImportProcessing {
+		span.SetAttributes(attribute.String("error", ErrImportInProgress.Error()))
+		return nil, ErrImportInProgress
+	}
+
+	resolved, err := us.repo.ResolveUnknownRows(id)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	retried, err := us.repo.RetryFailedRows(id)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	zap.L().Info("order import retry queued", zap.Uint("import", id), zap.Int64("rows", retried), zap.Int64("resolved", resolved))
+	return us.repo.GetImport(id)
+}
+
+// ProcessDue claims one due import and processes it, it returns the number of imports processed
+func (us orderImportUseCase) ProcessDue(ctx context.Context) (int, error) {
+	orderImport, err := us.repo.ClaimDueImport(importLease())
+	if err != nil || orderImport == nil {
+		return 0, err
+	}
+	if _, err := us.ProcessImport(ctx, orderImport, 0); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// RunImport claims the given import and processes it in the caller, the command line
+// uses it so the import does not wait for a server worker
+func (us orderImportUseCase) RunImport(ctx context.Context, id uint, concurrency int) (*model.OrderImport, error) {
+	orderImport, err := us.repo.ClaimImport(id, importLease())
+	if err != nil {
+		return nil, err
+	}
+	if orderImport == nil {
+		return nil, ErrImportInProgress
+	}
+	return us.ProcessImport(ctx, orderImport, concurrency)
+}
+
+// ProcessImport sends the pending rows to the provider, chunkSize rows per provider order,
+// with up to concurrency orders in flight, and closes the import
+func (us orderImportUseCase) ProcessImport(ctx context.Context, orderImport *model.OrderImport, concurrency int) (*model.OrderImport, error) {
+	span, spannedContext := trace.T.SpanFromContext(
+		ctx,
+		"ProcessImportUseCase",
+		"UseCase")
+	defer span.End()
+
+	logger := zap.L().With(
+		zap.Uint("import", orderImport.ID),
+	)
+
+	if concurrency <= 0 {
+		concurrency = config.C().Import.Concurrency
+	}
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	if err := us.repo.MarkInterruptedRowsUnknown(orderImport.ID, interruptedRowError); err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	rows, err := us.repo.ListRows(orderImport.ID, model.ImportRowPending)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+
+	chunkSize := max(orderImport.ChunkSize, 1)
+	orderCtx := utils.WithTeam(utils.WithPrincipal(spannedContext, orderImport.CreatedBy), orderImport.Team)
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var chunkErr error
+	for start := 0; start < len(rows) && ctx.Err() == nil; start += chunkSize {
+		chunk := rows[start:min(start+chunkSize, len(rows))]
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := us.processChunk(orderCtx, orderImport.ID, chunk); err != nil {
+				logger.Error("error while process order import chunk",
+					zap.Int("row", chunk[0].RowNumber),
+					zap.String("error", err.Error()),
+				)
+				mu.Lock()
+				chunkErr = errors.Join(chunkErr, err)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// unprocessed rows stay pending and the expired lease hands them to the next worker
+	if ctx.Err() != nil || chunkErr != nil {
+		err := errors.Join(ctx.Err(), chunkErr)
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+
+	finished, err := us.repo.FinishImport(orderImport.ID)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	logger.Info("order import finished",
+		zap.String("status", finished.Status),
+		zap.Int("succeeded", finished.SucceededRows),
+		zap.Int("failed", finished.FailedRows),
+	)
+	return finished, nil
+}
+
+// processChunk turns the rows into one provider order and records the outcome on each row
+func (us orderImportUseCase) processChunk(ctx context.Context, importID uint, rows []model.OrderImportRow) error {
+	ids := make([]uint, 0, len(rows))
+	productList := make([]map[string]any, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+		productList = append(productList, map[string]any{
+			"productId":   row.ProductID,
+			"sku":         row.SKU,
+			"productType": row.ProductType,
+			"quote":       row.Quote,
+			"quantity":    row.Quantity,
+		})
+	}
+	if err := us.repo.MarkRowsProcessing(ids); err != nil {
+		return err
+	}
+
+	data, orderErr := us.orders.CreateOrder(ctx, productList)
+	var unknownErr *orderUseCase.UnknownOutcomeError
+	for i := range rows {
+		switch {
+		case errors.As(orderErr, &unknownErr):
+			// a retry could buy the same cards twice, the row waits for its order to be reconciled
+			rows[i].Status = model.ImportRowUnknown
+			rows[i].OrderRef = &unknownErr.OrderRef
+			rows[i].Error = fmt.Sprintf(unknownRowError, unknownErr.OrderRef, unknownErr.Err.Error())
+		case orderErr != nil:
+			rows[i].Status = model.ImportRowFailed
+			rows[i].Error = rowErrorMessage(orderErr)
+		default:
+			rows[i].Status = model.ImportRowSucceeded
+			rows[i].OrderID = data.Data.ID
+			rows[i].Error = ""
+		}
+	}
+	if err := us.repo.UpdateRows(rows); err != nil {
+		return err
+	}
+	return us.repo.ExtendLease(importID, importLease())
+}
+
+func rowErrorMessage(err error) string {
+	var reqErr *giftcard.RequestErr
+	if errors.As(err, &reqErr) && reqErr.Response != nil {
+		return utils.Marshal(reqErr.Response)
+	}
+	return err.Error()
+}
+
+func importLease() time.Duration {
+	lease := time.Duration(config.C().Import.Lease) * time.Second
+	if lease <= 0 {
+		lease = defaultLease
+	}
+	return lease
+}
@@ -0,0 +1,18 @@
+package usecase
+
+import (
+	"context"
+	"giftcard/model"
+	"io"
+)
+
+type IOrderImportUseCase interface {
+	CreateImport(ctx context.Context, fileName string, file io.Reader, chunkSize int) (*model.OrderImport, error)
+	GetImport(ctx context.Context, id uint) (*model.OrderImport, error)
+	ListImports(ctx context.Context) ([]model.OrderImport, error)
+	ListRows(ctx context.Context, id uint, status string) ([]model.OrderImportRow, error)
+	RetryImport(ctx context.Context, id uint) (*model.OrderImport, error)
+	ProcessDue(ctx context.Context) (int, error)
+	RunImport(ctx context.Context, id uint, concurrency int) (*model.OrderImport, error)
+	ProcessImport(ctx context.Context, orderImport *model.OrderImport, concurrency int) (*model.OrderImport, error)
+}
@@ -0,0 +1,73 @@
+package worker
+
+import (
+	"context"
+	"giftcard/config"
+	"giftcard/internal/adaptor/metrics"
+	"giftcard/internal/modules/orderimport/usecase"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"log"
+	"time"
+)
+
+const (
+	workerName          = "order_import"
+	defaultPollInterval = 5 * time.Second
+)
+
+// RunImportWorker processes queued order imports for as long as the application runs
+func RunImportWorker(lc fx.Lifecycle, us usecase.IOrderImportUseCase, workers *metrics.Workers) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				run(ctx, us, workers)
+			}()
+			log.Println("order import worker started")
+			return nil
+		},
+		OnStop: func(c context.Context) error {
+			cancel()
+			select {
+			case <-done:
+			case <-c.Done():
+			}
+			log.Println("order import worker stopped")
+			return nil
+		},
+	})
+}
+
+func run(ctx context.Context, us usecase.IOrderImportUseCase, workers *metrics.Workers) {
+	interval := time.Duration(config.C().Import.PollInterval) * time.Second
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	workers.CaughtUp(workerName)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for ctx.Err() == nil {
+			processed, err := us.ProcessDue(ctx)
+			if err != nil {
+				zap.L().Error("error while process order imports", zap.String("error", err.Error()))
+				break
+			}
+			if processed == 0 {
+				workers.CaughtUp(workerName)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package outbox
+
+import (
+	"encoding/json"
+	"giftcard/model"
+	"github.com/google/uuid"
+	"time"
+)
+
+// NewEvent builds a pending outbox event, it must be saved in the same
+// transaction as the change it describes
+func NewEvent(eventType string, aggregateID string, payload any) (model.OutboxEvent, error) {
+	p, err := json.Marshal(payload)
+	if err != nil {
+		return model.OutboxEvent{}, err
+	}
+	return model.OutboxEvent{
+		EventID:       uuid.NewString(),
+		EventType:     eventType,
+		AggregateID:   aggregateID,
+		Payload:       string(p),
+		Status:        model.OutboxPending,
+		NextAttemptAt: time.Now(),
+	}, nil
+}
@@ -0,0 +1,12 @@
+package outbox
+
+import (
+	"giftcard/internal/modules/outbox/relay"
+	"giftcard/internal/modules/outbox/repository"
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module("outbox",
+	fx.Provide(repository.NewOutboxRepository),
+	fx.Invoke(relay.RunRelay),
+)
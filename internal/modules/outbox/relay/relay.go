@@ -0,0 +1,126 @@
+package relay
+
+import (
+	"context"
+	"giftcard/config"
+	"giftcard/internal/adaptor/metrics"
+	"giftcard/internal/modules/outbox/repository"
+	"giftcard/model"
+	"giftcard/pkg/eventbus"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"log"
+	"time"
+)
+
+const (
+	workerName          = "outbox"
+	defaultPollInterval = time.Second
+	defaultBatchSize    = 50
+	lease               = time.Minute
+	maxRetryDelay       = 10 * time.Minute
+)
+
+type RelayParams struct {
+	fx.In
+	Repo    repository.IOutboxRepository
+	Bus     *eventbus.Bus
+	Workers *metrics.Workers
+}
+
+// RunRelay publishes pending outbox events to the event bus at least once
+func RunRelay(lc fx.Lifecycle, params RelayParams) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				run(ctx, params.Repo, params.Bus, params.Workers)
+			}()
+			log.Println("outbox relay started")
+			return nil
+		},
+		OnStop: func(c context.Context) error {
+			cancel()
+			select {
+			case <-done:
+			case <-c.Done():
+			}
+			log.Println("outbox relay stopped")
+			return nil
+		},
+	})
+}
+
+func run(ctx context.Context, repo repository.IOutboxRepository, bus *eventbus.Bus, workers *metrics.Workers) {
+	interval := time.Duration(config.C().Outbox.PollInterval) * time.Second
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	batchSize := config.C().Outbox.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	workers.CaughtUp(workerName)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for ctx.Err() == nil {
+			events, err := repo.ClaimDueEvents(batchSize, lease)
+			if err != nil {
+				zap.L().Error("error while claim outbox events", zap.String("error", err.Error()))
+				break
+			}
+			for i := range events {
+				relay(ctx, repo, bus, &events[i])
+			}
+			if len(events) < batchSize {
+				workers.CaughtUp(workerName)
+				break
+			}
+		}
+	}
+}
+
+func relay(ctx context.Context, repo repository.IOutboxRepository, bus *eventbus.Bus, event *model.OutboxEvent) {
+	logger := zap.L().With(
+		zap.String("event", event.EventID),
+		zap.String("type", event.EventType),
+	)
+
+	err := bus.Publish(ctx, eventbus.Event{
+		ID:          event.EventID,
+		Type:        event.EventType,
+		AggregateID: event.AggregateID,
+		Payload:     []byte(event.Payload),
+		OccurredAt:  event.CreatedAt,
+	})
+	if err != nil {
+		logger.Error("error while publish outbox event", zap.String("error", err.Error()), zap.Int("attempts", event.Attempts))
+		if err := repo.MarkFailed(event, err, time.Now().Add(retryDelay(event.Attempts))); err != nil {
+			logger.Error("error while update outbox event", zap.String("error", err.Error()))
+		}
+		return
+	}
+
+	if err := repo.MarkPublished(event); err != nil {
+		logger.Error("error while update outbox event", zap.String("error", err.Error()))
+	}
+}
+
+func retryDelay(attempts int) time.Duration {
+	delay := time.Second << attempts
+	if delay > maxRetryDelay || delay <= 0 {
+		return maxRetryDelay
+	}
+	return delay
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"giftcard/model"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type OutboxRepository struct {
+	db *gorm.DB
+}
+
+type OutboxRepositoryParams struct {
+	fx.In
+	Db *gorm.DB
+}
+
+func NewOutboxRepository(params OutboxRepositoryParams) IOutboxRepository {
+	return &OutboxRepository{
+		db: params.Db,
+	}
+}
+
+// ClaimDueEvents locks due events in insertion order and pushes their next attempt
+// forward by lease, so other replicas skip them while they are being relayed
+func (repo *OutboxRepository) ClaimDueEvents(limit int, lease time.Duration) ([]model.OutboxEvent, error) {
+	var events []model.OutboxEvent
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", model.OutboxPending, time.Now()).
+			Order("id").
+			Limit(limit).
+			Find(&events).Error; err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, 0, len(events))
+		for _, event := range events {
+			ids = append(ids, event.ID)
+		}
+		return tx.Model(&model.OutboxEvent{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", time.Now().Add(lease)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (repo *OutboxRepository) MarkPublished(event *model.OutboxEvent) error {
+	now := time.Now()
+	event.Status = model.OutboxPublished
+	event.PublishedAt = &now
+	event.LastError = ""
+	return repo.db.Save(event).Error
+}
+
+func (repo *OutboxRepository) MarkFailed(event *model.OutboxEvent, err error, retryAt time.Time) error {
+	event.Attempts++
+	event.LastError = err.Error()
+	event.NextAttemptAt = retryAt
+	return repo.db.Save(event).Error
+}
@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"giftcard/model"
+	"time"
+)
+
+type IOutboxRepository interface {
+	ClaimDueEvents(limit int, lease time.Duration) ([]model.OutboxEvent, error)
+	MarkPublished(event *model.OutboxEvent) error
+	MarkFailed(event *model.OutboxEvent, err error, retryAt time.Time) error
+}
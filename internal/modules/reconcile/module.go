@@ -0,0 +1,14 @@
+package reconcile
+
+import (
+	"giftcard/internal/modules/reconcile/repository"
+	"giftcard/internal/modules/reconcile/usecase"
+	"giftcard/internal/modules/reconcile/worker"
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module("reconcile",
+	fx.Provide(usecase.NewReconcileUseCase),
+	fx.Provide(repository.NewReconcileRepository),
+	fx.Invoke(worker.RunScheduledReconcile),
+)
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"giftcard/model"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+	"time"
+)
+
+type ReconcileRepository struct {
+	db *gorm.DB
+}
+
+type ReconcileRepositoryParams struct {
+	fx.In
+	Db *gorm.DB
+}
+
+func NewReconcileRepository(params ReconcileRepositoryParams) IReconcileRepository {
+	return &ReconcileRepository{
+		db: params.Db,
+	}
+}
+
+// ListOrders pages through the orders created in [from, to) by ascending id
+func (repo *ReconcileRepository) ListOrders(from time.Time, to time.Time, afterID uint, limit int) ([]model.Order, error) {
+	var orders []model.Order
+	if err := repo.db.
+		Where("created_at >= ? AND created_at < ? AND id > ?", from, to, afterID).
+		Order("id").
+		Limit(limit).
+		Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
@@ -0,0 +1,10 @@
+package repository
+
+import (
+	"giftcard/model"
+	"time"
+)
+
+type IReconcileRepository interface {
+	ListOrders(from time.Time, to time.Time, afterID uint, limit int) ([]model.Order, error)
+}
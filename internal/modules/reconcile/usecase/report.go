@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Mismatch kinds found while reconciling
+const (
+	StatusMismatch    = "status_mismatch"
+	TotalMismatch     = "total_mismatch"
+	LineItemMismatch  = "line_item_mismatch"
+	MissingAtProvider = "missing_at_provider"
+	MissingProviderID = "missing_provider_id"
+	StuckOrder        = "stuck_order"
+	ProviderError     = "provider_error"
+	ReportFormatJSON  = "json"
+	ReportFormatCSV   = "csv"
+	reportTimeLayout  = time.RFC3339
+)
+
+type Mismatch struct {
+	LocalID  uint   `json:"localId"`
+	OrderID  string `json:"orderId"`
+	Kind     string `json:"kind"`
+	Field    string `json:"field,omitempty"`
+	Local    string `json:"local,omitempty"`
+	Provider string `json:"provider,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Fixed    bool   `json:"fixed"`
+}
+
+type Report struct {
+	From        time.Time  `json:"from"`
+	To          time.Time  `json:"to"`
+	GeneratedAt time.Time  `json:"generatedAt"`
+	Checked     int        `json:"checked"`
+	Matched     int        `json:"matched"`
+	Fixed       int        `json:"fixed"`
+	Mismatches  []Mismatch `json:"mismatches"`
+}
+
+func WriteJSON(w io.Writer, report Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// WriteCSV writes one row per mismatch, the report summary is not part of the csv
+func WriteCSV(w io.Writer, report Report) error {
+	writer := csv.NewWriter(w)
+	header := []string{"local_id", "order_id", "kind", "field", "local", "provider", "detail", "fixed", "generated_at"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, m := range report.Mismatches {
+		row := []string{
+			strconv.FormatUint(uint64(m.LocalID), 10),
+			m.OrderID,
+			m.Kind,
+			m.Field,
+			m.Local,
+			m.Provider,
+			m.Detail,
+			strconv.FormatBool(m.Fixed),
+			report.GeneratedAt.Format(reportTimeLayout),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// SaveReport writes the report as json and csv into dir and returns the file paths
+func SaveReport(dir string, report Report) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	name := "reconcile-" + report.GeneratedAt.Format("20060102T150405")
+	writers := map[string]func(io.Writer, Report) error{
+		ReportFormatJSON: WriteJSON,
+		ReportFormatCSV:  WriteCSV,
+	}
+
+	var paths []string
+	for _, format := range []string{ReportFormatJSON, ReportFormatCSV} {
+		path := filepath.Join(dir, name+"."+format)
+		file, err := os.Create(path)
+		if err != nil {
+			return paths, err
+		}
+		err = writers[format](file, report)
+		closeErr := file.Close()
+		if err != nil {
+			return paths, err
+		}
+		if closeErr != nil {
+			return paths, closeErr
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
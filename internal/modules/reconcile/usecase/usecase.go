@@ -0,0 +1,205 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"giftcard/config"
+	"giftcard/internal/adaptor/giftcard"
+	"giftcard/internal/adaptor/trace"
+	orderUseCase "giftcard/internal/modules/order/usecase"
+	"giftcard/internal/modules/reconcile/repository"
+	"giftcard/model"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"strconv"
+	"time"
+)
+
+const (
+	pageSize          = 100
+	defaultStuckAfter = 24 * time.Hour
+)
+
+type reconcileUseCase struct {
+	repo   repository.IReconcileRepository
+	gf     giftcard.IGiftCard
+	orders orderUseCase.IOrderUseCase
+}
+
+type ReconcileUseCaseParams struct {
+	fx.In
+	Repo   repository.IReconcileRepository
+	Gf     *giftcard.GiftCard
+	Orders orderUseCase.IOrderUseCase
+}
+
+func NewReconcileUseCase(params ReconcileUseCaseParams) IReconcileUseCase {
+	return &reconcileUseCase{
+		repo:   params.Repo,
+		gf:     params.Gf,
+		orders: params.Orders,
+	}
+}
+
+// Reconcile compares every local order created in [from, to) with the provider
+// and optionally moves mismatching local statuses to the provider status
+func (us reconcileUseCase) Reconcile(ctx context.Context, from time.Time, to time.Time, fix bool) (Report, error) {
+	span, spannedContext := trace.T.SpanFromContext(
+		ctx,
+		"ReconcileUseCase",
+		"UseCase")
+	defer span.End()
+
+	stuckAfter := time.Duration(config.C().Reconcile.StuckAfter) * time.Hour
+	if stuckAfter <= 0 {
+		stuckAfter = defaultStuckAfter
+	}
+
+	report := Report{
+		From:        from,
+		To:          to,
+		GeneratedAt: time.Now(),
+		Mismatches:  []Mismatch{},
+	}
+
+	var afterID uint
+	for {
+		orders, err := us.repo.ListOrders(from, to, afterID, pageSize)
+		if err != nil {
+			span.SetAttributes(attribute.String("error", err.Error()))
+			return report, err
+		}
+
+		for i := range orders {
+			mismatches := us.reconcileOrder(spannedContext, &orders[i], fix, stuckAfter)
+			report.Checked++
+			if len(mismatches) == 0 {
+				report.Matched++
+			}
+			for _, m := range mismatches {
+				if m.Fixed {
+					report.Fixed++
+				}
+			}
+			report.Mismatches = append(report.Mismatches, mismatches...)
+		}
+
+		if len(orders) < pageSize {
+			break
+		}
+		afterID = orders[len(orders)-1].ID
+	}
+
+	span.SetAttributes(
+		attribute.Int("checked", report.Checked),
+		attribute.Int("mismatches", len(report.Mismatches)),
+	)
+	return report, nil
+}
+
+func (us reconcileUseCase) reconcileOrder(ctx context.Context, order *model.Order, fix bool, stuckAfter time.Duration) []Mismatch {
+	mismatch := func(kind string, field string, local string, provider string) Mismatch {
+		return Mismatch{LocalID: order.ID, OrderID: order.OrderID, Kind: kind, Field: field, Local: local, Provider: provider}
+	}
+
+	if order.OrderID == "" {
+		m := mismatch(MissingProviderID, "orderId", order.Status, "")
+		m.Detail = "order has no provider id, attach it with /v1/order/reconcile"
+		return []Mismatch{m}
+	}
+
+	data, err := us.gf.RetrieveOrder(ctx, order.OrderID)
+	if err != nil {
+		var reqErr *giftcard.RequestErr
+		if errors.As(err, &reqErr) {
+			m := mismatch(MissingAtProvider, "orderId", order.OrderID, "")
+			m.Detail = fmt.Sprint(reqErr.Response["message"])
+			return []Mismatch{m}
+		}
+		m := mismatch(ProviderError, "", "", "")
+		m.Detail = err.Error()
+		return []Mismatch{m}
+	}
+
+	var providerOrder giftcard.OrderResponse
+	jsonData, _ := json.Marshal(data)
+	if err := json.Unmarshal(jsonData, &providerOrder); err != nil {
+		m := mismatch(ProviderError, "", "", "")
+		m.Detail = err.Error()
+		return []Mismatch{m}
+	}
+	invoice := providerOrder.Data.Invoice
+
+	var mismatches []Mismatch
+
+	if invoice.Status != "" && invoice.Status != order.Status {
+		m := mismatch(StatusMismatch, "status", order.Status, invoice.Status)
+		switch {
+		case model.LocalStatuses[order.Status]:
+			m.Detail = "local status, the provider status does not replace it"
+		case fix:
+			if fixed, err := us.orders.SyncOrderStatus(ctx, order.OrderID, invoice.Status); err != nil {
+				m.Detail = err.Error()
+			} else {
+				*order = *fixed
+				m.Fixed = true
+			}
+		}
+		mismatches = append(mismatches, m)
+	}
+
+	var record *giftcard.Record
+	for i := range invoice.Records {
+		if invoice.Records[i].Sku == order.SKU {
+			record = &invoice.Records[i]
+			break
+		}
+	}
+	if record == nil {
+		mismatches = append(mismatches, mismatch(LineItemMismatch, "sku", order.SKU, ""))
+	} else {
+		var quote, quantity, faceValue int
+		for _, item := range record.Items {
+			if item.MetaData.Quantity == 0 {
+				continue
+			}
+			quote = item.MetaData.Quote
+			quantity += item.MetaData.Quantity
+			faceValue += item.MetaData.Quote * item.MetaData.Quantity
+		}
+		if quantity != 0 && quantity != int(order.Quantity) {
+			mismatches = append(mismatches, mismatch(LineItemMismatch, "quantity", strconv.Itoa(int(order.Quantity)), strconv.Itoa(quantity)))
+		}
+		if quote != 0 && quote != int(order.Quote) {
+			mismatches = append(mismatches, mismatch(LineItemMismatch, "quote", strconv.Itoa(int(order.Quote)), strconv.Itoa(quote)))
+		}
+		localFaceValue := int(order.Quote * order.Quantity)
+		if faceValue != 0 && faceValue != localFaceValue {
+			mismatches = append(mismatches, mismatch(TotalMismatch, "faceValue", strconv.Itoa(localFaceValue), strconv.Itoa(faceValue)))
+		}
+	}
+
+	currentStatus := order.Status
+	if !finished(currentStatus) && time.Since(order.CreatedAt) > stuckAfter {
+		m := mismatch(StuckOrder, "status", currentStatus, invoice.Status)
+		m.Detail = fmt.Sprintf("not finished after %s", time.Since(order.CreatedAt).Round(time.Minute))
+		mismatches = append(mismatches, m)
+	}
+
+	if len(mismatches) > 0 {
+		zap.L().Info("order mismatch", zap.Uint("id", order.ID), zap.Any("mismatches", mismatches))
+	}
+	return mismatches
+}
+
+// finished reports whether the order reached the end of its lifecycle, here or at the provider
+func finished(status string) bool {
+	switch status {
+	case model.OrderStatusCreateFailed, model.OrderStatusExpired, model.OrderStatusCanceled:
+		return true
+	}
+	return model.DeliveredStatuses[status] || model.FailedStatuses[status]
+}
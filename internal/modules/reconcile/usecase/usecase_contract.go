@@ -0,0 +1,10 @@
+package usecase
+
+import (
+	"context"
+	"time"
+)
+
+type IReconcileUseCase interface {
+	Reconcile(ctx context.Context, from time.Time, to time.Time, fix bool) (Report, error)
+}
@@ -0,0 +1,97 @@
+package worker
+
+import (
+	"context"
+	"giftcard/config"
+	"giftcard/internal/adaptor/metrics"
+	"giftcard/internal/modules/reconcile/usecase"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"log"
+	"time"
+)
+
+const (
+	workerName          = "reconcile"
+	defaultInterval     = 24 * time.Hour
+	defaultLookbackDays = 7
+	defaultReportDir    = "reports"
+)
+
+// RunScheduledReconcile reconciles the recent orders on an interval when enabled in config
+func RunScheduledReconcile(lc fx.Lifecycle, us usecase.IReconcileUseCase, workers *metrics.Workers) {
+	if !config.C().Reconcile.Enabled {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				run(ctx, us, workers)
+			}()
+			log.Println("scheduled reconcile started")
+			return nil
+		},
+		OnStop: func(c context.Context) error {
+			cancel()
+			select {
+			case <-done:
+			case <-c.Done():
+			}
+			log.Println("scheduled reconcile stopped")
+			return nil
+		},
+	})
+}
+
+func run(ctx context.Context, us usecase.IReconcileUseCase, workers *metrics.Workers) {
+	confs := config.C().Reconcile
+	interval := time.Duration(confs.Interval) * time.Hour
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	lookbackDays := confs.LookbackDays
+	if lookbackDays <= 0 {
+		lookbackDays = defaultLookbackDays
+	}
+	reportDir := confs.ReportDir
+	if reportDir == "" {
+		reportDir = defaultReportDir
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	workers.CaughtUp(workerName)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		to := time.Now()
+		from := to.AddDate(0, 0, -lookbackDays)
+		report, err := us.Reconcile(ctx, from, to, confs.AutoFix)
+		if err != nil {
+			zap.L().Error("error while reconcile orders", zap.String("error", err.Error()))
+			continue
+		}
+
+		paths, err := usecase.SaveReport(reportDir, report)
+		if err != nil {
+			zap.L().Error("error while save reconcile report", zap.String("error", err.Error()))
+			continue
+		}
+		zap.L().Info("reconcile report saved",
+			zap.Strings("files", paths),
+			zap.Int("checked", report.Checked),
+			zap.Int("mismatches", len(report.Mismatches)),
+		)
+		workers.CaughtUp(workerName)
+	}
+}
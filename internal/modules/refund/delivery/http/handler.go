@@ -0,0 +1,266 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"giftcard/internal/adaptor/trace"
+	"giftcard/internal/exceptions"
+	"giftcard/internal/modules/refund/usecase"
+	"giftcard/model"
+	"giftcard/pkg/requester"
+	"giftcard/pkg/responser"
+	"giftcard/pkg/utils"
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+)
+
+type createRefundRequestBody struct {
+	OrderId        string  `json:"orderId" validate:"required"`
+	ExpectedAmount float64 `json:"expectedAmount" validate:"required,gt=0"`
+	Note           string  `json:"note" validate:"max=500"`
+}
+
+type receiveRefundRequestBody struct {
+	Amount float64 `json:"amount" validate:"required,gt=0"`
+}
+
+type RefundHandler struct {
+	us usecase.IRefundUseCase
+}
+
+type RefundHandlerParams struct {
+	fx.In
+	Us usecase.IRefundUseCase
+}
+
+func NewRefundHandler(params RefundHandlerParams) *RefundHandler {
+	return &RefundHandler{
+		us: params.Us,
+	}
+}
+
+// CreateRefund records the refund of a partly delivered order, failed orders get theirs automatically
+func (h *RefundHandler) CreateRefund(c echo.Context) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		utils.GetRequestCtx(c),
+		"CreateRefund[RefundDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
+	logger := zap.L().With(
+		zap.String("tracer", uniqueID),
+	)
+
+	var requestBody createRefundRequestBody
+	if err := c.Bind(&requestBody); err != nil {
+		return badRequest(c, logger, span, err)
+	}
+	validate := validator.New()
+	if err := validate.Struct(&requestBody); err != nil {
+		return badRequest(c, logger, span, err)
+	}
+
+	request := requester.Request{
+		ID:          uniqueID,
+		RequestBody: requestBody,
+		UserIP:      c.RealIP(),
+		Uri:         c.Path(),
+		Method:      c.Request().Method,
+		Host:        c.Request().Host,
+		Header:      c.Request().Header,
+		Params:      c.QueryParams(),
+	}
+	logger.Info("Request from client", zap.Any("data", request))
+	span.SetAttributes(attribute.String("Request", utils.Marshal(request)))
+
+	ctx := context.WithValue(spannedContext, "tracer", uniqueID)
+	refund, err := h.us.CreateRefund(ctx, requestBody.OrderId, requestBody.ExpectedAmount, requestBody.Note)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Info("Response to client", zap.Any("error", err.Error()))
+			span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+			return c.JSON(http.StatusNotFound, responser.Response{
+				Message: exceptions.RecordNotFound,
+				Data:    "",
+				Success: false,
+			})
+		}
+		return refundError(c, logger, span, err)
+	}
+
+	logger.Info("Response to client", zap.Uint("data", refund.ID))
+	return c.JSON(http.StatusCreated, responser.Response{
+		Message: "",
+		Success: true,
+		Data:    refund,
+	})
+}
+
+func (h *RefundHandler) ListRefunds(c echo.Context) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		utils.GetRequestCtx(c),
+		"ListRefunds[RefundDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
+	logger := zap.L().With(
+		zap.String("tracer", uniqueID),
+	)
+
+	status := c.QueryParam("status")
+	switch status {
+	case "", model.RefundPending, model.RefundPartiallyReceived, model.RefundReceived:
+	default:
+		return badRequest(c, logger, span, errors.New("invalid status "+status))
+	}
+
+	ctx := context.WithValue(spannedContext, "tracer", uniqueID)
+	refunds, err := h.us.ListRefunds(ctx, status)
+	if err != nil {
+		return internalError(c, logger, span, err)
+	}
+
+	logger.Info("Response to client", zap.Int("data", len(refunds)))
+	return c.JSON(http.StatusOK, responser.Response{
+		Message: "",
+		Success: true,
+		Data:    refunds,
+	})
+}
+
+// OutstandingRefunds reports the open refunds per currency
+func (h *RefundHandler) OutstandingRefunds(c echo.Context) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		utils.GetRequestCtx(c),
+		"OutstandingRefunds[RefundDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
+	logger := zap.L().With(
+		zap.String("tracer", uniqueID),
+	)
+
+	ctx := context.WithValue(spannedContext, "tracer", uniqueID)
+	report, err := h.us.OutstandingReport(ctx)
+	if err != nil {
+		return internalError(c, logger, span, err)
+	}
+
+	logger.Info("Response to client", zap.Int("data", len(report)))
+	return c.JSON(http.StatusOK, responser.Response{
+		Message: "",
+		Success: true,
+		Data:    report,
+	})
+}
+
+// ReceiveRefund records an amount that came back outside the wallet, a bank transfer for example
+func (h *RefundHandler) ReceiveRefund(c echo.Context) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		utils.GetRequestCtx(c),
+		"ReceiveRefund[RefundDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
+	logger := zap.L().With(
+		zap.String("tracer", uniqueID),
+	)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return badRequest(c, logger, span, err)
+	}
+	var requestBody receiveRefundRequestBody
+	if err := c.Bind(&requestBody); err != nil {
+		return badRequest(c, logger, span, err)
+	}
+	validate := validator.New()
+	if err := validate.Struct(&requestBody); err != nil {
+		return badRequest(c, logger, span, err)
+	}
+
+	request := requester.Request{
+		ID:          uniqueID,
+		RequestBody: requestBody,
+		UserIP:      c.RealIP(),
+		Uri:         c.Path(),
+		Method:      c.Request().Method,
+		Host:        c.Request().Host,
+		Header:      c.Request().Header,
+		Params:      c.QueryParams(),
+	}
+	logger.Info("Request from client", zap.Any("data", request))
+	span.SetAttributes(attribute.String("Request", utils.Marshal(request)))
+
+	ctx := context.WithValue(spannedContext, "tracer", uniqueID)
+	refund, err := h.us.ReceiveRefund(ctx, uint(id), requestBody.Amount)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Info("Response to client", zap.Any("error", err.Error()))
+			span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+			return c.JSON(http.StatusNotFound, responser.Response{
+				Message: exceptions.RefundNotFound,
+				Data:    "",
+				Success: false,
+			})
+		}
+		return refundError(c, logger, span, err)
+	}
+
+	logger.Info("Response to client", zap.Uint64("data", id))
+	return c.JSON(http.StatusOK, responser.Response{
+		Message: "",
+		Success: true,
+		Data:    refund,
+	})
+}
+
+// refundError answers 400 for an invalid refund, 409 for a duplicate and 500 otherwise
+func refundError(c echo.Context, logger *zap.Logger, span oteltrace.Span, err error) error {
+	status, message := 0, ""
+	switch {
+	case errors.Is(err, usecase.ErrInvalidRefund):
+		status, message = http.StatusBadRequest, exceptions.InvalidRefund
+	case errors.Is(err, usecase.ErrRefundExists):
+		status, message = http.StatusConflict, exceptions.RefundExists
+	default:
+		return internalError(c, logger, span, err)
+	}
+	logger.Info("Response to client", zap.Any("error", err.Error()))
+	span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+	return c.JSON(status, responser.Response{
+		Message: message,
+		Data:    err.Error(),
+		Success: false,
+	})
+}
+
+func badRequest(c echo.Context, logger *zap.Logger, span oteltrace.Span, err error) error {
+	logger.Info("Response to client", zap.Any("error", err.Error()))
+	span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+	return c.JSON(http.StatusBadRequest, responser.Response{
+		Message: exceptions.InvalidInput,
+		Data:    "",
+		Success: false})
+}
+
+func internalError(c echo.Context, logger *zap.Logger, span oteltrace.Span, err error) error {
+	logger.Info("Response to client", zap.Any("error", err.Error()))
+	span.SetAttributes(attribute.String(exceptions.InternalServerError, err.Error()))
+	return c.JSON(http.StatusInternalServerError, responser.Response{
+		Message: exceptions.InternalServerError,
+		Data:    "",
+		Success: false,
+	})
+}
@@ -0,0 +1,17 @@
+package refund
+
+import (
+	"giftcard/internal/modules/refund/delivery/http"
+	"giftcard/internal/modules/refund/repository"
+	"giftcard/internal/modules/refund/usecase"
+	"giftcard/internal/modules/refund/worker"
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module("refund",
+	fx.Provide(usecase.NewRefundUseCase),
+	fx.Provide(delivery.NewRefundHandler),
+	fx.Provide(repository.NewRefundRepository),
+	fx.Invoke(usecase.SubscribeOrderEvents),
+	fx.Invoke(worker.RunRefundWorker),
+)
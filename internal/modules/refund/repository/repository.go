@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"giftcard/internal/adaptor/postgres"
+	"giftcard/model"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+var outstandingStatuses = []string{model.RefundPending, model.RefundPartiallyReceived}
+
+type RefundRepository struct {
+	db      *gorm.DB
+	cluster *postgres.Cluster
+}
+
+type RefundRepositoryParams struct {
+	fx.In
+	Db      *gorm.DB
+	Cluster *postgres.Cluster
+}
+
+func NewRefundRepository(params RefundRepositoryParams) IRefundRepository {
+	return &RefundRepository{
+		db:      params.Db,
+		cluster: params.Cluster,
+	}
+}
+
+func (repo *RefundRepository) GetOrder(orderId string) (*model.Order, error) {
+	var order model.Order
+	if err := repo.db.Where("order_id = ?", orderId).First(&order).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// InsertRefund stores the refund unless the order already has one for the same reason,
+// it reports whether a row was created
+func (repo *RefundRepository) InsertRefund(refund *model.OrderRefund) (bool, error) {
+	result := repo.db.Clauses(clause.OnConflict{DoNothing: true}).Create(refund)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (repo *RefundRepository) GetRefund(id uint) (*model.OrderRefund, error) {
+	var refund model.OrderRefund
+	if err := repo.db.First(&refund, id).Error; err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+func (repo *RefundRepository) ListRefunds(status string) ([]model.OrderRefund, error) {
+	query := repo.cluster.Reader().Order("id desc")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var refunds []model.OrderRefund
+	if err := query.Find(&refunds).Error; err != nil {
+		return nil, err
+	}
+	return refunds, nil
+}
+
+func (repo *RefundRepository) UpdateRefund(refund *model.OrderRefund) error {
+	return repo.db.Save(refund).Error
+}
+
+func (repo *RefundRepository) OutstandingByCurrency() ([]model.OutstandingRefunds, error) {
+	var report []model.OutstandingRefunds
+	if err := repo.cluster.Reader().Model(&model.OrderRefund{}).
+		Select("currency, count(*) AS count, sum(expected_amount) AS expected, sum(received_amount) AS received, "+
+			"sum(expected_amount - received_amount) AS outstanding").
+		Where("status IN ?", outstandingStatuses).
+		Group("currency").
+		Order("currency").
+		Scan(&report).Error; err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (repo *RefundRepository) OutstandingCurrencies() ([]string, error) {
+	var currencies []string
+	if err := repo.db.Model(&model.OrderRefund{}).
+		Distinct("currency").
+		Where("status IN ?", outstandingStatuses).
+		Pluck("currency", &currencies).Error; err != nil {
+		return nil, err
+	}
+	return currencies, nil
+}
+
+// OutstandingRefunds returns the open refunds of the currency created before the given time, oldest first
+func (repo *RefundRepository) OutstandingRefunds(currency string, createdBefore time.Time) ([]model.OrderRefund, error) {
+	var refunds []model.OrderRefund
+	if err := repo.db.
+		Where("currency = ? AND status IN ? AND created_at < ?", currency, outstandingStatuses, createdBefore).
+		Order("created_at, id").
+		Find(&refunds).Error; err != nil {
+		return nil, err
+	}
+	return refunds, nil
+}
+
+// GetCursor returns the last matched wallet snapshot of the currency, zero before the first match
+func (repo *RefundRepository) GetCursor(currency string) (uint, error) {
+	var cursors []model.RefundLedgerCursor
+	if err := repo.db.Where("currency = ?", currency).Limit(1).Find(&cursors).Error; err != nil {
+		return 0, err
+	}
+	if len(cursors) == 0 {
+		return 0, nil
+	}
+	return cursors[0].WalletID, nil
+}
+
+// ListWalletSnapshots returns the wallet snapshots of the currency from the given id on, in insert order
+func (repo *RefundRepository) ListWalletSnapshots(currency string, fromID uint, limit int) ([]model.Wallet, error) {
+	var wallets []model.Wallet
+	if err := repo.db.
+		Where("currency = ? AND id >= ?", currency, fromID).
+		Order("id").
+		Limit(limit).
+		Find(&wallets).Error; err != nil {
+		return nil, err
+	}
+	return wallets, nil
+}
+
+// AdvanceCursor saves the matched refunds and moves the cursor of the currency from one snapshot
+// to the next in one transaction. It reports false and saves nothing when another replica
+// moved the cursor first.
+func (repo *RefundRepository) AdvanceCursor(currency string, from, to uint, refunds []model.OrderRefund) (bool, error) {
+	advanced := false
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&model.RefundLedgerCursor{Currency: currency}).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&model.RefundLedgerCursor{}).
+			Where("currency = ? AND wallet_id = ?", currency, from).
+			Update("wallet_id", to)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		for i := range refunds {
+			if err := tx.Save(&refunds[i]).Error; err != nil {
+				return err
+			}
+		}
+		advanced = true
+		return nil
+	})
+	return advanced, err
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"giftcard/model"
+	"time"
+)
+
+type IRefundRepository interface {
+	GetOrder(orderId string) (*model.Order, error)
+	InsertRefund(refund *model.OrderRefund) (bool, error)
+	GetRefund(id uint) (*model.OrderRefund, error)
+	ListRefunds(status string) ([]model.OrderRefund, error)
+	UpdateRefund(refund *model.OrderRefund) error
+	OutstandingByCurrency() ([]model.OutstandingRefunds, error)
+	OutstandingCurrencies() ([]string, error)
+	OutstandingRefunds(currency string, createdBefore time.Time) ([]model.OrderRefund, error)
+	GetCursor(currency string) (uint, error)
+	ListWalletSnapshots(currency string, fromID uint, limit int) ([]model.Wallet, error)
+	AdvanceCursor(currency string, from, to uint, refunds []model.OrderRefund) (bool, error)
+}
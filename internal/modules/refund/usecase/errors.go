@@ -0,0 +1,11 @@
+package usecase
+
+import (
+	"errors"
+	"giftcard/internal/exceptions"
+)
+
+var (
+	ErrInvalidRefund = errors.New(exceptions.InvalidRefund)
+	ErrRefundExists  = errors.New(exceptions.RefundExists)
+)
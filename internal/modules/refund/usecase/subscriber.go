@@ -0,0 +1,24 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"giftcard/model"
+	"giftcard/pkg/eventbus"
+)
+
+// SubscribeOrderEvents opens a refund for every confirmed order the provider failed
+func SubscribeOrderEvents(bus *eventbus.Bus, us IRefundUseCase) {
+	bus.Subscribe(model.OrderFailedEvent, func(ctx context.Context, event eventbus.Event) error {
+		var data struct {
+			OrderID string `json:"orderId"`
+		}
+		if err := json.Unmarshal(event.Payload, &data); err != nil {
+			return err
+		}
+		if data.OrderID == "" {
+			data.OrderID = event.AggregateID
+		}
+		return us.OrderFailed(ctx, data.OrderID)
+	})
+}
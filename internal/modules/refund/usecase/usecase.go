@@ -0,0 +1,280 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"giftcard/config"
+	"giftcard/internal/adaptor/trace"
+	"giftcard/internal/modules/refund/repository"
+	"giftcard/model"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	snapshotBatch         = 500
+	defaultMatchTolerance = 0.01
+)
+
+type refundUseCase struct {
+	repo repository.IRefundRepository
+}
+
+type RefundUseCaseParams struct {
+	fx.In
+	Repo repository.IRefundRepository
+}
+
+func NewRefundUseCase(params RefundUseCaseParams) IRefundUseCase {
+	return &refundUseCase{
+		repo: params.Repo,
+	}
+}
+
+// OrderFailed opens a refund of the whole order total. Unconfirmed orders were never charged
+// and get none, a replayed event finds the refund already there.
+func (us refundUseCase) OrderFailed(ctx context.Context, orderId string) error {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"OrderFailedUseCase",
+		"UseCase")
+	defer span.End()
+
+	order, err := us.repo.GetOrder(orderId)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return err
+	}
+	if order.ConfirmedAt == nil || order.Total <= 0 {
+		return nil
+	}
+
+	refund := newRefund(order, model.RefundReasonFailed, order.Total)
+	created, err := us.repo.InsertRefund(refund)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return err
+	}
+	if created {
+		zap.L().Info("refund expected for failed order",
+			zap.String("order", order.OrderID),
+			zap.Float64("amount", refund.ExpectedAmount),
+			zap.String("currency", refund.Currency),
+		)
+	}
+	return nil
+}
+
+// CreateRefund records the refund of a partly delivered order, the expected amount is what
+// the provider owes for the missing cards
+func (us refundUseCase) CreateRefund(ctx context.Context, orderId string, expectedAmount float64, note string) (*model.OrderRefund, error) {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"CreateRefundUseCase",
+		"UseCase")
+	defer span.End()
+
+	order, err := us.repo.GetOrder(orderId)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	if order.ConfirmedAt == nil {
+		span.SetAttributes(attribute.String("error", "order not confirmed"))
+		return nil, fmt.Errorf("%w: order was never confirmed", ErrInvalidRefund)
+	}
+	if order.Total > 0 && expectedAmount > order.Total {
+		span.SetAttributes(attribute.String("error", "amount over order total"))
+		return nil, fmt.Errorf("%w: expected amount over the order total %.2f", ErrInvalidRefund, order.Total)
+	}
+
+	refund := newRefund(order, model.RefundReasonPartialDelivery, expectedAmount)
+	refund.Note = note
+	created, err := us.repo.InsertRefund(refund)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	if !created {
+		span.SetAttributes(attribute.String("error", ErrRefundExists.Error()))
+		return nil, ErrRefundExists
+	}
+	return refund, nil
+}
+
+// ReceiveRefund records an amount received outside the wallet ledger, a bank transfer for example
+func (us refundUseCase) ReceiveRefund(ctx context.Context, id uint, amount float64) (*model.OrderRefund, error) {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"ReceiveRefundUseCase",
+		"UseCase")
+	defer span.End()
+
+	refund, err := us.repo.GetRefund(id)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	if refund.Status == model.RefundReceived {
+		span.SetAttributes(attribute.String("error", "refund already received"))
+		return nil, fmt.Errorf("%w: refund already received", ErrInvalidRefund)
+	}
+	if amount > refund.Outstanding()+tolerance() {
+		span.SetAttributes(attribute.String("error", "amount over outstanding"))
+		return nil, fmt.Errorf("%w: amount over the outstanding %.2f", ErrInvalidRefund, refund.Outstanding())
+	}
+
+	receive(refund, amount, time.Now())
+	if err := us.repo.UpdateRefund(refund); err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	return refund, nil
+}
+
+func (us refundUseCase) ListRefunds(ctx context.Context, status string) ([]model.OrderRefund, error) {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"ListRefundsUseCase",
+		"UseCase")
+	defer span.End()
+
+	refunds, err := us.repo.ListRefunds(status)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	return refunds, nil
+}
+
+func (us refundUseCase) OutstandingReport(ctx context.Context) ([]model.OutstandingRefunds, error) {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"OutstandingRefundsUseCase",
+		"UseCase")
+	defer span.End()
+
+	report, err := us.repo.OutstandingByCurrency()
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	return report, nil
+}
+
+// ProcessLedger walks the wallet snapshots of every currency with open refunds and matches
+// each balance increase to the oldest refund created before it whose outstanding amount equals
+// the increase. Increases that match nothing are top ups and are left alone. It returns the
+// number of snapshots walked.
+func (us refundUseCase) ProcessLedger(ctx context.Context) (int, error) {
+	currencies, err := us.repo.OutstandingCurrencies()
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for _, currency := range currencies {
+		if ctx.Err() != nil {
+			break
+		}
+		walked, err := us.processCurrency(currency)
+		processed += walked
+		if err != nil {
+			return processed, err
+		}
+	}
+	return processed, nil
+}
+
+func (us refundUseCase) processCurrency(currency string) (int, error) {
+	cursor, err := us.repo.GetCursor(currency)
+	if err != nil {
+		return 0, err
+	}
+	snapshots, err := us.repo.ListWalletSnapshots(currency, cursor, snapshotBatch)
+	if err != nil || len(snapshots) < 2 {
+		return 0, err
+	}
+	// a zero cursor has no matched snapshot yet, the first one becomes the baseline
+	previous := snapshots[0]
+	from := cursor
+
+	var matched []model.OrderRefund
+	for _, snapshot := range snapshots[1:] {
+		credit := snapshot.Balance - previous.Balance
+		previous = snapshot
+		if credit <= tolerance() {
+			continue
+		}
+		refunds, err := us.repo.OutstandingRefunds(currency, snapshot.CreatedAt)
+		if err != nil {
+			return 0, err
+		}
+		for i := range refunds {
+			if !matches(&refunds[i], credit, matched) {
+				continue
+			}
+			receive(&refunds[i], credit, snapshot.CreatedAt)
+			matched = append(matched, refunds[i])
+			zap.L().Info("refund received in wallet",
+				zap.String("order", refunds[i].OrderID),
+				zap.Float64("amount", credit),
+				zap.String("currency", currency),
+				zap.Uint("wallet", snapshot.ID),
+			)
+			break
+		}
+	}
+
+	last := snapshots[len(snapshots)-1].ID
+	advanced, err := us.repo.AdvanceCursor(currency, from, last, matched)
+	if err != nil || !advanced {
+		return 0, err
+	}
+	return len(snapshots) - 1, nil
+}
+
+// matches reports whether the credit pays the outstanding amount of the refund, a refund
+// already matched earlier in the same batch is skipped
+func matches(refund *model.OrderRefund, credit float64, matched []model.OrderRefund) bool {
+	for _, m := range matched {
+		if m.ID == refund.ID {
+			return false
+		}
+	}
+	diff := refund.Outstanding() - credit
+	return diff <= tolerance() && diff >= -tolerance()
+}
+
+func receive(refund *model.OrderRefund, amount float64, at time.Time) {
+	refund.ReceivedAmount += amount
+	refund.ReceivedAt = &at
+	refund.Status = model.RefundPartiallyReceived
+	if refund.Outstanding() <= tolerance() {
+		refund.Status = model.RefundReceived
+	}
+}
+
+func newRefund(order *model.Order, reason string, expectedAmount float64) *model.OrderRefund {
+	currency := order.Currency
+	if currency == "" && order.Invoice != nil {
+		currency = order.Invoice.Wallet
+	}
+	return &model.OrderRefund{
+		OrderRef:       order.ID,
+		OrderID:        order.OrderID,
+		Reason:         reason,
+		Currency:       currency,
+		ExpectedAmount: expectedAmount,
+		Status:         model.RefundPending,
+	}
+}
+
+func tolerance() float64 {
+	if t := config.C().Refund.MatchTolerance; t > 0 {
+		return t
+	}
+	return defaultMatchTolerance
+}
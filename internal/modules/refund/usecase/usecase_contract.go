@@ -0,0 +1,15 @@
+package usecase
+
+import (
+	"context"
+	"giftcard/model"
+)
+
+type IRefundUseCase interface {
+	OrderFailed(ctx context.Context, orderId string) error
+	CreateRefund(ctx context.Context, orderId string, expectedAmount float64, note string) (*model.OrderRefund, error)
+	ReceiveRefund(ctx context.Context, id uint, amount float64) (*model.OrderRefund, error)
+	ListRefunds(ctx context.Context, status string) ([]model.OrderRefund, error)
+	OutstandingReport(ctx context.Context) ([]model.OutstandingRefunds, error)
+	ProcessLedger(ctx context.Context) (int, error)
+}
@@ -0,0 +1,61 @@
+package usecase
+
+import (
+	"giftcard/model"
+	"testing"
+	"time"
+)
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		refund   model.OrderRefund
+		credit   float64
+		matched  []model.OrderRefund
+		expected bool
+	}{
+		{name: "exact", refund: model.OrderRefund{ID: 1, ExpectedAmount: 25}, credit: 25, expected: true},
+		{name: "within the tolerance", refund: model.OrderRefund{ID: 1, ExpectedAmount: 25}, credit: 25.005, expected: true},
+		{name: "above the tolerance", refund: model.OrderRefund{ID: 1, ExpectedAmount: 25}, credit: 25.5},
+		{name: "below the tolerance", refund: model.OrderRefund{ID: 1, ExpectedAmount: 25}, credit: 24.5},
+		{name: "outstanding part", refund: model.OrderRefund{ID: 1, ExpectedAmount: 25, ReceivedAmount: 10}, credit: 15, expected: true},
+		{name: "whole amount of a partly received refund", refund: model.OrderRefund{ID: 1, ExpectedAmount: 25, ReceivedAmount: 10}, credit: 25},
+		{name: "matched earlier in the batch", refund: model.OrderRefund{ID: 1, ExpectedAmount: 25}, credit: 25, matched: []model.OrderRefund{{ID: 1}}},
+		{name: "another refund matched earlier", refund: model.OrderRefund{ID: 1, ExpectedAmount: 25}, credit: 25, matched: []model.OrderRefund{{ID: 2}}, expected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matches(&tt.refund, tt.credit, tt.matched); got != tt.expected {
+				t.Errorf("matches() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReceive(t *testing.T) {
+	at := time.Date(2024, 3, 10, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		refund   model.OrderRefund
+		amount   float64
+		received float64
+		status   string
+	}{
+		{name: "whole amount", refund: model.OrderRefund{ExpectedAmount: 25, Status: model.RefundPending}, amount: 25, received: 25, status: model.RefundReceived},
+		{name: "part", refund: model.OrderRefund{ExpectedAmount: 25, Status: model.RefundPending}, amount: 10, received: 10, status: model.RefundPartiallyReceived},
+		{name: "rest", refund: model.OrderRefund{ExpectedAmount: 25, ReceivedAmount: 10, Status: model.RefundPartiallyReceived}, amount: 15, received: 25, status: model.RefundReceived},
+		{name: "short by the tolerance", refund: model.OrderRefund{ExpectedAmount: 25, Status: model.RefundPending}, amount: 24.995, received: 24.995, status: model.RefundReceived},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refund := tt.refund
+			receive(&refund, tt.amount, at)
+			if refund.ReceivedAmount != tt.received || refund.Status != tt.status {
+				t.Errorf("receive() left %v %s, want %v %s", refund.ReceivedAmount, refund.Status, tt.received, tt.status)
+			}
+			if refund.ReceivedAt == nil || !refund.ReceivedAt.Equal(at) {
+				t.Errorf("receive() set received at %v, want %s", refund.ReceivedAt, at)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"context"
+	"giftcard/config"
+	"giftcard/internal/adaptor/metrics"
+	"giftcard/internal/modules/refund/usecase"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"log"
+	"time"
+)
+
+const (
+	workerName          = "refund"
+	defaultPollInterval = 60 * time.Second
+)
+
+// RunRefundWorker matches wallet credits to open refunds for as long as the application runs
+func RunRefundWorker(lc fx.Lifecycle, us usecase.IRefundUseCase, workers *metrics.Workers) {
+	if !config.C().Refund.Enabled {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				run(ctx, us, workers)
+			}()
+			log.Println("refund worker started")
+			return nil
+		},
+		OnStop: func(c context.Context) error {
+			cancel()
+			select {
+			case <-done:
+			case <-c.Done():
+			}
+			log.Println("refund worker stopped")
+			return nil
+		},
+	})
+}
+
+func run(ctx context.Context, us usecase.IRefundUseCase, workers *metrics.Workers) {
+	interval := time.Duration(config.C().Refund.PollInterval) * time.Second
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	workers.CaughtUp(workerName)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for ctx.Err() == nil {
+			processed, err := us.ProcessLedger(ctx)
+			if err != nil {
+				zap.L().Error("error while match refunds to the wallet ledger", zap.String("error", err.Error()))
+				break
+			}
+			if processed == 0 {
+				workers.CaughtUp(workerName)
+				break
+			}
+		}
+	}
+}
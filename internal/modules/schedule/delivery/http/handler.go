@@ -0,0 +1,292 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"giftcard/internal/adaptor/trace"
+	"giftcard/internal/exceptions"
+	"giftcard/internal/modules/schedule/usecase"
+	"giftcard/model"
+	"giftcard/pkg/requester"
+	"giftcard/pkg/responser"
+	"giftcard/pkg/utils"
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+)
+
+type Product struct {
+	ProductID   string `json:"productId"`
+	Sku         string `json:"sku" validate:"required"`
+	ProductType string `json:"productType" validate:"required"`
+	Quote       uint   `json:"quote" validate:"required,gt=0"`
+	Quantity    uint   `json:"quantity" validate:"required,gt=0"`
+}
+
+type createScheduleRequestBody struct {
+	Name          string    `json:"name" validate:"required,max=100"`
+	Spec          string    `json:"spec" validate:"required"`
+	Timezone      string    `json:"timezone"`
+	ProductList   []Product `json:"productList" validate:"required,min=1,dive"`
+	AutoConfirm   bool      `json:"autoConfirm"`
+	RunBudget     float64   `json:"runBudget" validate:"gte=0"`
+	MonthlyBudget float64   `json:"monthlyBudget" validate:"gte=0"`
+}
+
+type scheduleResponse struct {
+	Schedule *model.OrderSchedule     `json:"schedule"`
+	Runs     []model.OrderScheduleRun `json:"runs"`
+}
+
+type ScheduleHandler struct {
+	us usecase.IScheduleUseCase
+}
+
+type ScheduleHandlerParams struct {
+	fx.In
+	Us usecase.IScheduleUseCase
+}
+
+func NewScheduleHandler(params ScheduleHandlerParams) *ScheduleHandler {
+	return &ScheduleHandler{
+		us: params.Us,
+	}
+}
+
+func (h *ScheduleHandler) CreateSchedule(c echo.Context) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		utils.GetRequestCtx(c),
+		"CreateSchedule[ScheduleDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
+	logger := zap.L().With(
+		zap.String("tracer", uniqueID),
+	)
+
+	var requestBody createScheduleRequestBody
+	if err := c.Bind(&requestBody); err != nil {
+		return badRequest(c, logger, span, err)
+	}
+	validate := validator.New()
+	if err := validate.Struct(&requestBody); err != nil {
+		return badRequest(c, logger, span, err)
+	}
+
+	request := requester.Request{
+		ID:          uniqueID,
+		RequestBody: requestBody,
+		UserIP:      c.RealIP(),
+		Uri:         c.Path(),
+		Method:      c.Request().Method,
+		Host:        c.Request().Host,
+		Header:      c.Request().Header,
+		Params:      c.QueryParams(),
+	}
+	logger.Info("Request from client", zap.Any("data", request))
+	span.SetAttributes(attribute.String("Request", utils.Marshal(request)))
+
+	schedule := &model.OrderSchedule{
+		Name:          requestBody.Name,
+		Spec:          requestBody.Spec,
+		Timezone:      requestBody.Timezone,
+		AutoConfirm:   requestBody.AutoConfirm,
+		RunBudget:     requestBody.RunBudget,
+		MonthlyBudget: requestBody.MonthlyBudget,
+	}
+	for _, product := range requestBody.ProductList {
+		schedule.Products = append(schedule.Products, model.ScheduleProduct{
+			ProductID:   product.ProductID,
+			SKU:         product.Sku,
+			ProductType: product.ProductType,
+			Quote:       product.Quote,
+			Quantity:    product.Quantity,
+		})
+	}
+
+	ctx := utils.WithPrincipal(context.WithValue(spannedContext, "tracer", uniqueID), utils.GetPrincipal(c))
+	ctx = utils.WithTeam(ctx, utils.GetTeam(c))
+	schedule, err := h.us.CreateSchedule(ctx, schedule)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidSchedule) {
+			logger.Info("Response to client", zap.Any("error", err.Error()))
+			span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+			return c.JSON(http.StatusBadRequest, responser.Response{
+				Message: exceptions.InvalidSchedule,
+				Data:    err.Error(),
+				Success: false,
+			})
+		}
+		return internalError(c, logger, span, err)
+	}
+
+	logger.Info("Response to client", zap.Uint("data", schedule.ID))
+	return c.JSON(http.StatusCreated, responser.Response{
+		Message: "",
+		Success: true,
+		Data:    schedule,
+	})
+}
+
+func (h *ScheduleHandler) ListSchedules(c echo.Context) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		utils.GetRequestCtx(c),
+		"ListSchedules[ScheduleDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
+	logger := zap.L().With(
+		zap.String("tracer", uniqueID),
+	)
+
+	ctx := context.WithValue(spannedContext, "tracer", uniqueID)
+	schedules, err := h.us.ListSchedules(ctx)
+	if err != nil {
+		return internalError(c, logger, span, err)
+	}
+
+	logger.Info("Response to client", zap.Int("data", len(schedules)))
+	return c.JSON(http.StatusOK, responser.Response{
+		Message: "",
+		Success: true,
+		Data:    schedules,
+	})
+}
+
+// GetSchedule returns the schedule with its latest runs
+func (h *ScheduleHandler) GetSchedule(c echo.Context) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		utils.GetRequestCtx(c),
+		"GetSchedule[ScheduleDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
+	logger := zap.L().With(
+		zap.String("tracer", uniqueID),
+	)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return badRequest(c, logger, span, err)
+	}
+
+	ctx := context.WithValue(spannedContext, "tracer", uniqueID)
+	schedule, runs, err := h.us.GetSchedule(ctx, uint(id))
+	if err != nil {
+		return notFoundOrInternalError(c, logger, span, err)
+	}
+
+	logger.Info("Response to client", zap.Uint64("data", id))
+	return c.JSON(http.StatusOK, responser.Response{
+		Message: "",
+		Success: true,
+		Data:    scheduleResponse{Schedule: schedule, Runs: runs},
+	})
+}
+
+func (h *ScheduleHandler) PauseSchedule(c echo.Context) error {
+	return h.changeSchedule(c, "PauseSchedule[ScheduleDelivery]", http.StatusOK, h.us.PauseSchedule)
+}
+
+func (h *ScheduleHandler) ResumeSchedule(c echo.Context) error {
+	return h.changeSchedule(c, "ResumeSchedule[ScheduleDelivery]", http.StatusOK, h.us.ResumeSchedule)
+}
+
+// RunSchedule queues an extra run, the scheduler places the order on its next poll
+func (h *ScheduleHandler) RunSchedule(c echo.Context) error {
+	return h.changeSchedule(c, "RunSchedule[ScheduleDelivery]", http.StatusAccepted, h.us.RunNow)
+}
+
+func (h *ScheduleHandler) changeSchedule(c echo.Context, spanName string, status int, change func(ctx context.Context, id uint) (*model.OrderSchedule, error)) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		utils.GetRequestCtx(c),
+		spanName,
+		"delivery")
+	defer span.End()
+
+	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
+	logger := zap.L().With(
+		zap.String("tracer", uniqueID),
+	)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return badRequest(c, logger, span, err)
+	}
+
+	request := requester.Request{
+		ID:          uniqueID,
+		RequestBody: "",
+		UserIP:      c.RealIP(),
+		Uri:         c.Path(),
+		Method:      c.Request().Method,
+		Host:        c.Request().Host,
+		Header:      c.Request().Header,
+		Params:      c.QueryParams(),
+	}
+	logger.Info("Request from client", zap.Any("data", request))
+	span.SetAttributes(attribute.String("Request", utils.Marshal(request)))
+
+	ctx := context.WithValue(spannedContext, "tracer", uniqueID)
+	schedule, err := change(ctx, uint(id))
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidSchedule) {
+			logger.Info("Response to client", zap.Any("error", err.Error()))
+			span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+			return c.JSON(http.StatusConflict, responser.Response{
+				Message: exceptions.InvalidSchedule,
+				Data:    err.Error(),
+				Success: false,
+			})
+		}
+		return notFoundOrInternalError(c, logger, span, err)
+	}
+
+	logger.Info("Response to client", zap.Uint64("data", id))
+	return c.JSON(status, responser.Response{
+		Message: "",
+		Success: true,
+		Data:    schedule,
+	})
+}
+
+func badRequest(c echo.Context, logger *zap.Logger, span oteltrace.Span, err error) error {
+	logger.Info("Response to client", zap.Any("error", err.Error()))
+	span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+	return c.JSON(http.StatusBadRequest, responser.Response{
+		Message: exceptions.InvalidInput,
+		Data:    "",
+		Success: false})
+}
+
+func notFoundOrInternalError(c echo.Context, logger *zap.Logger, span oteltrace.Span, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Info("Response to client", zap.Any("error", err.Error()))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, gorm.ErrRecordNotFound.Error()))
+		return c.JSON(http.StatusNotFound, responser.Response{
+			Message: exceptions.ScheduleNotFound,
+			Data:    "",
+			Success: false,
+		})
+	}
+	return internalError(c, logger, span, err)
+}
+
+func internalError(c echo.Context, logger *zap.Logger, span oteltrace.Span, err error) error {
+	logger.Info("Response to client", zap.Any("error", err.Error()))
+	span.SetAttributes(attribute.String(exceptions.InternalServerError, err.Error()))
+	return c.JSON(http.StatusInternalServerError, responser.Response{
+		Message: exceptions.InternalServerError,
+		Data:    "",
+		Success: false,
+	})
+}
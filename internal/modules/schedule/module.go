@@ -0,0 +1,16 @@
+package schedule
+
+import (
+	"giftcard/internal/modules/schedule/delivery/http"
+	"giftcard/internal/modules/schedule/repository"
+	"giftcard/internal/modules/schedule/usecase"
+	"giftcard/internal/modules/schedule/worker"
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module("schedule",
+	fx.Provide(usecase.NewScheduleUseCase),
+	fx.Provide(delivery.NewScheduleHandler),
+	fx.Provide(repository.NewScheduleRepository),
+	fx.Invoke(worker.RunScheduleWorker),
+)
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"giftcard/model"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type ScheduleRepository struct {
+	db *gorm.DB
+}
+
+type ScheduleRepositoryParams struct {
+	fx.In
+	Db *gorm.DB
+}
+
+func NewScheduleRepository(params ScheduleRepositoryParams) IScheduleRepository {
+	return &ScheduleRepository{
+		db: params.Db,
+	}
+}
+
+func (repo *ScheduleRepository) InsertSchedule(schedule *model.OrderSchedule) error {
+	return repo.db.Create(schedule).Error
+}
+
+func (repo *ScheduleRepository) GetSchedule(id uint) (*model.OrderSchedule, error) {
+	var schedule model.OrderSchedule
+	if err := repo.db.First(&schedule, id).Error; err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (repo *ScheduleRepository) ListSchedules() ([]model.OrderSchedule, error) {
+	var schedules []model.OrderSchedule
+	if err := repo.db.Order("id").Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// UpdateSchedule locks the schedule, lets update change it and writes back the fields a
+// pause, resume or run request changes. The row lock waits for a replica claiming the schedule,
+// so update always sees the next run and status the claim left.
+func (repo *ScheduleRepository) UpdateSchedule(id uint, update func(schedule *model.OrderSchedule) error) (*model.OrderSchedule, error) {
+	var schedule model.OrderSchedule
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&schedule, id).Error; err != nil {
+			return err
+		}
+		if err := update(&schedule); err != nil {
+			return err
+		}
+		return tx.Model(&schedule).
+			Select("status", "next_run_at", "run_requested").
+			Updates(&schedule).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// ClaimDueSchedule locks one active schedule whose next run is due, or one with a requested
+// run, and saves it after advance moved its next run. The row lock skips schedules another
+// replica is claiming and the next run is moved before the order is placed, so a run is
+// executed at most once across replicas.
+func (repo *ScheduleRepository) ClaimDueSchedule(now time.Time, advance func(schedule *model.OrderSchedule) error) (*model.OrderSchedule, error) {
+	var schedules []model.OrderSchedule
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND next_run_at <= ?) OR run_requested", model.ScheduleActive, now).
+			Order("next_run_at").
+			Limit(1).
+			Find(&schedules).Error; err != nil {
+			return err
+		}
+		if len(schedules) == 0 {
+			return nil
+		}
+		if err := advance(&schedules[0]); err != nil {
+			return err
+		}
+		return tx.Save(&schedules[0]).Error
+	})
+	if err != nil || len(schedules) == 0 {
+		return nil, err
+	}
+	return &schedules[0], nil
+}
+
+func (repo *ScheduleRepository) InsertRun(run *model.OrderScheduleRun) error {
+	return repo.db.Create(run).Error
+}
+
+func (repo *ScheduleRepository) ListRuns(scheduleID uint, limit int) ([]model.OrderScheduleRun, error) {
+	var runs []model.OrderScheduleRun
+	if err := repo.db.
+		Where("schedule_id = ?", scheduleID).
+		Order("id desc").
+		Limit(limit).
+		Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// ConfirmedSpend sums the confirmed orders of the schedule created since the given time
+func (repo *ScheduleRepository) ConfirmedSpend(scheduleID uint, since time.Time) (float64, error) {
+	var spend float64
+	if err := repo.db.Model(&model.Order{}).
+		Select("coalesce(sum(total), 0)").
+		Where("schedule_id = ? AND created_at >= ? AND confirmed_at IS NOT NULL", scheduleID, since).
+		Scan(&spend).Error; err != nil {
+		return 0, err
+	}
+	return spend, nil
+}
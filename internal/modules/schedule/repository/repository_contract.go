@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"giftcard/model"
+	"time"
+)
+
+type IScheduleRepository interface {
+	InsertSchedule(schedule *model.OrderSchedule) error
+	GetSchedule(id uint) (*model.OrderSchedule, error)
+	ListSchedules() ([]model.OrderSchedule, error)
+	UpdateSchedule(id uint, update func(schedule *model.OrderSchedule) error) (*model.OrderSchedule, error)
+	ClaimDueSchedule(now time.Time, advance func(schedule *model.OrderSchedule) error) (*model.OrderSchedule, error)
+	InsertRun(run *model.OrderScheduleRun) error
+	ListRuns(scheduleID uint, limit int) ([]model.OrderScheduleRun, error)
+	ConfirmedSpend(scheduleID uint, since time.Time) (float64, error)
+}
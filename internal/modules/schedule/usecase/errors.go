@@ -0,0 +1,10 @@
+package usecase
+
+import (
+	"errors"
+	"giftcard/internal/exceptions"
+)
+
+var (
+	ErrInvalidSchedule = errors.New(exceptions.InvalidSchedule)
+)
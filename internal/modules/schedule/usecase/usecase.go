@@ -0,0 +1,301 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"giftcard/internal/adaptor/trace"
+	orderUseCase "giftcard/internal/modules/order/usecase"
+	"giftcard/internal/modules/schedule/repository"
+	"giftcard/model"
+	"giftcard/pkg/cron"
+	"giftcard/pkg/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"time"
+)
+
+const runsLimit = 50
+
+type scheduleUseCase struct {
+	repo   repository.IScheduleRepository
+	orders orderUseCase.IOrderUseCase
+}
+
+type ScheduleUseCaseParams struct {
+	fx.In
+	Repo   repository.IScheduleRepository
+	Orders orderUseCase.IOrderUseCase
+}
+
+func NewScheduleUseCase(params ScheduleUseCaseParams) IScheduleUseCase {
+	return &scheduleUseCase{
+		repo:   params.Repo,
+		orders: params.Orders,
+	}
+}
+
+// CreateSchedule validates the spec and timezone and stores an active schedule
+func (us scheduleUseCase) CreateSchedule(ctx context.Context, schedule *model.OrderSchedule) (*model.OrderSchedule, error) {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"CreateScheduleUseCase",
+		"UseCase")
+	defer span.End()
+
+	if schedule.Timezone == "" {
+		schedule.Timezone = "UTC"
+	}
+	schedule.Status = model.ScheduleActive
+	schedule.CreatedBy = utils.PrincipalFromCtx(ctx)
+	schedule.Team = utils.TeamFromCtx(ctx)
+
+	nextRunAt, err := nextRun(schedule, time.Now())
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	schedule.NextRunAt = nextRunAt
+
+	if err := us.repo.InsertSchedule(schedule); err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	zap.L().Info("order schedule created",
+		zap.Uint("schedule", schedule.ID),
+		zap.String("spec", schedule.Spec),
+	)
+	return schedule, nil
+}
+
+func (us scheduleUseCase) ListSchedules(ctx context.Context) ([]model.OrderSchedule, error) {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"ListSchedulesUseCase",
+		"UseCase")
+	defer span.End()
+
+	schedules, err := us.repo.ListSchedules()
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// GetSchedule returns the schedule with its latest runs, newest first
+func (us scheduleUseCase) GetSchedule(ctx context.Context, id uint) (*model.OrderSchedule, []model.OrderScheduleRun, error) {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"GetScheduleUseCase",
+		"UseCase")
+	defer span.End()
+
+	schedule, err := us.repo.GetSchedule(id)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, nil, err
+	}
+	runs, err := us.repo.ListRuns(id, runsLimit)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, nil, err
+	}
+	return schedule, runs, nil
+}
+
+func (us scheduleUseCase) PauseSchedule(ctx context.Context, id uint) (*model.OrderSchedule, error) {
+	return us.updateSchedule(ctx, "PauseScheduleUseCase", id, func(schedule *model.OrderSchedule) error {
+		schedule.Status = model.SchedulePaused
+		return nil
+	})
+}
+
+// ResumeSchedule activates the schedule from now on, runs missed while paused are not caught up
+func (us scheduleUseCase) ResumeSchedule(ctx context.Context, id uint) (*model.OrderSchedule, error) {
+	return us.updateSchedule(ctx, "ResumeScheduleUseCase", id, func(schedule *model.OrderSchedule) error {
+		nextRunAt, err := nextRun(schedule, time.Now())
+		if err != nil {
+			return err
+		}
+		schedule.Status = model.ScheduleActive
+		schedule.NextRunAt = nextRunAt
+		return nil
+	})
+}
+
+// RunNow asks the scheduler for an extra run, paused schedules included, and leaves the
+// regular runs as they are
+func (us scheduleUseCase) RunNow(ctx context.Context, id uint) (*model.OrderSchedule, error) {
+	return us.updateSchedule(ctx, "RunNowUseCase", id, func(schedule *model.OrderSchedule) error {
+		schedule.RunRequested = true
+		return nil
+	})
+}
+
+func (us scheduleUseCase) updateSchedule(ctx context.Context, spanName string, id uint, update func(schedule *model.OrderSchedule) error) (*model.OrderSchedule, error) {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		spanName,
+		"UseCase")
+	defer span.End()
+
+	schedule, err := us.repo.UpdateSchedule(id, update)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// ProcessDue claims one due schedule and runs it, it returns the number of schedules run.
+// Runs missed while the scheduler was down are coalesced into a single run.
+func (us scheduleUseCase) ProcessDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	var scheduledFor time.Time
+	var manual bool
+	schedule, err := us.repo.ClaimDueSchedule(now, func(schedule *model.OrderSchedule) error {
+		due := schedule.Status == model.ScheduleActive && schedule.NextRunAt != nil && !schedule.NextRunAt.After(now)
+		manual = !due
+		scheduledFor = now
+		if due {
+			scheduledFor = *schedule.NextRunAt
+			nextRunAt, err := nextRun(schedule, now)
+			if err != nil {
+				// a spec that no longer parses must not be claimed again on every poll
+				schedule.Status = model.SchedulePaused
+				nextRunAt = nil
+				zap.L().Error("error while compute next schedule run, schedule paused",
+					zap.Uint("schedule", schedule.ID),
+					zap.String("error", err.Error()),
+				)
+			}
+			schedule.NextRunAt = nextRunAt
+		}
+		schedule.RunRequested = false
+		schedule.LastRunAt = &now
+		return nil
+	})
+	if err != nil || schedule == nil {
+		return 0, err
+	}
+
+	span, spannedContext := trace.T.SpanFromContext(
+		ctx,
+		"RunScheduleUseCase",
+		"UseCase")
+	defer span.End()
+	span.SetAttributes(attribute.Int("schedule", int(schedule.ID)))
+
+	run := us.runSchedule(spannedContext, schedule, scheduledFor)
+	run.Manual = manual
+	if run.Error != "" {
+		span.SetAttributes(attribute.String("error", run.Error))
+	}
+	if err := us.repo.InsertRun(run); err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return 1, err
+	}
+
+	zap.L().Info("order schedule run",
+		zap.Uint("schedule", schedule.ID),
+		zap.String("status", run.Status),
+		zap.String("order", run.OrderID),
+	)
+	return 1, nil
+}
+
+// runSchedule places the schedule order as its creator and confirms it when the schedule
+// auto confirms and the order fits the budgets
+func (us scheduleUseCase) runSchedule(ctx context.Context, schedule *model.OrderSchedule, scheduledFor time.Time) *model.OrderScheduleRun {
+	run := &model.OrderScheduleRun{
+		ScheduleID:   schedule.ID,
+		ScheduledFor: scheduledFor,
+	}
+	fail := func(err error) *model.OrderScheduleRun {
+		run.Status = model.ScheduleRunFailed
+		run.Error = err.Error()
+		return run
+	}
+
+	if len(schedule.Products) == 0 {
+		return fail(errors.New("schedule has no products"))
+	}
+	location, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return fail(err)
+	}
+	now := time.Now().In(location)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, location)
+	spent, err := us.repo.ConfirmedSpend(schedule.ID, monthStart)
+	if err != nil {
+		return fail(err)
+	}
+	if schedule.MonthlyBudget > 0 && spent >= schedule.MonthlyBudget {
+		run.Status = model.ScheduleRunSkipped
+		run.Error = fmt.Sprintf("monthly budget %.2f spent", schedule.MonthlyBudget)
+		return run
+	}
+
+	ctx = utils.WithTeam(utils.WithPrincipal(ctx, schedule.CreatedBy), schedule.Team)
+	ctx = orderUseCase.WithSchedule(ctx, schedule.ID)
+	productList := make([]map[string]any, 0, len(schedule.Products))
+	for _, product := range schedule.Products {
+		productList = append(productList, map[string]any{
+			"productId":   product.ProductID,
+			"sku":         product.SKU,
+			"productType": product.ProductType,
+			"quote":       product.Quote,
+			"quantity":    product.Quantity,
+		})
+	}
+	data, err := us.orders.CreateOrder(ctx, productList)
+	if err != nil {
+		return fail(err)
+	}
+	run.OrderID = data.Data.ID
+	run.Total = data.Data.Invoice.Total
+	run.Currency = data.Data.Invoice.Wallet
+
+	switch {
+	case schedule.RunBudget > 0 && run.Total > schedule.RunBudget:
+		run.Status = model.ScheduleRunOverBudget
+		run.Error = fmt.Sprintf("order total %.2f over the run budget %.2f", run.Total, schedule.RunBudget)
+		return run
+	case schedule.MonthlyBudget > 0 && spent+run.Total > schedule.MonthlyBudget:
+		run.Status = model.ScheduleRunOverBudget
+		run.Error = fmt.Sprintf("order total %.2f over the remaining monthly budget %.2f", run.Total, schedule.MonthlyBudget-spent)
+		return run
+	case !schedule.AutoConfirm:
+		run.Status = model.ScheduleRunCreated
+		return run
+	}
+
+	if _, err := us.orders.ConfirmOrder(ctx, run.OrderID); err != nil {
+		if errors.Is(err, orderUseCase.ErrApprovalPending) {
+			run.Status = model.ScheduleRunPendingApproval
+			return run
+		}
+		return fail(err)
+	}
+	run.Status = model.ScheduleRunConfirmed
+	return run
+}
+
+// nextRun returns the first run of the schedule after the given time, nil when the spec never fires again
+func nextRun(schedule *model.OrderSchedule, after time.Time) (*time.Time, error) {
+	spec, err := cron.Parse(schedule.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSchedule, err.Error())
+	}
+	location, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSchedule, err.Error())
+	}
+	next := spec.Next(after.In(location))
+	if next.IsZero() {
+		return nil, nil
+	}
+	return &next, nil
+}
@@ -0,0 +1,16 @@
+package usecase
+
+import (
+	"context"
+	"giftcard/model"
+)
+
+type IScheduleUseCase interface {
+	CreateSchedule(ctx context.Context, schedule *model.OrderSchedule) (*model.OrderSchedule, error)
+	ListSchedules(ctx context.Context) ([]model.OrderSchedule, error)
+	GetSchedule(ctx context.Context, id uint) (*model.OrderSchedule, []model.OrderScheduleRun, error)
+	PauseSchedule(ctx context.Context, id uint) (*model.OrderSchedule, error)
+	ResumeSchedule(ctx context.Context, id uint) (*model.OrderSchedule, error)
+	RunNow(ctx context.Context, id uint) (*model.OrderSchedule, error)
+	ProcessDue(ctx context.Context) (int, error)
+}
@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"context"
+	"giftcard/config"
+	"giftcard/internal/adaptor/metrics"
+	"giftcard/internal/modules/schedule/usecase"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"log"
+	"time"
+)
+
+const (
+	workerName          = "schedule"
+	defaultPollInterval = 30 * time.Second
+)
+
+// RunScheduleWorker places the orders of due schedules for as long as the application runs
+func RunScheduleWorker(lc fx.Lifecycle, us usecase.IScheduleUseCase, workers *metrics.Workers) {
+	if !config.C().Schedule.Enabled {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				run(ctx, us, workers)
+			}()
+			log.Println("order schedule worker started")
+			return nil
+		},
+		OnStop: func(c context.Context) error {
+			cancel()
+			select {
+			case <-done:
+			case <-c.Done():
+			}
+			log.Println("order schedule worker stopped")
+			return nil
+		},
+	})
+}
+
+func run(ctx context.Context, us usecase.IScheduleUseCase, workers *metrics.Workers) {
+	interval := time.Duration(config.C().Schedule.PollInterval) * time.Second
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	workers.CaughtUp(workerName)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for ctx.Err() == nil {
+			processed, err := us.ProcessDue(ctx)
+			if err != nil {
+				zap.L().Error("error while process order schedules", zap.String("error", err.Error()))
+				break
+			}
+			if processed == 0 {
+				workers.CaughtUp(workerName)
+				break
+			}
+		}
+	}
+}
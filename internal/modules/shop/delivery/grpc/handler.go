@@ -0,0 +1,116 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"giftcard/api/pb"
+	gftErr "giftcard/internal/adaptor/giftcard"
+	"giftcard/internal/adaptor/trace"
+	"giftcard/internal/exceptions"
+	"giftcard/internal/modules/shop/usecase"
+	"giftcard/pkg/logger"
+	"giftcard/pkg/utils"
+	"github.com/go-playground/validator"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type ShopGrpcHandler struct {
+	pb.UnimplementedShopServiceServer
+	us usecase.IShopUseCase
+}
+
+type ShopGrpcHandlerParams struct {
+	fx.In
+	Us usecase.IShopUseCase
+}
+
+func NewShopGrpcHandler(params ShopGrpcHandlerParams) *ShopGrpcHandler {
+	return &ShopGrpcHandler{
+		us: params.Us,
+	}
+}
+
+func (h *ShopGrpcHandler) GetProduct(ctx context.Context, req *pb.GetProductRequest) (*pb.DataResponse, error) {
+	span, spannedContext := trace.T.SpanFromContext(
+		ctx,
+		"ShopItem[ShopGrpcDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID, _ := ctx.Value("tracer").(string)
+	logger := logger.For(ctx, logger.ModuleShop).With(
+		zap.String("tracer", uniqueID),
+	)
+
+	if req.GetProductId() == "" {
+		logger.Info("Response to client", zap.Any("error", exceptions.ProductIDError))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, exceptions.ProductIDError))
+		return nil, status.Error(codes.InvalidArgument, exceptions.ProductIDError)
+	}
+
+	data, err := h.us.GetShopItem(spannedContext, req.GetProductId())
+	if err != nil {
+		return nil, toStatusError(logger, span, err)
+	}
+
+	value, err := utils.ToProtoValue(data.Data)
+	if err != nil {
+		return nil, toStatusError(logger, span, err)
+	}
+	return &pb.DataResponse{Data: value}, nil
+}
+
+func (h *ShopGrpcHandler) ListProducts(ctx context.Context, req *pb.ListProductsRequest) (*pb.DataResponse, error) {
+	span, spannedContext := trace.T.SpanFromContext(
+		ctx,
+		"ShopList[ShopGrpcDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID, _ := ctx.Value("tracer").(string)
+	logger := logger.For(ctx, logger.ModuleShop).With(
+		zap.String("tracer", uniqueID),
+	)
+
+	pageSize := int(req.GetPageSize())
+	validate := validator.New()
+	if err := validate.Var(pageSize, "min=5,max=50"); err != nil {
+		logger.Info("Response to client", zap.Any("error", exceptions.PageSizeError))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+		return nil, status.Error(codes.InvalidArgument, exceptions.PageSizeError)
+	}
+
+	data, err := h.us.GetShopList(spannedContext, pageSize, req.GetPageToken())
+	if err != nil {
+		return nil, toStatusError(logger, span, err)
+	}
+
+	value, err := utils.ToProtoValue(data["data"])
+	if err != nil {
+		return nil, toStatusError(logger, span, err)
+	}
+	return &pb.DataResponse{Data: value}, nil
+}
+
+func toStatusError(logger *zap.Logger, span oteltrace.Span, err error) error {
+	var forbiddenErr *gftErr.ForbiddenErr
+	if errors.As(err, &forbiddenErr) {
+		logger.Info("Response to client", zap.Any("error", forbiddenErr.ErrMsg))
+		span.SetAttributes(attribute.String(exceptions.StatusForbidden, forbiddenErr.ErrMsg))
+		return status.Error(codes.PermissionDenied, forbiddenErr.ErrMsg)
+	}
+	var reqErr *gftErr.RequestErr
+	if errors.As(err, &reqErr) {
+		logger.Info("Response to client", zap.Any("error", reqErr.ErrMsg))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, reqErr.ErrMsg))
+		return status.Error(codes.InvalidArgument, utils.Marshal(reqErr.Response))
+	}
+	logger.Info("Response to client", zap.Any("error", err.Error()))
+	span.SetAttributes(attribute.String(exceptions.InternalServerError, err.Error()))
+	return status.Error(codes.Internal, exceptions.InternalServerError)
+}
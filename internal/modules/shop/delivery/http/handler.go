@@ -7,6 +7,7 @@ import (
 	"giftcard/internal/adaptor/trace"
 	"giftcard/internal/exceptions"
 	"giftcard/internal/modules/shop/usecase"
+	"giftcard/pkg/logger"
 	"giftcard/pkg/requester"
 	"giftcard/pkg/responser"
 	"giftcard/pkg/utils"
@@ -40,7 +41,7 @@ func (h *ShopHandler) ShopItem(c echo.Context) error {
 		"delivery")
 	defer span.End()
 	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
-	logger := zap.L().With(
+	logger := logger.For(spannedContext, logger.ModuleShop).With(
 		zap.String("tracer", uniqueID),
 	)
 
@@ -115,7 +116,7 @@ func (h *ShopHandler) ShopList(c echo.Context) error {
 	defer span.End()
 
 	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
-	logger := zap.L().With(
+	logger := logger.For(spannedContext, logger.ModuleShop).With(
 		zap.String("tracer", uniqueID),
 	)
 
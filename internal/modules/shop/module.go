@@ -1,6 +1,7 @@
 package shop
 
 import (
+	grpcDelivery "giftcard/internal/modules/shop/delivery/grpc"
 	"giftcard/internal/modules/shop/delivery/http"
 	"giftcard/internal/modules/shop/usecase"
 	"go.uber.org/fx"
@@ -9,4 +10,5 @@ import (
 var Module = fx.Module("shop",
 	fx.Provide(usecase.NewShopUseCase),
 	fx.Provide(delivery.NewShopHandler),
+	fx.Provide(grpcDelivery.NewShopGrpcHandler),
 )
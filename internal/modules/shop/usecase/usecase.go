@@ -4,6 +4,7 @@ import (
 	"context"
 	"giftcard/internal/adaptor/giftcard"
 	"giftcard/internal/adaptor/trace"
+	"giftcard/pkg/logger"
 	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
@@ -33,7 +34,7 @@ func (us giftCardShopUseCase) GetShopList(ctx context.Context, pageSize int, pag
 
 	uniqueID, _ := ctx.Value("tracer").(string)
 
-	logger := zap.L().With(
+	logger := logger.For(ctx, logger.ModuleShop).With(
 		zap.String("tracer", uniqueID),
 	)
 
@@ -57,7 +58,7 @@ func (us giftCardShopUseCase) GetShopItem(ctx context.Context, productId string)
 
 	uniqueID, _ := ctx.Value("tracer").(string)
 
-	logger := zap.L().With(
+	logger := logger.For(ctx, logger.ModuleShop).With(
 		zap.String("tracer", uniqueID),
 	)
 
@@ -0,0 +1,258 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"giftcard/internal/adaptor/trace"
+	"giftcard/internal/exceptions"
+	"giftcard/internal/modules/webhook/usecase"
+	"giftcard/pkg/requester"
+	"giftcard/pkg/responser"
+	"giftcard/pkg/utils"
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+)
+
+type createSubscriptionRequest struct {
+	URL        string   `json:"url" validate:"required,url"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"eventTypes" validate:"required,min=1,dive,oneof=order.created order.confirmed order.delivered order.failed order.expired order.approved order.rejected order.canceled *"`
+}
+
+type WebhookHandler struct {
+	us usecase.IWebhookUseCase
+}
+
+type WebhookHandlerParams struct {
+	fx.In
+	Us usecase.IWebhookUseCase
+}
+
+func NewWebhookHandler(params WebhookHandlerParams) *WebhookHandler {
+	return &WebhookHandler{
+		us: params.Us,
+	}
+}
+
+func (h *WebhookHandler) CreateSubscription(c echo.Context) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		utils.GetRequestCtx(c),
+		"CreateSubscription[WebhookDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
+	logger := zap.L().With(
+		zap.String("tracer", uniqueID),
+	)
+
+	var requestBody createSubscriptionRequest
+	if err := c.Bind(&requestBody); err != nil {
+		logger.Info("Response to client", zap.Any("error", err.Error()))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+		return c.JSON(http.StatusBadRequest, responser.Response{
+			Message: exceptions.InvalidWebhookInput,
+			Data:    "",
+			Success: false})
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&requestBody); err != nil {
+		logger.Info("Response to client", zap.Any("error", err.Error()))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+		return c.JSON(http.StatusBadRequest, responser.Response{
+			Message: exceptions.InvalidWebhookInput,
+			Data:    "",
+			Success: false})
+	}
+
+	request := requester.Request{
+		ID:          uniqueID,
+		RequestBody: requestBody.URL,
+		UserIP:      c.RealIP(),
+		Uri:         c.Path(),
+		Method:      c.Request().Method,
+		Host:        c.Request().Host,
+		Header:      c.Request().Header,
+		Params:      c.QueryParams(),
+	}
+	logger.Info("Request from client", zap.Any("data", request))
+	span.SetAttributes(attribute.String("Request from client", utils.Marshal(request)))
+
+	ctx := context.WithValue(spannedContext, "tracer", uniqueID)
+	subscription, err := h.us.CreateSubscription(ctx, requestBody.URL, requestBody.Secret, requestBody.EventTypes)
+	if errors.Is(err, usecase.ErrUnsafeTarget) {
+		logger.Info("Response to client", zap.Any("error", err.Error()))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+		return c.JSON(http.StatusBadRequest, responser.Response{
+			Message: exceptions.UnsafeWebhookTarget,
+			Data:    "",
+			Success: false,
+		})
+	}
+	if err != nil {
+		return internalError(c, logger, span, err)
+	}
+
+	logger.Info("Response to client", zap.Uint("data", subscription.ID))
+	return c.JSON(http.StatusCreated, responser.Response{
+		Message: "",
+		Success: true,
+		Data:    subscription,
+	})
+}
+
+func (h *WebhookHandler) ListSubscriptions(c echo.Context) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		utils.GetRequestCtx(c),
+		"ListSubscriptions[WebhookDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
+	logger := zap.L().With(
+		zap.String("tracer", uniqueID),
+	)
+
+	ctx := context.WithValue(spannedContext, "tracer", uniqueID)
+	subscriptions, err := h.us.ListSubscriptions(ctx)
+	if err != nil {
+		return internalError(c, logger, span, err)
+	}
+
+	response := responser.Response{
+		Message: "",
+		Success: true,
+		Data:    subscriptions,
+	}
+	logger.Info("Response to client", zap.Any("data", response))
+	span.SetAttributes(attribute.String("Response", utils.Marshal(response)))
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *WebhookHandler) DeleteSubscription(c echo.Context) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		utils.GetRequestCtx(c),
+		"DeleteSubscription[WebhookDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
+	logger := zap.L().With(
+		zap.String("tracer", uniqueID),
+	)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		logger.Info("Response to client", zap.Any("error", err.Error()))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+		return c.JSON(http.StatusBadRequest, responser.Response{
+			Message: exceptions.InvalidInput,
+			Data:    "",
+			Success: false})
+	}
+
+	ctx := context.WithValue(spannedContext, "tracer", uniqueID)
+	if err := h.us.DeleteSubscription(ctx, uint(id)); err != nil {
+		return notFoundOrInternalError(c, logger, span, err)
+	}
+
+	logger.Info("Response to client", zap.Uint64("data", id))
+	return c.JSON(http.StatusOK, responser.Response{
+		Message: "",
+		Success: true,
+		Data:    "",
+	})
+}
+
+func (h *WebhookHandler) ListDeliveries(c echo.Context) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		utils.GetRequestCtx(c),
+		"ListDeliveries[WebhookDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
+	logger := zap.L().With(
+		zap.String("tracer", uniqueID),
+	)
+
+	ctx := context.WithValue(spannedContext, "tracer", uniqueID)
+	deliveries, err := h.us.ListDeliveries(ctx, c.QueryParam("status"))
+	if err != nil {
+		return internalError(c, logger, span, err)
+	}
+
+	response := responser.Response{
+		Message: "",
+		Success: true,
+		Data:    deliveries,
+	}
+	logger.Info("Response to client", zap.Int("data", len(deliveries)))
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *WebhookHandler) ReplayDelivery(c echo.Context) error {
+	span, spannedContext := trace.T.SpanFromContext(
+		utils.GetRequestCtx(c),
+		"ReplayDelivery[WebhookDelivery]",
+		"delivery")
+	defer span.End()
+
+	uniqueID := c.Response().Header().Get(echo.HeaderXRequestID)
+	logger := zap.L().With(
+		zap.String("tracer", uniqueID),
+	)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		logger.Info("Response to client", zap.Any("error", err.Error()))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, err.Error()))
+		return c.JSON(http.StatusBadRequest, responser.Response{
+			Message: exceptions.InvalidInput,
+			Data:    "",
+			Success: false})
+	}
+
+	ctx := context.WithValue(spannedContext, "tracer", uniqueID)
+	if err := h.us.ReplayDelivery(ctx, uint(id)); err != nil {
+		return notFoundOrInternalError(c, logger, span, err)
+	}
+
+	logger.Info("Response to client", zap.Uint64("data", id))
+	return c.JSON(http.StatusAccepted, responser.Response{
+		Message: "",
+		Success: true,
+		Data:    "",
+	})
+}
+
+func notFoundOrInternalError(c echo.Context, logger *zap.Logger, span oteltrace.Span, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Info("Response to client", zap.Any("error", err.Error()))
+		span.SetAttributes(attribute.String(exceptions.StatusBadRequest, gorm.ErrRecordNotFound.Error()))
+		return c.JSON(http.StatusNotFound, responser.Response{
+			Message: exceptions.WebhookNotFound,
+			Data:    "",
+			Success: false,
+		})
+	}
+	return internalError(c, logger, span, err)
+}
+
+func internalError(c echo.Context, logger *zap.Logger, span oteltrace.Span, err error) error {
+	logger.Info("Response to client", zap.Any("error", err.Error()))
+	span.SetAttributes(attribute.String(exceptions.InternalServerError, err.Error()))
+	return c.JSON(http.StatusInternalServerError, responser.Response{
+		Message: exceptions.InternalServerError,
+		Data:    "",
+		Success: false,
+	})
+}
@@ -0,0 +1,17 @@
+package webhook
+
+import (
+	"giftcard/internal/modules/webhook/delivery/http"
+	"giftcard/internal/modules/webhook/repository"
+	"giftcard/internal/modules/webhook/usecase"
+	"giftcard/internal/modules/webhook/worker"
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module("webhook",
+	fx.Provide(usecase.NewWebhookUseCase),
+	fx.Provide(delivery.NewWebhookHandler),
+	fx.Provide(repository.NewWebhookRepository),
+	fx.Invoke(usecase.SubscribeOrderEvents),
+	fx.Invoke(worker.RunDeliveryWorker),
+)
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"giftcard/model"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+type WebhookRepositoryParams struct {
+	fx.In
+	Db *gorm.DB
+}
+
+func NewWebhookRepository(params WebhookRepositoryParams) IWebhookRepository {
+	return &WebhookRepository{
+		db: params.Db,
+	}
+}
+
+func (repo *WebhookRepository) InsertSubscription(subscription *model.WebhookSubscription) error {
+	return repo.db.Create(subscription).Error
+}
+
+func (repo *WebhookRepository) GetSubscription(id uint) (*model.WebhookSubscription, error) {
+	var subscription model.WebhookSubscription
+	if err := repo.db.First(&subscription, id).Error; err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (repo *WebhookRepository) ListSubscriptions() ([]model.WebhookSubscription, error) {
+	var subscriptions []model.WebhookSubscription
+	if err := repo.db.Order("id").Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// ListActiveSubscriptions returns the active subscriptions interested in eventType
+func (repo *WebhookRepository) ListActiveSubscriptions(eventType string) ([]model.WebhookSubscription, error) {
+	var subscriptions []model.WebhookSubscription
+	if err := repo.db.Where("active = ?", true).Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+
+	matched := subscriptions[:0]
+	for _, subscription := range subscriptions {
+		for _, t := range subscription.EventTypes {
+			if t == eventType || t == "*" {
+				matched = append(matched, subscription)
+				break
+			}
+		}
+	}
+	return matched, nil
+}
+
+func (repo *WebhookRepository) DeleteSubscription(id uint) error {
+	result := repo.db.Delete(&model.WebhookSubscription{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (repo *WebhookRepository) InsertDeliveries(deliveries []model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	// an event relayed twice must not be delivered twice
+	return repo.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error
+}
+
+func (repo *WebhookRepository) ListDeliveries(status string, limit int) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	query := repo.db.Order("id desc").Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// ClaimDueDeliveries locks due deliveries and pushes their next attempt forward by lease,
+// so other workers skip them while they are being sent
+func (repo *WebhookRepository) ClaimDueDeliveries(limit int, lease time.Duration) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", model.DeliveryPending, time.Now()).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, 0, len(deliveries))
+		for _, delivery := range deliveries {
+			ids = append(ids, delivery.ID)
+		}
+		return tx.Model(&model.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", time.Now().Add(lease)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (repo *WebhookRepository) UpdateDelivery(delivery *model.WebhookDelivery) error {
+	return repo.db.Save(delivery).Error
+}
+
+// ReplayDelivery puts a delivery back in the queue with a fresh attempt budget
+func (repo *WebhookRepository) ReplayDelivery(id uint) error {
+	result := repo.db.Model(&model.WebhookDelivery{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":          model.DeliveryPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+			"last_error":      "",
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
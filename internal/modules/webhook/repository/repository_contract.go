@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"giftcard/model"
+	"time"
+)
+
+type IWebhookRepository interface {
+	InsertSubscription(subscription *model.WebhookSubscription) error
+	GetSubscription(id uint) (*model.WebhookSubscription, error)
+	ListSubscriptions() ([]model.WebhookSubscription, error)
+	ListActiveSubscriptions(eventType string) ([]model.WebhookSubscription, error)
+	DeleteSubscription(id uint) error
+	InsertDeliveries(deliveries []model.WebhookDelivery) error
+	ListDeliveries(status string, limit int) ([]model.WebhookDelivery, error)
+	ClaimDueDeliveries(limit int, lease time.Duration) ([]model.WebhookDelivery, error)
+	UpdateDelivery(delivery *model.WebhookDelivery) error
+	ReplayDelivery(id uint) error
+}
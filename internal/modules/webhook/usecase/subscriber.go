@@ -0,0 +1,18 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"giftcard/pkg/eventbus"
+	"strings"
+)
+
+// SubscribeOrderEvents queues webhooks for the order events relayed from the outbox
+func SubscribeOrderEvents(bus *eventbus.Bus, us IWebhookUseCase) {
+	bus.Subscribe(eventbus.AllEvents, func(ctx context.Context, event eventbus.Event) error {
+		if !strings.HasPrefix(event.Type, "order.") {
+			return nil
+		}
+		return us.Dispatch(ctx, event.ID, event.Type, json.RawMessage(event.Payload))
+	})
+}
@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"giftcard/config"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// blockedPrefixes are the shared and reserved ranges the net.IP helpers do not cover
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// publicAddr is false for loopback, private, link local and the other addresses a webhook
+// must never reach, unless webhook.allow_private_targets is set
+func publicAddr(addr netip.Addr) bool {
+	if config.C().Webhook.AllowPrivateTargets {
+		return true
+	}
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkTarget refuses a subscription url that is not http or https or whose host resolves
+// to an address publicAddr refuses
+func checkTarget(ctx context.Context, rawURL string) error {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w, %v", ErrUnsafeTarget, err)
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return fmt.Errorf("%w, scheme %q", ErrUnsafeTarget, target.Scheme)
+	}
+	if target.User != nil {
+		return fmt.Errorf("%w, credentials in the url", ErrUnsafeTarget)
+	}
+	host := target.Hostname()
+	if host == "" {
+		return fmt.Errorf("%w, no host", ErrUnsafeTarget)
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !publicAddr(addr) {
+			return fmt.Errorf("%w, address %s", ErrUnsafeTarget, addr)
+		}
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("%w, host %s does not resolve", ErrUnsafeTarget, host)
+	}
+	for _, addr := range addrs {
+		if !publicAddr(addr) {
+			return fmt.Errorf("%w, host %s resolves to %s", ErrUnsafeTarget, host, addr)
+		}
+	}
+	return nil
+}
+
+// newClient checks every address right before it is dialed, redirects included, a host that
+// resolved to a public address at subscription time may not anymore. It skips the proxy from
+// the environment, the check would only see the proxy address.
+func newClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !publicAddr(addrPort.Addr()) {
+				return fmt.Errorf("%w, address %s", ErrUnsafeTarget, addrPort.Addr())
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
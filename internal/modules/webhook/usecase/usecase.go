@@ -0,0 +1,358 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"giftcard/config"
+	"giftcard/internal/adaptor/trace"
+	"giftcard/internal/modules/webhook/repository"
+	"giftcard/model"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"io"
+	"math"
+	mathRand "math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultBatchSize   = 20
+	defaultMaxAttempts = 8
+	defaultBaseBackoff = 10 * time.Second
+	defaultMaxBackoff  = time.Hour
+	defaultTimeout     = 10 * time.Second
+	deliveriesLimit    = 100
+)
+
+const (
+	SignatureHeader = "X-Giftcard-Signature"
+	TimestampHeader = "X-Giftcard-Timestamp"
+	EventHeader     = "X-Giftcard-Event"
+	DeliveryHeader  = "X-Giftcard-Delivery"
+)
+
+type webhookEvent struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"createdAt"`
+	Data      any       `json:"data"`
+}
+
+type webhookUseCase struct {
+	repo   repository.IWebhookRepository
+	client *http.Client
+}
+
+type WebhookUseCaseParams struct {
+	fx.In
+	Repo repository.IWebhookRepository
+}
+
+func NewWebhookUseCase(params WebhookUseCaseParams) IWebhookUseCase {
+	timeout := time.Duration(config.C().Webhook.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &webhookUseCase{
+		repo:   params.Repo,
+		client: newClient(timeout),
+	}
+}
+
+func (us webhookUseCase) CreateSubscription(ctx context.Context, url string, secret string, eventTypes []string) (*model.WebhookSubscription, error) {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"CreateSubscriptionUseCase",
+		"UseCase")
+	defer span.End()
+
+	if err := checkTarget(ctx, url); err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+
+	if secret == "" {
+		generated, err := generateSecret()
+		if err != nil {
+			span.SetAttributes(attribute.String("error", err.Error()))
+			return nil, err
+		}
+		secret = generated
+	}
+
+	subscription := &model.WebhookSubscription{
+		URL:        url,
+		Secret:     secret,
+		EventTypes: eventTypes,
+		Active:     true,
+	}
+	if err := us.repo.InsertSubscription(subscription); err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (us webhookUseCase) ListSubscriptions(ctx context.Context) ([]model.WebhookSubscription, error) {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"ListSubscriptionsUseCase",
+		"UseCase")
+	defer span.End()
+
+	subscriptions, err := us.repo.ListSubscriptions()
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+	return subscriptions, nil
+}
+
+func (us webhookUseCase) DeleteSubscription(ctx context.Context, id uint) error {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"DeleteSubscriptionUseCase",
+		"UseCase")
+	defer span.End()
+
+	if err := us.repo.DeleteSubscription(id); err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (us webhookUseCase) ListDeliveries(ctx context.Context, status string) ([]model.WebhookDelivery, error) {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"ListDeliveriesUseCase",
+		"UseCase")
+	defer span.End()
+
+	deliveries, err := us.repo.ListDeliveries(status, deliveriesLimit)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (us webhookUseCase) ReplayDelivery(ctx context.Context, id uint) error {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"ReplayDeliveryUseCase",
+		"UseCase")
+	defer span.End()
+
+	if err := us.repo.ReplayDelivery(id); err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// Dispatch queues one delivery per subscription interested in eventType,
+// dispatching the same eventID again is a no-op
+func (us webhookUseCase) Dispatch(ctx context.Context, eventID string, eventType string, data any) error {
+	span, _ := trace.T.SpanFromContext(
+		ctx,
+		"DispatchWebhookUseCase",
+		"UseCase")
+	defer span.End()
+
+	subscriptions, err := us.repo.ListActiveSubscriptions(eventType)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return err
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	event := webhookEvent{
+		ID:        eventID,
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return err
+	}
+
+	deliveries := make([]model.WebhookDelivery, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		deliveries = append(deliveries, model.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        event.ID,
+			EventType:      eventType,
+			Payload:        string(payload),
+			Status:         model.DeliveryPending,
+			NextAttemptAt:  time.Now(),
+		})
+	}
+	if err := us.repo.InsertDeliveries(deliveries); err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// DeliverDue sends a batch of due deliveries and returns how many were processed
+func (us webhookUseCase) DeliverDue(ctx context.Context) (int, error) {
+	batchSize := config.C().Webhook.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	deliveries, err := us.repo.ClaimDueDeliveries(batchSize, 2*us.client.Timeout)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range deliveries {
+		us.deliver(ctx, &deliveries[i])
+	}
+	return len(deliveries), nil
+}
+
+func (us webhookUseCase) deliver(ctx context.Context, delivery *model.WebhookDelivery) {
+	span, spannedContext := trace.T.SpanFromContext(
+		ctx,
+		"DeliverWebhookUseCase",
+		"UseCase")
+	defer span.End()
+
+	logger := zap.L().With(
+		zap.Uint("delivery", delivery.ID),
+		zap.String("event", delivery.EventID),
+	)
+
+	subscription, err := us.repo.GetSubscription(delivery.SubscriptionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// the subscription was removed, nothing left to deliver to
+		delivery.Status = model.DeliveryDead
+		delivery.LastError = err.Error()
+		us.saveDelivery(logger, delivery)
+		return
+	}
+	if err != nil {
+		// the subscription could not be read, try again later without spending an attempt
+		span.SetAttributes(attribute.String("error", err.Error()))
+		logger.Error("error while get webhook subscription", zap.String("error", err.Error()))
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = time.Now().Add(backoff(delivery.Attempts + 1))
+		us.saveDelivery(logger, delivery)
+		return
+	}
+
+	statusCode, err := us.send(spannedContext, subscription, delivery)
+	delivery.Attempts++
+	delivery.LastStatusCode = statusCode
+	if err == nil {
+		now := time.Now()
+		delivery.Status = model.DeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+		logger.Info("webhook delivered", zap.Int("statusCode", statusCode))
+		us.saveDelivery(logger, delivery)
+		return
+	}
+
+	span.SetAttributes(attribute.String("error", err.Error()))
+	logger.Error("webhook delivery failed", zap.String("error", err.Error()), zap.Int("attempts", delivery.Attempts))
+	delivery.LastError = err.Error()
+
+	maxAttempts := config.C().Webhook.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+	if delivery.Attempts >= maxAttempts {
+		delivery.Status = model.DeliveryDead
+	} else {
+		delivery.NextAttemptAt = time.Now().Add(backoff(delivery.Attempts))
+	}
+	us.saveDelivery(logger, delivery)
+}
+
+func (us webhookUseCase) send(ctx context.Context, subscription *model.WebhookSubscription, delivery *model.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewBufferString(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, delivery.EventID)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, "sha256="+Sign(subscription.Secret, timestamp, []byte(delivery.Payload)))
+
+	res, err := us.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, fmt.Errorf("subscriber responded with status %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
+
+func (us webhookUseCase) saveDelivery(logger *zap.Logger, delivery *model.WebhookDelivery) {
+	if err := us.repo.UpdateDelivery(delivery); err != nil {
+		logger.Error("error while update webhook delivery", zap.String("error", err.Error()))
+	}
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of "<timestamp>.<payload>" with the subscription secret
+func Sign(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// backoff grows exponentially with the attempts, capped and with up to 20% jitter
+func backoff(attempts int) time.Duration {
+	base := time.Duration(config.C().Webhook.BaseBackoff) * time.Second
+	if base <= 0 {
+		base = defaultBaseBackoff
+	}
+	maxBackoff := time.Duration(config.C().Webhook.MaxBackoff) * time.Second
+	if maxBackoff <= 0 {
+		maxBackoff = defaultMaxBackoff
+	}
+
+	delay := time.Duration(float64(base) * math.Pow(2, float64(attempts-1)))
+	if delay > maxBackoff || delay <= 0 {
+		delay = maxBackoff
+	}
+	return delay + time.Duration(mathRand.Int63n(int64(delay)/5+1))
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
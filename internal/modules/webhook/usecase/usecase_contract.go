@@ -0,0 +1,16 @@
+package usecase
+
+import (
+	"context"
+	"giftcard/model"
+)
+
+type IWebhookUseCase interface {
+	CreateSubscription(ctx context.Context, url string, secret string, eventTypes []string) (*model.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]model.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id uint) error
+	ListDeliveries(ctx context.Context, status string) ([]model.WebhookDelivery, error)
+	ReplayDelivery(ctx context.Context, id uint) error
+	Dispatch(ctx context.Context, eventID string, eventType string, data any) error
+	DeliverDue(ctx context.Context) (int, error)
+}
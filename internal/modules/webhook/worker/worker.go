@@ -0,0 +1,74 @@
+package worker
+
+import (
+	"context"
+	"giftcard/config"
+	"giftcard/internal/adaptor/metrics"
+	"giftcard/internal/modules/webhook/usecase"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"log"
+	"time"
+)
+
+const (
+	workerName          = "webhook"
+	defaultPollInterval = 5 * time.Second
+)
+
+// RunDeliveryWorker polls due webhook deliveries for as long as the application runs
+func RunDeliveryWorker(lc fx.Lifecycle, us usecase.IWebhookUseCase, workers *metrics.Workers) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				run(ctx, us, workers)
+			}()
+			log.Println("webhook delivery worker started")
+			return nil
+		},
+		OnStop: func(c context.Context) error {
+			cancel()
+			select {
+			case <-done:
+			case <-c.Done():
+			}
+			log.Println("webhook delivery worker stopped")
+			return nil
+		},
+	})
+}
+
+func run(ctx context.Context, us usecase.IWebhookUseCase, workers *metrics.Workers) {
+	interval := time.Duration(config.C().Webhook.PollInterval) * time.Second
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	workers.CaughtUp(workerName)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// drain the queue before waiting for the next tick
+		for ctx.Err() == nil {
+			processed, err := us.DeliverDue(ctx)
+			if err != nil {
+				zap.L().Error("error while deliver webhooks", zap.String("error", err.Error()))
+				break
+			}
+			if processed == 0 {
+				workers.CaughtUp(workerName)
+				break
+			}
+		}
+	}
+}
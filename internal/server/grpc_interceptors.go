@@ -0,0 +1,216 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"giftcard/internal/adaptor/trace"
+	"giftcard/internal/exceptions"
+	"giftcard/pkg/logger"
+	"giftcard/pkg/requester"
+	"giftcard/pkg/utils"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+const (
+	requestIDMetadataKey = "x-request-id"
+	// principalMetadataKey and teamMetadataKey carry the caller set by the gateway, like the
+	// X-Principal and X-Team headers of the http api
+	principalMetadataKey = "x-principal"
+	teamMetadataKey      = "x-team"
+)
+
+// wrappedStream lets stream interceptors replace the stream context
+type wrappedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (w *wrappedStream) Context() context.Context {
+	return w.ctx
+}
+
+// RequestIDUnaryInterceptor reuses the caller x-request-id or generates a new one,
+// and stores it in the context the same way the http handlers do
+func RequestIDUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(withRequestID(ctx), req)
+	}
+}
+
+func RequestIDStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &wrappedStream{ServerStream: ss, ctx: withRequestID(ss.Context())})
+	}
+}
+
+func withRequestID(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	uniqueID := utils.MetadataCarrier(md).Get(requestIDMetadataKey)
+	if uniqueID == "" {
+		uniqueID = uuid.NewString()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadataKey, uniqueID))
+	return context.WithValue(ctx, "tracer", uniqueID)
+}
+
+// PrincipalUnaryInterceptor stores the caller principal and team in the context the same
+// way the http handlers do, so orders created over grpc keep their creator
+func PrincipalUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(withPrincipal(ctx), req)
+	}
+}
+
+func PrincipalStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &wrappedStream{ServerStream: ss, ctx: withPrincipal(ss.Context())})
+	}
+}
+
+func withPrincipal(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	carrier := utils.MetadataCarrier(md)
+	return utils.WithTeam(utils.WithPrincipal(ctx, carrier.Get(principalMetadataKey)), carrier.Get(teamMetadataKey))
+}
+
+// TracingUnaryInterceptor continues the caller trace from the incoming metadata
+func TracingUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		span, spannedContext := trace.T.SpanFromContext(extractTrace(ctx), info.FullMethod, "grpc")
+		defer span.End()
+
+		resp, err := handler(spannedContext, req)
+		if err != nil {
+			span.SetAttributes(attribute.String("error", err.Error()))
+		}
+		return resp, err
+	}
+}
+
+func TracingStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		span, spannedContext := trace.T.SpanFromContext(extractTrace(ss.Context()), info.FullMethod, "grpc")
+		defer span.End()
+
+		err := handler(srv, &wrappedStream{ServerStream: ss, ctx: spannedContext})
+		if err != nil {
+			span.SetAttributes(attribute.String("error", err.Error()))
+		}
+		return err
+	}
+}
+
+func extractTrace(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return otel.GetTextMapPropagator().Extract(ctx, utils.MetadataCarrier(md))
+}
+
+// LoggingUnaryInterceptor logs every call and injects the trace aware logger into the context
+func LoggingUnaryInterceptor(log *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		uniqueID, _ := ctx.Value("tracer").(string)
+		lg := requestLog(ctx, log, uniqueID)
+
+		lg.Info("Request from client", zap.Any("data", grpcRequest(ctx, uniqueID, info.FullMethod, req)))
+		resp, err := handler(logger.ToContext(ctx, lg), req)
+		if err != nil {
+			lg.Info("Response to client", zap.Any("error", err.Error()))
+			return resp, err
+		}
+		// the payload may carry gift card codes, it is only logged at debug
+		lg.Info("Response to client", zap.String("method", info.FullMethod))
+		lg.Debug("Response payload", zap.Any("data", resp))
+		return resp, nil
+	}
+}
+
+func LoggingStreamInterceptor(log *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := ss.Context()
+		uniqueID, _ := ctx.Value("tracer").(string)
+		lg := requestLog(ctx, log, uniqueID)
+
+		lg.Info("Request from client", zap.Any("data", grpcRequest(ctx, uniqueID, info.FullMethod, nil)))
+		ctx = logger.ToContext(ctx, lg)
+		err := handler(srv, &wrappedStream{ServerStream: ss, ctx: ctx})
+		if err != nil {
+			lg.Info("Response to client", zap.Any("error", err.Error()))
+		}
+		return err
+	}
+}
+
+// requestLog carries the request and trace ids like the logger of the http requests
+func requestLog(ctx context.Context, log *zap.Logger, uniqueID string) *zap.Logger {
+	fields := []zap.Field{zap.String("request.id", uniqueID)}
+	if spanContext := oteltrace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		fields = append(fields, zap.String("trace.id", spanContext.TraceID().String()))
+	}
+	return log.With(fields...)
+}
+
+func grpcRequest(ctx context.Context, uniqueID string, method string, body any) requester.Request {
+	md, _ := metadata.FromIncomingContext(ctx)
+	header := md.Copy()
+	header.Delete("authorization")
+
+	request := requester.Request{
+		ID:          uniqueID,
+		RequestBody: body,
+		Uri:         method,
+		Method:      "GRPC",
+		Header:      header,
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		request.UserIP = p.Addr.String()
+	}
+	return request
+}
+
+// AuthUnaryInterceptor checks the bearer token against the configured tokens, every call is
+// refused when no token is configured unless disabled is set
+func AuthUnaryInterceptor(tokens []string, disabled bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := authorize(ctx, tokens, disabled); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func AuthStreamInterceptor(tokens []string, disabled bool) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authorize(ss.Context(), tokens, disabled); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func authorize(ctx context.Context, tokens []string, disabled bool) error {
+	if disabled {
+		return nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	token := strings.TrimSpace(strings.TrimPrefix(utils.MetadataCarrier(md).Get("authorization"), "Bearer "))
+	if token == "" {
+		return status.Error(codes.Unauthenticated, exceptions.AuthenticationError)
+	}
+	for _, t := range tokens {
+		if strings.TrimSpace(t) != "" && subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, exceptions.AuthenticationError)
+}
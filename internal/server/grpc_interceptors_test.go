@@ -0,0 +1,43 @@
+package server
+
+import (
+	"context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestAuthorize(t *testing.T) {
+	tests := []struct {
+		name     string
+		tokens   []string
+		disabled bool
+		header   string
+		allowed  bool
+	}{
+		{name: "matching token", tokens: []string{"a", "b"}, header: "Bearer b", allowed: true},
+		{name: "wrong token", tokens: []string{"a"}, header: "Bearer b"},
+		{name: "no header", tokens: []string{"a"}},
+		{name: "no token configured", header: "Bearer "},
+		{name: "no token configured and no header"},
+		{name: "disabled", disabled: true, allowed: true},
+		{name: "blank token configured and no header", tokens: []string{""}},
+		{name: "blank token configured and a blank header", tokens: []string{" "}, header: "Bearer  "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.header != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", tt.header))
+			}
+			err := authorize(ctx, tt.tokens, tt.disabled)
+			if tt.allowed && err != nil {
+				t.Fatalf("expected the call to be let in, got %v", err)
+			}
+			if !tt.allowed && status.Code(err) != codes.Unauthenticated {
+				t.Fatalf("expected the call to be refused, got %v", err)
+			}
+		})
+	}
+}
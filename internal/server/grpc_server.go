@@ -0,0 +1,82 @@
+package server
+
+import (
+	"fmt"
+	"giftcard/api/pb"
+	"giftcard/config"
+	CustomerGrpc "giftcard/internal/modules/customer/delivery/grpc"
+	OrderGrpc "giftcard/internal/modules/order/delivery/grpc"
+	ShopGrpc "giftcard/internal/modules/shop/delivery/grpc"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"log"
+	"net"
+)
+
+type GrpcServer struct {
+	srv       *grpc.Server
+	container GrpcDeliveryContainer
+}
+
+func NewGrpcServer(p GrpcDeliveryContainer) IGrpcServer {
+	conf := config.C().Grpc
+	if conf.AuthDisabled {
+		zap.L().Warn("grpc authentication is disabled, every caller is let in")
+	}
+	server := GrpcServer{
+		srv: grpc.NewServer(
+			grpc.ChainUnaryInterceptor(
+				RequestIDUnaryInterceptor(),
+				TracingUnaryInterceptor(),
+				AuthUnaryInterceptor(conf.AuthTokens, conf.AuthDisabled),
+				LoggingUnaryInterceptor(zap.L()),
+				PrincipalUnaryInterceptor(),
+			),
+			grpc.ChainStreamInterceptor(
+				RequestIDStreamInterceptor(),
+				TracingStreamInterceptor(),
+				AuthStreamInterceptor(conf.AuthTokens, conf.AuthDisabled),
+				LoggingStreamInterceptor(zap.L()),
+				PrincipalStreamInterceptor(),
+			),
+		),
+		container: p,
+	}
+	return &server
+}
+
+func (s *GrpcServer) SetUpServer(container GrpcDeliveryContainer) {
+	pb.RegisterShopServiceServer(s.srv, container.ShopHandler)
+	pb.RegisterOrderServiceServer(s.srv, container.OrderHandler)
+	pb.RegisterCustomerServiceServer(s.srv, container.CustomerHandler)
+}
+
+func (s *GrpcServer) Run() error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", config.C().Grpc.Port))
+	if err != nil {
+		return err
+	}
+
+	s.SetUpServer(s.container)
+	go func() {
+		log.Printf("gRPC server is listening on %s", listener.Addr())
+		if err := s.srv.Serve(listener); err != nil {
+			panic("error in starting grpc " + err.Error())
+		}
+	}()
+	return nil
+}
+
+func (s *GrpcServer) Shutdown() error {
+	log.Println("Shutting down gRPC server............")
+	s.srv.GracefulStop()
+	return nil
+}
+
+type GrpcDeliveryContainer struct {
+	fx.In
+	ShopHandler     *ShopGrpc.ShopGrpcHandler
+	OrderHandler    *OrderGrpc.OrderGrpcHandler
+	CustomerHandler *CustomerGrpc.CustomerGrpcHandler
+}
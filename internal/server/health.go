@@ -0,0 +1,34 @@
+package server
+
+import (
+	"giftcard/pkg/health"
+	"giftcard/pkg/responser"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+// livez answers as long as the process serves http, restarting does not fix a dependency
+func livez(c echo.Context) error {
+	return c.JSON(http.StatusOK, responser.Response{
+		Message: "",
+		Success: true,
+		Data:    map[string]string{"status": health.StatusOK},
+	})
+}
+
+// readyz reports every dependency check, a failing critical one takes the instance out of
+// the load balancer
+func readyz(checks *health.Registry) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		report := checks.Ready(c.Request().Context())
+		status := http.StatusOK
+		if report.Status == health.StatusUnavailable {
+			status = http.StatusServiceUnavailable
+		}
+		return c.JSON(status, responser.Response{
+			Message: "",
+			Success: status == http.StatusOK,
+			Data:    report,
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"errors"
+	"giftcard/config"
+	"giftcard/internal/exceptions"
+	"giftcard/pkg/logger"
+	"giftcard/pkg/responser"
+	"giftcard/pkg/utils"
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// requestLogger puts a logger carrying the request id, trace id and principal into the
+// request context, handlers and use cases take it with logger.For. Every request is logged
+// at debug with the http level once it is served.
+func requestLogger() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			request := c.Request()
+			fields := []zap.Field{zap.String("request.id", utils.GetRequestID(c))}
+			if spanContext := trace.SpanContextFromContext(request.Context()); spanContext.HasTraceID() {
+				fields = append(fields, zap.String("trace.id", spanContext.TraceID().String()))
+			}
+			if principal := utils.GetPrincipal(c); principal != "" {
+				fields = append(fields, zap.String("principal", principal))
+			}
+			ctx := logger.ToContext(request.Context(), zap.L().With(fields...))
+			c.SetRequest(request.WithContext(ctx))
+
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+			route := c.Path()
+			if route == "" {
+				route = request.URL.Path
+			}
+			logger.For(ctx, logger.ModuleHTTP).Debug("request served",
+				zap.String("method", request.Method),
+				zap.String("route", route),
+				zap.Int("status", c.Response().Status),
+				zap.Duration("duration", time.Since(start)))
+			return nil
+		}
+	}
+}
+
+type logLevelRequestBody struct {
+	// service or one of the modules, empty is the service level
+	Module string `json:"module"`
+	// empty reverts the module to its configured level
+	Level string `json:"level" validate:"omitempty,oneof=debug info warn error"`
+	// seconds until the level is reverted, zero keeps it until the next change or restart
+	TTL int `json:"ttl" validate:"gte=0"`
+}
+
+// logLevels lists the level of the service and of every module
+func logLevels(c echo.Context) error {
+	return c.JSON(http.StatusOK, responser.Response{
+		Message: "",
+		Success: true,
+		Data:    logger.Levels(),
+	})
+}
+
+// setLogLevel changes a level at runtime, debug logging of a single module for a few minutes
+// is the usual use
+func setLogLevel(c echo.Context) error {
+	log := logger.For(c.Request().Context(), logger.ModuleHTTP)
+
+	var requestBody logLevelRequestBody
+	if err := c.Bind(&requestBody); err != nil {
+		return c.JSON(http.StatusBadRequest, responser.Response{
+			Message: exceptions.InvalidInput,
+			Data:    "",
+			Success: false})
+	}
+	if err := validator.New().Struct(&requestBody); err != nil {
+		return c.JSON(http.StatusBadRequest, responser.Response{
+			Message: exceptions.InvalidLogLevel,
+			Data:    err.Error(),
+			Success: false})
+	}
+
+	status, err := logger.SetLevel(requestBody.Module, requestBody.Level, time.Duration(requestBody.TTL)*time.Second)
+	if err != nil {
+		if errors.Is(err, logger.ErrUnknownModule) || errors.Is(err, logger.ErrUnknownLevel) {
+			return c.JSON(http.StatusBadRequest, responser.Response{
+				Message: exceptions.InvalidLogLevel,
+				Data:    err.Error(),
+				Success: false})
+		}
+		return c.JSON(http.StatusInternalServerError, responser.Response{
+			Message: exceptions.InternalServerError,
+			Data:    "",
+			Success: false})
+	}
+
+	// logged at warn so the change shows up whatever the levels are
+	log.Warn("log level changed",
+		zap.String("module", status.Module),
+		zap.String("level", status.Level),
+		zap.Int("ttl", requestBody.TTL))
+	return c.JSON(http.StatusOK, responser.Response{
+		Message: "",
+		Success: true,
+		Data:    status,
+	})
+}
+
+// adminOnly lets only the principals of log.admins through, an empty list closes the
+// endpoints. The X-Principal header is trusted as is, so the gateway in front of us has to
+// set it and drop any value the caller sent.
+func adminOnly(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		principal := utils.GetPrincipal(c)
+		if principal == "" {
+			return c.JSON(http.StatusUnauthorized, responser.Response{
+				Message: exceptions.RequiredPrincipal,
+				Data:    "",
+				Success: false})
+		}
+		if !slices.Contains(config.C().Log.Admins, principal) {
+			return c.JSON(http.StatusForbidden, responser.Response{
+				Message: exceptions.StatusForbidden,
+				Data:    "",
+				Success: false})
+		}
+		return next(c)
+	}
+}
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"giftcard/pkg/utils"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAdminOnlyWithoutAdmins(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal string
+		want      int
+	}{
+		{name: "no principal", want: http.StatusUnauthorized},
+		{name: "any principal", principal: "alice", want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/log-levels", nil)
+			if tt.principal != "" {
+				req.Header.Set(utils.HeaderPrincipal, tt.principal)
+			}
+			rec := httptest.NewRecorder()
+			handler := adminOnly(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+			if err := handler(echo.New().NewContext(req, rec)); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != tt.want {
+				t.Fatalf("status %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+package routes
+
+import (
+	exchangeRateDelivery "giftcard/internal/modules/exchangerate/delivery/http"
+	"github.com/labstack/echo/v4"
+)
+
+func MapExchangeRateHandler(g *echo.Group, d *exchangeRateDelivery.ExchangeRateHandler) {
+	g.GET("/exchange-rates", d.ExchangeRates)
+	g.GET("/order/:id/exchange-rates", d.OrderExchangeRates)
+}
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	exportDelivery "giftcard/internal/modules/export/delivery/http"
+	"github.com/labstack/echo/v4"
+)
+
+func MapExportHandler(g *echo.Group, d *exportDelivery.ExportHandler) {
+	g.POST("/exports", d.CreateExport)
+	g.GET("/exports", d.ListExports)
+	g.GET("/exports/:id", d.GetExport)
+	g.GET("/exports/:id/download", d.DownloadExport)
+}
@@ -6,7 +6,15 @@ import (
 )
 
 func MapOrderHandler(g *echo.Group, d *orderDelivery.OrderHandler) {
+	g.GET("/orders", d.ListOrders)
 	g.POST("/order/create", d.CreateOrder)
 	g.POST("/order/confirm", d.ConfirmOrder)
+	g.POST("/order/cancel", d.CancelOrder)
+	g.POST("/order/approve", d.ApproveOrder)
+	g.POST("/order/reject", d.RejectOrder)
 	g.GET("/order/get/status", d.RetrieveOrder)
+	g.GET("/order/:id/events", d.OrderEvents)
+	g.POST("/order/reconcile", d.ReconcileOrder)
+	g.GET("/order/:id/invoice.pdf", d.InvoicePDF)
+	g.POST("/order/:id/invoice.pdf", d.InvoicePDF)
 }
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	orderImportDelivery "giftcard/internal/modules/orderimport/delivery/http"
+	"github.com/labstack/echo/v4"
+)
+
+func MapOrderImportHandler(g *echo.Group, d *orderImportDelivery.OrderImportHandler) {
+	g.POST("/order/import", d.CreateImport)
+	g.GET("/order/import", d.ListImports)
+	g.GET("/order/import/:id", d.GetImport)
+	g.GET("/order/import/:id/rows", d.ListRows)
+	g.POST("/order/import/:id/retry", d.RetryImport)
+}
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	refundDelivery "giftcard/internal/modules/refund/delivery/http"
+	"github.com/labstack/echo/v4"
+)
+
+func MapRefundHandler(g *echo.Group, d *refundDelivery.RefundHandler) {
+	g.POST("/refunds", d.CreateRefund)
+	g.GET("/refunds", d.ListRefunds)
+	g.GET("/refunds/outstanding", d.OutstandingRefunds)
+	g.POST("/refunds/:id/receive", d.ReceiveRefund)
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	scheduleDelivery "giftcard/internal/modules/schedule/delivery/http"
+	"github.com/labstack/echo/v4"
+)
+
+func MapScheduleHandler(g *echo.Group, d *scheduleDelivery.ScheduleHandler) {
+	g.POST("/order/schedules", d.CreateSchedule)
+	g.GET("/order/schedules", d.ListSchedules)
+	g.GET("/order/schedules/:id", d.GetSchedule)
+	g.POST("/order/schedules/:id/pause", d.PauseSchedule)
+	g.POST("/order/schedules/:id/resume", d.ResumeSchedule)
+	g.POST("/order/schedules/:id/run", d.RunSchedule)
+}
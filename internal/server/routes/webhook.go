@@ -0,0 +1,14 @@
+package routes
+
+import (
+	webhookDelivery "giftcard/internal/modules/webhook/delivery/http"
+	"github.com/labstack/echo/v4"
+)
+
+func MapWebhookHandler(g *echo.Group, d *webhookDelivery.WebhookHandler) {
+	g.POST("/webhooks", d.CreateSubscription)
+	g.GET("/webhooks", d.ListSubscriptions)
+	g.DELETE("/webhooks/:id", d.DeleteSubscription)
+	g.GET("/webhooks/deliveries", d.ListDeliveries)
+	g.POST("/webhooks/deliveries/:id/replay", d.ReplayDelivery)
+}
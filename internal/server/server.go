@@ -2,13 +2,22 @@ package server
 
 import (
 	"context"
-	"fmt"
+	"giftcard/internal/adaptor/metrics"
+	"giftcard/internal/adaptor/trace"
 	CustomerHttp "giftcard/internal/modules/customer/delivery/http"
+	ExchangeRateHttp "giftcard/internal/modules/exchangerate/delivery/http"
+	ExportHttp "giftcard/internal/modules/export/delivery/http"
 	OrderHttp "giftcard/internal/modules/order/delivery/http"
+	OrderImportHttp "giftcard/internal/modules/orderimport/delivery/http"
+	RefundHttp "giftcard/internal/modules/refund/delivery/http"
+	ScheduleHttp "giftcard/internal/modules/schedule/delivery/http"
 	ShopHttp "giftcard/internal/modules/shop/delivery/http"
+	WebhookHttp "giftcard/internal/modules/webhook/delivery/http"
 	"giftcard/internal/server/routes"
+	"giftcard/pkg/health"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/fx"
 	"log"
 	"net/http"
@@ -30,14 +39,28 @@ func NewServer(p DeliveryContainer) IServer {
 
 func (s *Server) SetUpServer(container DeliveryContainer) {
 	s.srv.Use(middleware.RequestID())
+	s.srv.Use(metrics.HTTPMiddleware(container.Registry))
+	s.srv.Use(trace.EchoMiddleware())
+	s.srv.Use(requestLogger())
 	v1 := s.srv.Group("/v1")
 	routes.MapShopHandler(v1, container.ShopHandler)
 	routes.MapCustomerHandler(v1, container.CustomerHandler)
 	routes.MapOrderHandler(v1, container.OrderHandler)
+	routes.MapWebhookHandler(v1, container.WebhookHandler)
+	routes.MapOrderImportHandler(v1, container.OrderImportHandler)
+	routes.MapExportHandler(v1, container.ExportHandler)
+	routes.MapExchangeRateHandler(v1, container.ExchangeRateHandler)
+	routes.MapScheduleHandler(v1, container.ScheduleHandler)
+	routes.MapRefundHandler(v1, container.RefundHandler)
 
-	s.srv.GET("/health", func(c echo.Context) error {
-		return c.String(200, fmt.Sprintf("Hi :)) i'm in healthy"))
-	})
+	s.srv.GET("/metrics", echo.WrapHandler(metrics.Handler(container.Registry)))
+	s.srv.GET("/livez", livez)
+	s.srv.GET("/readyz", readyz(container.Health))
+	s.srv.GET("/health", readyz(container.Health))
+
+	admin := s.srv.Group("/admin", adminOnly)
+	admin.GET("/log-levels", logLevels)
+	admin.PUT("/log-levels", setLogLevel)
 
 }
 
@@ -68,7 +91,15 @@ func (s *Server) Shutdown() error {
 
 type DeliveryContainer struct {
 	fx.In
-	ShopHandler     *ShopHttp.ShopHandler
-	OrderHandler    *OrderHttp.OrderHandler
-	CustomerHandler *CustomerHttp.CustomerInfoHandler
+	ShopHandler         *ShopHttp.ShopHandler
+	OrderHandler        *OrderHttp.OrderHandler
+	CustomerHandler     *CustomerHttp.CustomerInfoHandler
+	WebhookHandler      *WebhookHttp.WebhookHandler
+	OrderImportHandler  *OrderImportHttp.OrderImportHandler
+	ExportHandler       *ExportHttp.ExportHandler
+	ExchangeRateHandler *ExchangeRateHttp.ExchangeRateHandler
+	ScheduleHandler     *ScheduleHttp.ScheduleHandler
+	RefundHandler       *RefundHttp.RefundHandler
+	Registry            *prometheus.Registry
+	Health              *health.Registry
 }
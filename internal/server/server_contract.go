@@ -5,3 +5,9 @@ type IServer interface {
 	Shutdown() error
 	Run() error
 }
+
+type IGrpcServer interface {
+	SetUpServer(container GrpcDeliveryContainer)
+	Shutdown() error
+	Run() error
+}
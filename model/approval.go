@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// Approval decisions
+const (
+	ApprovalDecisionApprove = "approve"
+	ApprovalDecisionReject  = "reject"
+)
+
+// OrderApproval records one approver decision on an order, a principal decides once per order
+type OrderApproval struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	OrderRef  uint      `gorm:"column:order_ref;not null;uniqueIndex:idx_order_approval_principal,priority:1" json:"-"`
+	OrderID   string    `gorm:"column:order_id;not null" json:"orderId"`
+	Principal string    `gorm:"column:principal;not null;uniqueIndex:idx_order_approval_principal,priority:2" json:"principal"`
+	Decision  string    `gorm:"column:decision;not null" json:"decision"`
+	Comment   string    `gorm:"column:comment" json:"comment"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"createdAt"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// ExchangeRate is one provider rate, a pair keeps one row per provider modified date
+type ExchangeRate struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	BaseCurrency   string    `gorm:"column:base_currency;not null;uniqueIndex:idx_exchange_rate_pair_modified,priority:1" json:"baseCurrency"`
+	TargetCurrency string    `gorm:"column:target_currency;not null;uniqueIndex:idx_exchange_rate_pair_modified,priority:2" json:"targetCurrency"`
+	ModifiedDate   time.Time `gorm:"column:modified_date;not null;uniqueIndex:idx_exchange_rate_pair_modified,priority:3" json:"modifiedDate"`
+	Rate           float64   `gorm:"column:rate;not null" json:"rate"`
+	CreatedAt      time.Time `gorm:"column:created_at" json:"createdAt"`
+}
+
+// OrderExchangeRate links an order to the rates the provider priced it with
+type OrderExchangeRate struct {
+	OrderRef        uint `gorm:"column:order_ref;primaryKey"`
+	ExchangeRateRef uint `gorm:"column:exchange_rate_ref;primaryKey;index"`
+}
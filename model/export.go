@@ -0,0 +1,47 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// Export file formats
+const (
+	ExportCSV   = "csv"
+	ExportXLSX  = "xlsx"
+	ExportJSONL = "jsonl"
+)
+
+// Export job states
+const (
+	ExportPending    = "pending"
+	ExportProcessing = "processing"
+	ExportCompleted  = "completed"
+	ExportFailed     = "failed"
+)
+
+// OrderExport is an accounting export job, the file is kept until ExpiresAt
+type OrderExport struct {
+	gorm.Model
+	Format      string     `gorm:"column:format;not null" json:"format"`
+	From        time.Time  `gorm:"column:from_date;not null" json:"from"`
+	To          time.Time  `gorm:"column:to_date;not null" json:"to"`
+	Currency    string     `gorm:"column:currency" json:"currency"`
+	Team        string     `gorm:"column:team" json:"team"`
+	CreatedBy   string     `gorm:"column:created_by" json:"createdBy"`
+	Status      string     `gorm:"column:status;not null;index" json:"status"`
+	Rows        int        `gorm:"column:rows;not null;default:0" json:"rows"`
+	HasFile     bool       `gorm:"column:has_file;not null;default:false" json:"-"`
+	Error       string     `gorm:"column:error;type:text" json:"error,omitempty"`
+	LockedUntil *time.Time `gorm:"column:locked_until" json:"-"`
+	FinishedAt  *time.Time `gorm:"column:finished_at" json:"finishedAt"`
+	ExpiresAt   *time.Time `gorm:"column:expires_at;index" json:"expiresAt"`
+}
+
+// OrderExportFile is the written file of an export, kept in the database so every replica
+// can serve and purge it
+type OrderExportFile struct {
+	ExportID  uint   `gorm:"column:export_id;primaryKey;autoIncrement:false"`
+	Content   []byte `gorm:"column:content;type:bytea;not null"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,48 @@
+package model
+
+// Invoice line item types that adjust the price instead of buying a product
+const (
+	InvoiceItemDiscount = "discount"
+	InvoiceItemFee      = "fee"
+)
+
+// OrderInvoice is the provider invoice stored with the order, the only record of what we were charged
+type OrderInvoice struct {
+	PaymentMethod string          `json:"paymentMethod"`
+	Records       []InvoiceRecord `json:"records"`
+	Status        string          `json:"status"`
+	Total         float64         `json:"total"`
+	Wallet        string          `json:"wallet"`
+}
+
+type InvoiceRecord struct {
+	SKU   string             `json:"sku"`
+	Items []InvoiceItem      `json:"items"`
+	Total map[string]float64 `json:"total"`
+}
+
+// InvoiceItem is one line of a record, the rate fields carry the exchange rate used to price it
+type InvoiceItem struct {
+	Description    string  `json:"description"`
+	Type           string  `json:"type"`
+	Amount         float64 `json:"amount"`
+	Currency       string  `json:"currency"`
+	BaseCurrency   string  `json:"baseCurrency,omitempty"`
+	TargetCurrency string  `json:"targetCurrency,omitempty"`
+	Rate           float64 `json:"rate,omitempty"`
+	Quantity       int     `json:"quantity,omitempty"`
+	Quote          int     `json:"quote,omitempty"`
+}
+
+// Adjustment sums the items of the given type over every record, in the invoice wallet currency
+func (i *OrderInvoice) Adjustment(itemType string) float64 {
+	var total float64
+	for _, record := range i.Records {
+		for _, item := range record.Items {
+			if item.Type == itemType {
+				total += item.Amount
+			}
+		}
+	}
+	return total
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// InvoiceDocument is the rendered pdf of an order invoice, kept so the document
+// stays the same until it is explicitly regenerated
+type InvoiceDocument struct {
+	ID          uint      `gorm:"primaryKey"`
+	OrderRef    uint      `gorm:"column:order_ref;not null;uniqueIndex"`
+	Number      string    `gorm:"column:number;not null;uniqueIndex"`
+	Content     []byte    `gorm:"column:content;type:bytea;not null"`
+	GeneratedAt time.Time `gorm:"column:generated_at;not null"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
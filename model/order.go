@@ -1,19 +1,77 @@
 package model
 
 import (
-	"gorm.io/gorm"
 	"time"
 )
 
+// Local order states, every other status comes from the provider
+const (
+	// OrderStatusCreating marks an order written before the provider call
+	OrderStatusCreating = "creating"
+	// OrderStatusCreateFailed marks an order the provider refused to create
+	OrderStatusCreateFailed = "create_failed"
+	// OrderStatusOrphaned marks a creating order whose provider call never completed
+	OrderStatusOrphaned = "orphaned"
+	// OrderStatusExpired marks an order that was not confirmed before the provider expiry
+	OrderStatusExpired = "expired"
+	// OrderStatusCanceled marks an unconfirmed order abandoned through our API, the provider
+	// lets it expire
+	OrderStatusCanceled = "canceled"
+)
+
+// LocalStatuses are the states the provider knows nothing about, its status never replaces them
+var LocalStatuses = map[string]bool{
+	OrderStatusCreating:     true,
+	OrderStatusCreateFailed: true,
+	OrderStatusOrphaned:     true,
+	OrderStatusExpired:      true,
+	OrderStatusCanceled:     true,
+}
+
+// Provider invoice states that end the order lifecycle
+var (
+	DeliveredStatuses = map[string]bool{"completed": true, "delivered": true}
+	FailedStatuses    = map[string]bool{"failed": true, "rejected": true, "canceled": true, "cancelled": true}
+)
+
+// Order approval states, an order without a matching policy needs no approval
+const (
+	ApprovalNotRequired = ""
+	ApprovalPending     = "pending"
+	ApprovalApproved    = "approved"
+	ApprovalRejected    = "rejected"
+)
+
+// OrderStatusChangedEvent is emitted for status changes that are not a lifecycle event
+const OrderStatusChangedEvent = "order.status_changed"
+
 type Order struct {
-	gorm.Model
-	ID          uint `gorm:"primaryKey"`
-	SKU         string
-	OrderID     string
-	ProductType string
+	ID          uint   `gorm:"primaryKey"`
+	SKU         string `gorm:"index:idx_orders_sku_created,priority:1"`
+	OrderID     string `gorm:"uniqueIndex:idx_orders_order_id,where:order_id <> ''"`
+	ProductType string `gorm:"index:idx_orders_product_type_created,priority:1"`
 	Quote       uint
 	Quantity    uint
-	Status      string
-	CreatedAt   time.Time
-	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
+	Status      string  `gorm:"index:idx_orders_status_created,priority:1"`
+	Total       float64 `gorm:"not null;default:0"`
+	Currency    string
+	Country     string
+	CreatedBy   string        `gorm:"index:idx_orders_created_by_created,priority:1"`
+	Team        string        `gorm:"index"`
+	Invoice     *OrderInvoice `gorm:"type:text;serializer:json"`
+	ExpiresAt   *time.Time    `gorm:"index"`
+	ConfirmedAt *time.Time
+	ScheduleID  *uint `gorm:"index"`
+	// the listing indexes end with created_at so the default newest first page is an index scan
+	CreatedAt time.Time `gorm:"index:idx_orders_created,priority:1;index:idx_orders_status_created,priority:2;index:idx_orders_sku_created,priority:2;index:idx_orders_product_type_created,priority:2;index:idx_orders_created_by_created,priority:2"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+
+	// approval policy matched at creation, see config.Approval
+	ApprovalPolicy    string
+	RequiredApprovals int
+	ApprovalStatus    string
+
+	// LockFence is the fencing token of the last order lock owner that saved the order, a save
+	// under an older token is refused
+	LockFence int64 `gorm:"not null;default:0" json:"-"`
 }
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// Order import batch states
+const (
+	ImportPending             = "pending"
+	ImportProcessing          = "processing"
+	ImportCompleted           = "completed"
+	ImportCompletedWithErrors = "completed_with_errors"
+)
+
+// Order import row states
+const (
+	ImportRowPending    = "pending"
+	ImportRowProcessing = "processing"
+	ImportRowSucceeded  = "succeeded"
+	ImportRowFailed     = "failed"
+	// ImportRowUnknown marks a row whose provider call failed without an answer, the provider
+	// may hold its order so it is only retried once its order is reconciled
+	ImportRowUnknown = "unknown"
+)
+
+// OrderImport is a bulk order job created from an uploaded csv file
+type OrderImport struct {
+	gorm.Model
+	FileName      string     `gorm:"column:file_name" json:"fileName"`
+	CreatedBy     string     `gorm:"column:created_by" json:"createdBy"`
+	Team          string     `gorm:"column:team" json:"team"`
+	Status        string     `gorm:"column:status;not null;index" json:"status"`
+	ChunkSize     int        `gorm:"column:chunk_size;not null" json:"chunkSize"`
+	TotalRows     int        `gorm:"column:total_rows;not null" json:"totalRows"`
+	SucceededRows int        `gorm:"column:succeeded_rows;not null;default:0" json:"succeededRows"`
+	FailedRows    int        `gorm:"column:failed_rows;not null;default:0" json:"failedRows"`
+	UnknownRows   int        `gorm:"column:unknown_rows;not null;default:0" json:"unknownRows"`
+	LockedUntil   *time.Time `gorm:"column:locked_until" json:"-"`
+	FinishedAt    *time.Time `gorm:"column:finished_at" json:"finishedAt"`
+}
+
+// OrderImportRow is one csv line of an import, rows of the same chunk share a provider order
+type OrderImportRow struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	ImportID    uint   `gorm:"column:import_id;not null;index:idx_order_import_row,priority:1" json:"importId"`
+	RowNumber   int    `gorm:"column:row_number;not null;index:idx_order_import_row,priority:2" json:"rowNumber"`
+	ProductID   string `gorm:"column:product_id" json:"productId"`
+	SKU         string `gorm:"column:sku;not null" json:"sku"`
+	ProductType string `gorm:"column:product_type;not null" json:"productType"`
+	Quote       uint   `gorm:"column:quote;not null" json:"quote"`
+	Quantity    uint   `gorm:"column:quantity;not null" json:"quantity"`
+	Status      string `gorm:"column:status;not null" json:"status"`
+	OrderID     string `gorm:"column:order_id" json:"orderId"`
+	// OrderRef is the local order of a row in unknown status
+	OrderRef  *uint     `gorm:"column:order_ref" json:"orderRef"`
+	Error     string    `gorm:"column:error;type:text" json:"error"`
+	Attempts  int       `gorm:"column:attempts;not null;default:0" json:"attempts"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updatedAt"`
+}
@@ -0,0 +1,15 @@
+package model
+
+// OrderItem is one product line of an order. Order keeps the first line for the listings,
+// the items hold every line of a multi product order.
+type OrderItem struct {
+	ID          uint   `gorm:"primaryKey" json:"-"`
+	OrderRef    uint   `gorm:"column:order_ref;not null;index" json:"-"`
+	ProductID   string `gorm:"column:product_id" json:"productId"`
+	SKU         string `gorm:"column:sku;not null" json:"sku"`
+	ProductType string `gorm:"column:product_type;not null" json:"productType"`
+	Quote       uint   `gorm:"column:quote;not null" json:"quote"`
+	Quantity    uint   `gorm:"column:quantity;not null" json:"quantity"`
+	// Country is only looked up when an approval policy filters on it, empty when unknown
+	Country string `gorm:"column:country" json:"country"`
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// Outbox event states
+const (
+	OutboxPending   = "pending"
+	OutboxPublished = "published"
+)
+
+// OutboxEvent is written in the same transaction as the change it describes
+// and relayed to the event bus afterwards
+type OutboxEvent struct {
+	gorm.Model
+	EventID       string     `gorm:"column:event_id;not null;uniqueIndex"`
+	EventType     string     `gorm:"column:event_type;not null"`
+	AggregateID   string     `gorm:"column:aggregate_id;not null;index"`
+	Payload       string     `gorm:"column:payload;type:text;not null"`
+	Status        string     `gorm:"column:status;not null;index:idx_outbox_due,priority:1"`
+	Attempts      int        `gorm:"column:attempts;not null;default:0"`
+	NextAttemptAt time.Time  `gorm:"column:next_attempt_at;not null;index:idx_outbox_due,priority:2"`
+	LastError     string     `gorm:"column:last_error"`
+	PublishedAt   *time.Time `gorm:"column:published_at"`
+}
@@ -0,0 +1,54 @@
+package model
+
+import "time"
+
+// Refund reasons
+const (
+	RefundReasonFailed          = "failed"
+	RefundReasonPartialDelivery = "partial_delivery"
+)
+
+// Refund states, a refund stays outstanding until the whole expected amount came back
+const (
+	RefundPending           = "pending"
+	RefundPartiallyReceived = "partially_received"
+	RefundReceived          = "received"
+)
+
+// OrderRefund tracks money the provider owes back for a confirmed order that failed or was
+// only partly delivered
+type OrderRefund struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	OrderRef       uint       `gorm:"uniqueIndex:idx_order_refunds_order_reason,priority:1" json:"-"`
+	OrderID        string     `gorm:"index" json:"orderId"`
+	Reason         string     `gorm:"uniqueIndex:idx_order_refunds_order_reason,priority:2" json:"reason"`
+	Note           string     `json:"note"`
+	Currency       string     `gorm:"type:varchar(3);index" json:"currency"`
+	ExpectedAmount float64    `gorm:"type:numeric;not null" json:"expectedAmount"`
+	ReceivedAmount float64    `gorm:"type:numeric;not null;default:0" json:"receivedAmount"`
+	Status         string     `gorm:"index" json:"status"`
+	ReceivedAt     *time.Time `json:"receivedAt"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updatedAt"`
+}
+
+// Outstanding is the part of the refund that did not come back yet
+func (r *OrderRefund) Outstanding() float64 {
+	return r.ExpectedAmount - r.ReceivedAmount
+}
+
+// RefundLedgerCursor is the last wallet snapshot of a currency already matched against refunds
+type RefundLedgerCursor struct {
+	Currency  string `gorm:"primaryKey;type:varchar(3)"`
+	WalletID  uint
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// OutstandingRefunds sums the open refunds of one currency
+type OutstandingRefunds struct {
+	Currency    string  `json:"currency"`
+	Count       int     `json:"count"`
+	Expected    float64 `json:"expected"`
+	Received    float64 `json:"received"`
+	Outstanding float64 `json:"outstanding"`
+}
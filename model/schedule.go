@@ -0,0 +1,70 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// Order schedule states
+const (
+	ScheduleActive = "active"
+	SchedulePaused = "paused"
+)
+
+// Schedule run outcomes
+const (
+	// ScheduleRunConfirmed marks a run whose order was created and confirmed
+	ScheduleRunConfirmed = "confirmed"
+	// ScheduleRunCreated marks a run whose order was created and left for a manual confirm
+	ScheduleRunCreated = "created"
+	// ScheduleRunOverBudget marks a run whose order was created but not confirmed for exceeding a budget
+	ScheduleRunOverBudget = "over_budget"
+	// ScheduleRunPendingApproval marks a run whose order waits for the approval policy
+	ScheduleRunPendingApproval = "pending_approval"
+	// ScheduleRunSkipped marks a run that created no order since the monthly budget was spent
+	ScheduleRunSkipped = "skipped"
+	// ScheduleRunFailed marks a run whose order could not be created or confirmed
+	ScheduleRunFailed = "failed"
+)
+
+// ScheduleProduct is one product line ordered on every run
+type ScheduleProduct struct {
+	ProductID   string `json:"productId"`
+	SKU         string `json:"sku"`
+	ProductType string `json:"productType"`
+	Quote       uint   `json:"quote"`
+	Quantity    uint   `json:"quantity"`
+}
+
+// OrderSchedule creates an order from the same product list every time its cron spec fires.
+// Budgets are in the wallet currency, zero means no limit.
+type OrderSchedule struct {
+	gorm.Model
+	Name          string            `gorm:"column:name;not null" json:"name"`
+	Spec          string            `gorm:"column:spec;not null" json:"spec"`
+	Timezone      string            `gorm:"column:timezone;not null;default:UTC" json:"timezone"`
+	Products      []ScheduleProduct `gorm:"column:products;type:text;serializer:json" json:"products"`
+	AutoConfirm   bool              `gorm:"column:auto_confirm;not null;default:false" json:"autoConfirm"`
+	RunBudget     float64           `gorm:"column:run_budget;not null;default:0" json:"runBudget"`
+	MonthlyBudget float64           `gorm:"column:monthly_budget;not null;default:0" json:"monthlyBudget"`
+	Status        string            `gorm:"column:status;not null;index:idx_order_schedules_due,priority:1" json:"status"`
+	NextRunAt     *time.Time        `gorm:"column:next_run_at;index:idx_order_schedules_due,priority:2" json:"nextRunAt"`
+	LastRunAt     *time.Time        `gorm:"column:last_run_at" json:"lastRunAt"`
+	RunRequested  bool              `gorm:"column:run_requested;not null;default:false" json:"runRequested"`
+	CreatedBy     string            `gorm:"column:created_by" json:"createdBy"`
+	Team          string            `gorm:"column:team" json:"team"`
+}
+
+// OrderScheduleRun records one execution of a schedule and the order it created
+type OrderScheduleRun struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	ScheduleID   uint      `gorm:"column:schedule_id;not null;index" json:"scheduleId"`
+	OrderID      string    `gorm:"column:order_id" json:"orderId"`
+	Status       string    `gorm:"column:status;not null" json:"status"`
+	Total        float64   `gorm:"column:total;not null;default:0" json:"total"`
+	Currency     string    `gorm:"column:currency" json:"currency"`
+	Error        string    `gorm:"column:error;type:text" json:"error,omitempty"`
+	ScheduledFor time.Time `gorm:"column:scheduled_for;not null" json:"scheduledFor"`
+	Manual       bool      `gorm:"column:manual;not null;default:false" json:"manual"`
+	CreatedAt    time.Time `gorm:"column:created_at" json:"createdAt"`
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// Order lifecycle event types delivered to webhook subscribers
+const (
+	OrderCreatedEvent   = "order.created"
+	OrderConfirmedEvent = "order.confirmed"
+	OrderDeliveredEvent = "order.delivered"
+	OrderFailedEvent    = "order.failed"
+	OrderExpiredEvent   = "order.expired"
+	OrderApprovedEvent  = "order.approved"
+	OrderRejectedEvent  = "order.rejected"
+	OrderCanceledEvent  = "order.canceled"
+)
+
+// Webhook delivery states
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+type WebhookSubscription struct {
+	gorm.Model
+	URL        string   `gorm:"column:url;not null" json:"url"`
+	Secret     string   `gorm:"column:secret;not null" json:"secret,omitempty"`
+	EventTypes []string `gorm:"column:event_types;type:text;serializer:json" json:"eventTypes"`
+	Active     bool     `gorm:"column:active;not null;default:true" json:"active"`
+}
+
+type WebhookDelivery struct {
+	gorm.Model
+	SubscriptionID uint       `gorm:"column:subscription_id;not null;uniqueIndex:idx_webhook_delivery_event,priority:1" json:"subscriptionId"`
+	EventID        string     `gorm:"column:event_id;not null;uniqueIndex:idx_webhook_delivery_event,priority:2" json:"eventId"`
+	EventType      string     `gorm:"column:event_type;not null" json:"eventType"`
+	Payload        string     `gorm:"column:payload;type:text;not null" json:"payload"`
+	Status         string     `gorm:"column:status;not null;index:idx_webhook_delivery_due,priority:1" json:"status"`
+	Attempts       int        `gorm:"column:attempts;not null;default:0" json:"attempts"`
+	NextAttemptAt  time.Time  `gorm:"column:next_attempt_at;not null;index:idx_webhook_delivery_due,priority:2" json:"nextAttemptAt"`
+	LastStatusCode int        `gorm:"column:last_status_code" json:"lastStatusCode"`
+	LastError      string     `gorm:"column:last_error" json:"lastError"`
+	DeliveredAt    *time.Time `gorm:"column:delivered_at" json:"deliveredAt"`
+}
@@ -0,0 +1,91 @@
+package cron
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec string
+		err  string
+	}{
+		{spec: "*/15 * * * *"},
+		{spec: "0 9-17 * * mon-fri"},
+		{spec: "0 0 1,15 jan,jul *"},
+		{spec: "30 2 * * 7"},
+		{spec: "@daily"},
+		{spec: "@every 90m"},
+		{spec: "* * * *", err: "expected 5 fields, got 4"},
+		{spec: "60 * * * *", err: "invalid minute"},
+		{spec: "0 24 * * *", err: "invalid hour"},
+		{spec: "0 0 0 * *", err: "invalid day of month"},
+		{spec: "0 0 * 13 *", err: "invalid month"},
+		{spec: "0 0 * * 8", err: "invalid day of week"},
+		{spec: "0 17-9 * * *", err: "invalid hour range"},
+		{spec: "*/0 * * * *", err: "invalid minute step"},
+		{spec: "0 0 * foo *", err: "invalid month"},
+		{spec: "@every 30s", err: "at least a minute"},
+		{spec: "@every soon", err: "invalid @every duration"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := Parse(tt.spec)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	tehran, err := time.LoadLocation("Asia/Tehran")
+	if err != nil {
+		t.Skip("no time zone data")
+	}
+	at := func(value string) time.Time {
+		parsed, err := time.ParseInLocation("2006-01-02 15:04:05", value, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{name: "strictly after", spec: "*/15 * * * *", from: at("2024-03-10 10:15:00"), want: at("2024-03-10 10:30:00")},
+		{name: "seconds are dropped", spec: "*/15 * * * *", from: at("2024-03-10 10:14:59"), want: at("2024-03-10 10:15:00")},
+		{name: "next day", spec: "30 2 * * *", from: at("2024-03-10 03:00:00"), want: at("2024-03-11 02:30:00")},
+		{name: "weekday range skips the weekend", spec: "0 9 * * mon-fri", from: at("2024-03-08 10:00:00"), want: at("2024-03-11 09:00:00")},
+		{name: "7 is sunday", spec: "0 0 * * 7", from: at("2024-03-10 10:00:00"), want: at("2024-03-17 00:00:00")},
+		{name: "either day field", spec: "0 0 1 * mon", from: at("2024-03-19 00:00:00"), want: at("2024-03-25 00:00:00")},
+		{name: "either day field weekday first", spec: "0 0 15 * fri", from: at("2024-03-10 00:00:00"), want: at("2024-03-15 00:00:00")},
+		{name: "leap day", spec: "0 0 29 feb *", from: at("2024-03-01 00:00:00"), want: at("2028-02-29 00:00:00")},
+		{name: "step from a value", spec: "10/20 * * * *", from: at("2024-03-10 10:31:00"), want: at("2024-03-10 10:50:00")},
+		{name: "never", spec: "0 0 30 feb *", from: at("2024-03-10 00:00:00"), want: time.Time{}},
+		{name: "every", spec: "@every 90m", from: at("2024-03-10 10:00:30"), want: at("2024-03-10 11:30:30")},
+		{name: "location of t", spec: "0 9 * * *", from: time.Date(2024, 3, 10, 10, 0, 0, 0, tehran), want: time.Date(2024, 3, 11, 9, 0, 0, 0, tehran)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+				t.Fatalf("Next(%s) = %s, want %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// AllEvents subscribes a handler to every event type
+const AllEvents = "*"
+
+type Event struct {
+	ID          string
+	Type        string
+	AggregateID string
+	Payload     []byte
+	OccurredAt  time.Time
+}
+
+type Handler func(ctx context.Context, event Event) error
+
+// Bus is an in-process, synchronous event bus
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+func New() *Bus {
+	return &Bus{handlers: map[string][]Handler{}}
+}
+
+func (b *Bus) Subscribe(eventType string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+// Publish runs every matching handler and joins their errors,
+// handlers must be idempotent because a failed event is published again
+func (b *Bus) Publish(ctx context.Context, event Event) error {
+	b.mu.RLock()
+	handlers := append([]Handler{}, b.handlers[event.Type]...)
+	handlers = append(handlers, b.handlers[AllEvents]...)
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
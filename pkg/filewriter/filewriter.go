@@ -1,45 +1,86 @@
 package filewriter
 
 import (
-	"io"
-	"log"
+	"gopkg.in/natefinch/lumberjack.v2"
 	"os"
+	"path/filepath"
+	"sync"
+	"time"
 )
 
-type file struct {
-	file *os.File
+type Options struct {
+	// megabytes the file grows to before it is rotated
+	MaxSize int
+	// the file is rotated once it is older, zero rotates on size only
+	RotateEvery time.Duration
+	// days rotated files are kept, zero keeps them
+	MaxAge int
+	// rotated files that are kept, zero keeps them all
+	MaxBackups int
+	// gzip rotated files
+	Compress bool
 }
 
-func NewFile(filename string, flag int) *file {
-	f, err := os.OpenFile(filename, flag, 0666)
-	if err != nil {
-		log.Fatalf("error opening file: %v", err)
-	}
-	return &file{file: f}
+// Rotating is a file that is rotated by size and age, rotated files get a timestamp in
+// their name next to the file
+type Rotating struct {
+	mu       sync.Mutex
+	file     *lumberjack.Logger
+	every    time.Duration
+	openedAt time.Time
 }
 
-func (l *file) Write(p []byte) (int, error) {
-	n, err := l.file.Write(p)
-	if err != nil {
-		log.Println(" \n \n ERROR: ", err, n, " \n \n")
+func NewRotating(filename string, options Options) (*Rotating, error) {
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return nil, err
 	}
-
-	return n, err
+	openedAt := time.Now()
+	// a file left by the previous run keeps its age
+	if info, err := os.Stat(filename); err == nil {
+		openedAt = info.ModTime()
+	}
+	return &Rotating{
+		file: &lumberjack.Logger{
+			Filename:   filename,
+			MaxSize:    options.MaxSize,
+			MaxAge:     options.MaxAge,
+			MaxBackups: options.MaxBackups,
+			LocalTime:  true,
+			Compress:   options.Compress,
+		},
+		every:    options.RotateEvery,
+		openedAt: openedAt,
+	}, nil
 }
 
-func (f *file) ReadAll() (string, error) {
-	// Seek to the beginning of the file
-	_, err := f.file.Seek(0, io.SeekStart)
-	if err != nil {
-		return "", err
+func (r *Rotating) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.every > 0 && time.Since(r.openedAt) >= r.every {
+		if err := r.file.Rotate(); err != nil {
+			return 0, err
+		}
+		r.openedAt = time.Now()
 	}
+	return r.file.Write(p)
+}
 
-	// Read the entire file into a byte slice
-	data, err := io.ReadAll(f.file)
-	if err != nil {
-		return "", err
-	}
+// Rotate starts a new file now
+func (r *Rotating) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.openedAt = time.Now()
+	return r.file.Rotate()
+}
+
+// Sync is a no-op, every write goes straight to the file
+func (r *Rotating) Sync() error {
+	return nil
+}
 
-	// Convert the byte slice to a string and return it
-	return string(data), nil
+// Close closes the file, a later write opens it again
+func (r *Rotating) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
 }
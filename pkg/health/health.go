@@ -0,0 +1,156 @@
+package health
+
+import (
+	"context"
+	"giftcard/config"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Report states
+const (
+	StatusOK          = "ok"
+	StatusDegraded    = "degraded"
+	StatusUnavailable = "unavailable"
+	StatusFailed      = "failed"
+)
+
+const defaultTimeout = 2 * time.Second
+
+// Checker returns nil when the dependency is usable, it must give up when ctx is done
+type Checker func(ctx context.Context) error
+
+type check struct {
+	name    string
+	timeout time.Duration
+	checker Checker
+}
+
+type Result struct {
+	Status     string `json:"status"`
+	Critical   bool   `json:"critical"`
+	DurationMs int64  `json:"durationMs"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Report is the readiness breakdown, Status is unavailable when a critical check failed and
+// degraded when only others did
+type Report struct {
+	Status    string            `json:"status"`
+	CheckedAt time.Time         `json:"checkedAt"`
+	Checks    map[string]Result `json:"checks"`
+}
+
+// Registry holds the checks the adaptors register while they are constructed
+type Registry struct {
+	mu     sync.Mutex
+	checks []check
+
+	runMu    sync.Mutex
+	cached   *Report
+	cachedAt time.Time
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a check, a zero timeout uses health.timeout from config
+func (r *Registry) Register(name string, timeout time.Duration, checker Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, check{name: name, timeout: timeout, checker: checker})
+}
+
+// Ready runs every check concurrently, or returns the last report while it is younger than
+// health.cache_ttl so frequent probes do not reach the dependencies every time
+func (r *Registry) Ready(ctx context.Context) Report {
+	confs := config.C().Health
+	ttl := time.Duration(confs.CacheTTL) * time.Second
+
+	// one probe runs the checks, the ones arriving meanwhile wait for its report
+	r.runMu.Lock()
+	defer r.runMu.Unlock()
+	if r.cached != nil && time.Since(r.cachedAt) < ttl {
+		return *r.cached
+	}
+
+	// the report is shared, a probe hanging up must not fail it for the others
+	report := r.run(context.WithoutCancel(ctx), confs)
+	r.cached = &report
+	r.cachedAt = time.Now()
+	return report
+}
+
+func (r *Registry) run(ctx context.Context, confs config.Health) Report {
+	r.mu.Lock()
+	checks := slices.Clone(r.checks)
+	r.mu.Unlock()
+
+	defaultTimeout := defaultTimeout
+	if confs.Timeout > 0 {
+		defaultTimeout = time.Duration(confs.Timeout) * time.Second
+	}
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c check) {
+			defer wg.Done()
+			timeout := c.timeout
+			if timeout <= 0 {
+				timeout = defaultTimeout
+			}
+			results[i] = runCheck(ctx, c.checker, timeout)
+			results[i].Critical = slices.Contains(confs.Critical, c.name)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{
+		Status:    StatusOK,
+		CheckedAt: time.Now(),
+		Checks:    make(map[string]Result, len(checks)),
+	}
+	for i, c := range checks {
+		result := results[i]
+		report.Checks[c.name] = result
+		if result.Status == StatusOK {
+			continue
+		}
+		if result.Critical {
+			report.Status = StatusUnavailable
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// runCheck stops waiting at the timeout even when the checker ignores ctx
+func runCheck(ctx context.Context, checker Checker, timeout time.Duration) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- checker(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := Result{Status: StatusOK, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = StatusFailed
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,221 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"giftcard/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Modules with a level of their own, set them with log.modules or at runtime. A module
+// without a level follows the service level.
+const (
+	ModuleGiftCard = "giftcard"
+	ModuleOrder    = "order"
+	ModuleShop     = "shop"
+	ModuleCustomer = "customer"
+	ModuleHTTP     = "http"
+)
+
+// ServiceLevel names the level of everything outside the modules
+const ServiceLevel = "service"
+
+var (
+	ErrUnknownModule = errors.New("unknown log module")
+	ErrUnknownLevel  = errors.New("unknown log level, use debug, info, warn or error")
+)
+
+// LevelStatus is the level a module logs at, Configured is empty for a module following
+// the service level and ExpiresAt is set while a runtime change waits to be reverted
+type LevelStatus struct {
+	Module     string     `json:"module"`
+	Level      string     `json:"level"`
+	Configured string     `json:"configured"`
+	Runtime    bool       `json:"runtime"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// levelState is the level of the service or of one module, level and own are read on every
+// log call, the rest is guarded by levelsMu
+type levelState struct {
+	level zap.AtomicLevel
+	// own is false for a module that follows the service level
+	own atomic.Bool
+
+	configured string
+	runtime    bool
+	expiresAt  time.Time
+	revert     *time.Timer
+	// change counts the runtime changes, a revert only applies to the change that set it
+	change uint64
+}
+
+func (s *levelState) Enabled(l zapcore.Level) bool {
+	if s.own.Load() {
+		return s.level.Enabled(l)
+	}
+	return service.level.Enabled(l)
+}
+
+func (s *levelState) Level() zapcore.Level {
+	if s.own.Load() {
+		return s.level.Level()
+	}
+	return service.level.Level()
+}
+
+// set applies value, an empty value makes a module follow the service level again
+func (s *levelState) set(value string) {
+	if value == "" {
+		s.own.Store(false)
+		return
+	}
+	s.level.SetLevel(parseLevel(value))
+	s.own.Store(true)
+}
+
+var (
+	levelsMu sync.Mutex
+	service  = newLevelState(true)
+	// modules is filled once, only the states change
+	modules = map[string]*levelState{
+		ModuleGiftCard: newLevelState(false),
+		ModuleOrder:    newLevelState(false),
+		ModuleShop:     newLevelState(false),
+		ModuleCustomer: newLevelState(false),
+		ModuleHTTP:     newLevelState(false),
+	}
+)
+
+func newLevelState(own bool) *levelState {
+	state := &levelState{level: zap.NewAtomicLevelAt(zap.InfoLevel)}
+	state.own.Store(own)
+	return state
+}
+
+func stateOf(module string) (*levelState, error) {
+	if module == ServiceLevel || module == "" {
+		return service, nil
+	}
+	state, ok := modules[module]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownModule, module)
+	}
+	return state, nil
+}
+
+// enablerOf is the level of module, unknown modules log at the service level
+func enablerOf(module string) zapcore.LevelEnabler {
+	if state, ok := modules[module]; ok {
+		return state
+	}
+	return service
+}
+
+// applyConfig takes the configured levels, levels changed at runtime keep their value until
+// they are reverted
+func applyConfig(conf config.Log) {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	service.configured = conf.Level
+	if !service.runtime {
+		service.set(conf.Level)
+	}
+	for name, state := range modules {
+		state.configured = conf.Modules[name]
+		if !state.runtime {
+			state.set(state.configured)
+		}
+	}
+}
+
+// SetLevel changes the level of module until ttl passes, a zero ttl keeps it until the next
+// change or restart. An empty level reverts module to its configured level.
+func SetLevel(module string, level string, ttl time.Duration) (LevelStatus, error) {
+	state, err := stateOf(module)
+	if err != nil {
+		return LevelStatus{}, err
+	}
+	if level != "" {
+		if _, err := zapcore.ParseLevel(level); err != nil {
+			return LevelStatus{}, ErrUnknownLevel
+		}
+	}
+
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	state.change++
+	if state.revert != nil {
+		state.revert.Stop()
+		state.revert = nil
+	}
+	state.expiresAt = time.Time{}
+	if level == "" {
+		state.runtime = false
+		state.set(state.configured)
+		return statusOf(nameOf(module), state), nil
+	}
+
+	state.runtime = true
+	state.set(level)
+	if ttl > 0 {
+		state.expiresAt = time.Now().Add(ttl)
+		change := state.change
+		state.revert = time.AfterFunc(ttl, func() {
+			revert(module, state, change)
+		})
+	}
+	return statusOf(nameOf(module), state), nil
+}
+
+// revert puts a runtime change back once its ttl passed, unless it was changed again meanwhile
+func revert(module string, state *levelState, change uint64) {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	if state.change != change {
+		return
+	}
+	state.revert = nil
+	state.runtime = false
+	state.expiresAt = time.Time{}
+	state.set(state.configured)
+	zap.L().Info("log level reverted",
+		zap.String("module", nameOf(module)),
+		zap.String("level", state.Level().String()))
+}
+
+// Levels lists the service level and every module level
+func Levels() []LevelStatus {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	statuses := []LevelStatus{statusOf(ServiceLevel, service)}
+	for _, name := range []string{ModuleGiftCard, ModuleOrder, ModuleShop, ModuleCustomer, ModuleHTTP} {
+		statuses = append(statuses, statusOf(name, modules[name]))
+	}
+	return statuses
+}
+
+func statusOf(name string, state *levelState) LevelStatus {
+	status := LevelStatus{
+		Module:     name,
+		Level:      state.Level().String(),
+		Configured: state.configured,
+		Runtime:    state.runtime,
+	}
+	if !state.expiresAt.IsZero() {
+		expiresAt := state.expiresAt
+		status.ExpiresAt = &expiresAt
+	}
+	return status
+}
+
+func nameOf(module string) string {
+	if module == "" {
+		return ServiceLevel
+	}
+	return module
+}
@@ -10,20 +10,22 @@ import (
 	"io"
 	"log"
 	"os"
+	"reflect"
 
 	"giftcard/config"
 	"giftcard/internal/adaptor/logstash"
+	"giftcard/pkg/filewriter"
 )
 
-func InitGlobalLogger(lc fx.Lifecycle, L *logstash.LogStash) error {
-	// TODO: if you need to write log in file in a critical scenario use FILE and initial a file to pass it as writer in config logger
-	//path, err := os.Getwd()
-	//if err != nil {
-	//	log.Fatal(err)
-	//}
-	//file := filewriter.NewFile(path+"/logs/"+config.C().Service.Name, os.O_RDWR|os.O_CREATE|os.O_APPEND)
-	logger := configLogger(L)
+func InitGlobalLogger(lc fx.Lifecycle, L *logstash.LogStash, file *filewriter.Rotating) error {
+	var always zapcore.WriteSyncer
+	if file != nil && config.C().Log.File.Always {
+		always = file
+	}
+	applyConfig(config.C().Log)
+	logger := configLogger(L, always)
 	zap.ReplaceGlobals(logger)
+	config.OnReload(reloadLevel)
 
 	lc.Append(fx.Hook{
 		OnStop: func(c context.Context) error {
@@ -37,8 +39,11 @@ func InitGlobalLogger(lc fx.Lifecycle, L *logstash.LogStash) error {
 	return nil
 }
 
-func configLogger(writer io.Writer) *zap.Logger {
-	logLevel := getLogLevel()
+// configLogger writes to the console and ships to logstash, file gets every line as well
+// when it is set. The cores take every level, leveledCore filters with the service level
+// and For swaps in the level of a module.
+func configLogger(writer io.Writer, file zapcore.WriteSyncer) *zap.Logger {
+	logLevel := zapcore.DebugLevel
 
 	wZapCore := ecszap.NewCore(
 		ecszap.NewDefaultEncoderConfig(),
@@ -52,31 +57,84 @@ func configLogger(writer io.Writer) *zap.Logger {
 		zapcore.AddSync(os.Stdout),
 		logLevel,
 	)
-	core := zapcore.NewTee(terminalZapCore, wZapCore)
+	cores := []zapcore.Core{terminalZapCore, wZapCore}
+	if file != nil {
+		cores = append(cores, ecszap.NewCore(ecszap.NewDefaultEncoderConfig(), file, logLevel))
+	}
+	core := leveledCore{Core: zapcore.NewTee(cores...), level: service}
 	logger := zap.New(core, zap.AddCaller())
 	return logger.With(zap.String("service.name", config.C().Service.Name))
 }
 
-func getLogLevel() zapcore.Level {
-	//if config.C().Service {
-	//	return zap.DebugLevel
-	//}
-	return zap.DebugLevel
+// leveledCore filters entries with a level of its own so module loggers share the cores of
+// the service logger
+type leveledCore struct {
+	zapcore.Core
+	level zapcore.LevelEnabler
+}
+
+func (c leveledCore) Enabled(l zapcore.Level) bool {
+	return c.level.Enabled(l)
+}
+
+func (c leveledCore) Level() zapcore.Level {
+	return zapcore.LevelOf(c.level)
+}
+
+func (c leveledCore) With(fields []zapcore.Field) zapcore.Core {
+	return leveledCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c leveledCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.level.Enabled(entry.Level) {
+		return checked
+	}
+	return c.Core.Check(entry, checked)
+}
+
+// reloadLevel follows log.level and log.modules on config reload
+func reloadLevel(previous, current *config.Config) {
+	if previous.Log.Level != current.Log.Level || !reflect.DeepEqual(previous.Log.Modules, current.Log.Modules) {
+		applyConfig(current.Log)
+		zap.L().Info("log level changed",
+			zap.String("level", current.Log.Level),
+			zap.Any("modules", current.Log.Modules))
+	}
+}
+
+func parseLevel(value string) zapcore.Level {
+	parsed, err := zapcore.ParseLevel(value)
+	if err != nil {
+		return zap.InfoLevel
+	}
+	return parsed
 }
 
 const (
 	ZapCtxKey = "zap"
 )
 
+// FromContext is the logger of the request ctx belongs to, workers and commands have none
+// and get the global logger
 func FromContext(ctx context.Context) *zap.Logger {
 	logger, ok := ctx.Value(ZapCtxKey).(*zap.Logger)
 	if !ok {
 		logger = zap.L()
-		log.Print("cannot extract logger from context")
 	}
 	return logger
 }
 
+// For is the logger of ctx at the level of module
+func For(ctx context.Context, module string) *zap.Logger {
+	enabler := enablerOf(module)
+	return FromContext(ctx).WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		if leveled, ok := core.(leveledCore); ok {
+			return leveledCore{Core: leveled.Core, level: enabler}
+		}
+		return core
+	}))
+}
+
 func ToContext(ctx context.Context, l *zap.Logger) context.Context {
 	return context.WithValue(ctx, ZapCtxKey, l)
 }
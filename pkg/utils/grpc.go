@@ -1,22 +1,45 @@
 package utils
 
-//import (
-//	"context"
-//	"go.opentelemetry.io/otel/trace"
-//	"go.uber.org/zap"
-//	"google.golang.org/grpc"
-//	"giftCard/pkg/logger"
-//)
+import (
+	"encoding/json"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+)
 
-//func GrpcLogInjector(log *zap.Logger) grpc.UnaryServerInterceptor {
-//	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
-//		traceId := trace.SpanFromContext(ctx).SpanContext().TraceID().String()
-//
-//		lg := log.With(
-//			zap.Any("TRACE.ID", traceId))
-//
-//		ctx = logger.ToContext(ctx, lg)
-//
-//		return handler(ctx, req)
-//	}
-//}
+// MetadataCarrier adapts grpc metadata to the otel TextMapCarrier interface
+type MetadataCarrier metadata.MD
+
+func (m MetadataCarrier) Get(key string) string {
+	values := metadata.MD(m).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (m MetadataCarrier) Set(key string, value string) {
+	metadata.MD(m).Set(key, value)
+}
+
+func (m MetadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// ToProtoValue converts any json serializable value to a protobuf Value
+func ToProtoValue(v any) (*structpb.Value, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	value := &structpb.Value{}
+	if err := protojson.Unmarshal(b, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
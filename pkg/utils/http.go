@@ -32,17 +32,55 @@ func GetRequestCtx(c echo.Context) context.Context {
 	return context.WithValue(c.Request().Context(), ReqIDCtxKey{}, GetRequestID(c))
 }
 
-// Get config path for local or docker
-func GetConfigPath(configPath string) string {
-	if configPath == "docker" {
-		return "./config/config-docker"
-	}
-	return "./config/config-local"
+// Get config file of a profile, profiles live next to the config package as config-<profile>.yaml
+func GetConfigPath(profile string) string {
+	return "./config/config-" + profile + ".yaml"
 }
 
 // UserCtxKey is a key used for the User object in the context
 type UserCtxKey struct{}
 
+// HeaderPrincipal carries the authenticated caller set by the gateway in front of us
+const HeaderPrincipal = "X-Principal"
+
+// HeaderTeam carries the team of the authenticated caller, orders are reported per team
+const HeaderTeam = "X-Team"
+
+// TeamCtxKey is a key used for the caller team in the context
+type TeamCtxKey struct{}
+
+// Get the authenticated principal from echo context
+func GetPrincipal(c echo.Context) string {
+	return c.Request().Header.Get(HeaderPrincipal)
+}
+
+// Get context with the principal, use cases read it back with PrincipalFromCtx
+func WithPrincipal(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, UserCtxKey{}, principal)
+}
+
+// Get the principal stored by WithPrincipal
+func PrincipalFromCtx(ctx context.Context) string {
+	principal, _ := ctx.Value(UserCtxKey{}).(string)
+	return principal
+}
+
+// Get the caller team from echo context
+func GetTeam(c echo.Context) string {
+	return c.Request().Header.Get(HeaderTeam)
+}
+
+// Get context with the caller team
+func WithTeam(ctx context.Context, team string) context.Context {
+	return context.WithValue(ctx, TeamCtxKey{}, team)
+}
+
+// Get the team stored by WithTeam
+func TeamFromCtx(ctx context.Context) string {
+	team, _ := ctx.Value(TeamCtxKey{}).(string)
+	return team
+}
+
 // Get user ip address
 func GetIPAddress(c echo.Context) string {
 	return c.Request().RemoteAddr